@@ -12,6 +12,7 @@ interface Student {
   age: number;
   class_id?: number;
   roll_no?: string;
+  photo_url?: string;
 }
 
 export default function StudentList() {
@@ -244,11 +245,19 @@ export default function StudentList() {
                   <TableRow key={student.id}>
                     <TableCell>
                       <div className="flex items-center space-x-3">
-                        <div className="w-8 h-8 bg-slate-100 rounded-full flex items-center justify-center">
-                          <span className="text-slate-600 font-medium text-sm">
-                            {student.name.charAt(0).toUpperCase()}
-                          </span>
-                        </div>
+                        {student.photo_url ? (
+                          <img
+                            src={`http://localhost:8082${student.photo_url}&size=64`}
+                            alt=""
+                            className="w-8 h-8 rounded-full object-cover"
+                          />
+                        ) : (
+                          <div className="w-8 h-8 bg-slate-100 rounded-full flex items-center justify-center">
+                            <span className="text-slate-600 font-medium text-sm">
+                              {student.name.charAt(0).toUpperCase()}
+                            </span>
+                          </div>
+                        )}
                         <div>
                           <p className="font-medium">{student.name}</p>
                         </div>
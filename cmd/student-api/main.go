@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
@@ -10,12 +11,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/tukesh1/student-api/internal/alerts"
+	"github.com/tukesh1/student-api/internal/bus"
+	"github.com/tukesh1/student-api/internal/checkin"
 	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/http/handlers/alert"
+	"github.com/tukesh1/student-api/internal/http/handlers/analytics"
+	"github.com/tukesh1/student-api/internal/http/handlers/attachment"
+	"github.com/tukesh1/student-api/internal/http/handlers/attendance"
+	"github.com/tukesh1/student-api/internal/http/handlers/calendar"
+	"github.com/tukesh1/student-api/internal/http/handlers/changes"
 	"github.com/tukesh1/student-api/internal/http/handlers/class"
+	"github.com/tukesh1/student-api/internal/http/handlers/enrollment"
+	"github.com/tukesh1/student-api/internal/http/handlers/guardian"
 	"github.com/tukesh1/student-api/internal/http/handlers/health"
+	"github.com/tukesh1/student-api/internal/http/handlers/importer"
+	"github.com/tukesh1/student-api/internal/http/handlers/kiosk"
+	"github.com/tukesh1/student-api/internal/http/handlers/leave"
+	"github.com/tukesh1/student-api/internal/http/handlers/notification"
+	"github.com/tukesh1/student-api/internal/http/handlers/report"
+	"github.com/tukesh1/student-api/internal/http/handlers/stream"
 	"github.com/tukesh1/student-api/internal/http/handlers/student"
+	"github.com/tukesh1/student-api/internal/http/handlers/teacher"
+	"github.com/tukesh1/student-api/internal/http/handlers/timetable"
+	"github.com/tukesh1/student-api/internal/http/handlers/webhook"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/metrics"
 	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/notify"
+	"github.com/tukesh1/student-api/internal/outbox"
+	"github.com/tukesh1/student-api/internal/storage/localfs"
 	"github.com/tukesh1/student-api/internal/storage/sqlite"
+	"github.com/tukesh1/student-api/internal/tracing"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/webhooks"
 )
 
 // CORS middleware to handle cross-origin requests
@@ -23,7 +52,8 @@ func corsHandler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, Last-Event-ID, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, X-Request-ID, Content-Disposition")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -34,52 +64,328 @@ func corsHandler(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// routeGroup returns the middleware shared by one group of routes: its rate
+// limit, if configured, and its request body cap
+func routeGroup(rule config.RateLimitRule, maxBodyBytes int64, key middleware.KeyFunc) func(http.HandlerFunc) http.HandlerFunc {
+	limitBody := middleware.MaxBodySize(maxBodyBytes)
+	if rule.RequestsPerSecond <= 0 {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return limitBody(next).ServeHTTP
+		}
+	}
+	limit := middleware.RateLimit(middleware.NewMemoryLimiter(rule.RequestsPerSecond, rule.Burst), key)
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return limit(limitBody(next)).ServeHTTP
+	}
+}
+
 func main() {
 	// log config
 	cfg := config.MustLoad()
+	slog.SetDefault(logger.New(cfg))
+
+	// tracing setup
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// database setup
-	storage, err := sqlite.New(cfg)
+	db, err := sqlite.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	metrics.RegisterDB(db.Db, "sqlite")
+	storage := metrics.InstrumentStorage(db)
+	metrics.RegisterStats(storage)
+
+	// absenteeism rules run on every attendance write and nightly
+	alertEngine, err := alerts.NewEngine(storage, cfg.Alerts)
 	if err != nil {
 		log.Fatal(err)
 	}
+	storage = alertEngine.Watch(storage)
+
+	// absent marks for today queue a notice to the student's guardian
+	dispatcher, err := notify.NewDispatcher(storage, cfg.Notifications)
+	if err != nil {
+		log.Fatal(err)
+	}
+	storage = dispatcher.Watch(storage)
+
+	// every mutation is queued for webhook subscribers and sent in the background
+	hooks := webhooks.NewDispatcher(storage, cfg.Webhooks)
+	storage = hooks.Watch(storage)
+
+	// students, classes and attendance reach publishers through the outbox;
+	// the bus feeds live streams in this process
+	events := bus.New(cfg.Stream.Buffer)
+	relay := outbox.NewRelay(storage, cfg.Outbox, hooks, events)
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go alertEngine.RunNightly(jobs)
+	go dispatcher.Run(jobs)
+	go hooks.Run(jobs)
+	go relay.Run(jobs)
 	slog.Info("Storage initilised", slog.String("env", cfg.Env))
+
+	// student badges and check-in session codes are signed with one secret
+	signer, err := checkin.NewSigner(cfg.CheckIn.Secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// attachment contents and photos are kept on disk, outside the database
+	blobs, err := localfs.New(cfg.Attachments.Dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	photos, err := localfs.New(cfg.Photos.Dir)
+	if err != nil {
+		log.Fatal(err)
+	}
 	// setup router
 	router := http.NewServeMux()
 
+	// Rate limits and body caps per route group
+	keys := middleware.NewAPIKeys(cfg.Auth.APIKeys)
+	read := routeGroup(cfg.RateLimit.Read, cfg.MaxBodyBytes, keys.ClientKey)
+	write := routeGroup(cfg.RateLimit.Write, cfg.MaxBodyBytes, keys.ClientKey)
+	upload := routeGroup(cfg.RateLimit.Write, cfg.MaxUploadBytes, keys.ClientKey)
+
 	// Handle OPTIONS requests for CORS preflight
 	router.HandleFunc("OPTIONS /api/students", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
 	router.HandleFunc("OPTIONS /api/students/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
 	router.HandleFunc("OPTIONS /api/classes", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
 	router.HandleFunc("OPTIONS /api/classes/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/attendance", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/attendance/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/alerts", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/alerts/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/teachers", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/teachers/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/teachers/{id}/classes", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/periods", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/periods/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardians", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardians/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardians/{id}/password", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/students/{id}/guardians", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/students/{id}/guardians/{guardianId}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardian/login", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardian/logout", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardian/students", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardian/leave-requests", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/leave-requests", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/leave-requests/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/leave-requests/{id}/review", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/leave-requests/{id}/attachments", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/students/{id}/attachments", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/students/{id}/photo", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/attendance/{id}/attachments", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/attachments/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/attachments/{id}/content", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardian/leave-requests/{id}/attachments", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/guardian/attachments/{id}/content", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/notifications", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/notifications/templates", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/notifications/templates/{channel}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/enrollments", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/enrollments/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/enrollments/{id}/drop", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/subjects", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/subjects/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/classes/{id}/timetable", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/classes/{id}/grace", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/calendar/{resource}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/calendar/{resource}/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/webhooks", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/webhooks/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/webhooks/{id}/deliveries", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/webhooks/deliveries/{id}/redeliver", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/stream/attendance", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/changes", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/students/{id}/qr/revoke", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/checkin", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/checkin/sessions", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/checkins", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/checkins/{id}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+	router.HandleFunc("OPTIONS /api/import/{resource}", corsHandler(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Liveness and readiness probes; /health is kept for existing monitors
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.Register("database", db.Ping)
+	checker.Register("migrations", db.CheckMigrations)
+	checker.Register("disk", health.DiskSpaceCheck(cfg.StoragePath, cfg.Health.MinFreeDiskMB*1024*1024))
+	router.HandleFunc("GET /livez", health.Livez())
+	router.HandleFunc("GET /readyz", checker.Readyz())
+	router.HandleFunc("GET /health", checker.Readyz())
 
-	// Health check endpoint
-	router.HandleFunc("GET /health", health.HealthCheck(storage))
+	// Prometheus metrics endpoint
+	router.Handle("GET /metrics", metrics.Handler())
 
 	// Student API routes with CORS
-	router.HandleFunc("POST /api/students", corsHandler(student.New(storage)))
-	router.HandleFunc("GET /api/students/{id}", corsHandler(student.GetById(storage)))
-	router.HandleFunc("GET /api/students", corsHandler(student.GetList(storage)))
-	router.HandleFunc("PUT /api/students/{id}", corsHandler(student.UpdateById(storage)))
-	router.HandleFunc("DELETE /api/students/{id}", corsHandler(student.DeleteById(storage)))
+	router.HandleFunc("POST /api/students", corsHandler(write(student.New(storage))))
+	router.HandleFunc("GET /api/students/{id}", corsHandler(read(student.GetById(storage))))
+	router.HandleFunc("GET /api/students", corsHandler(read(student.GetList(storage))))
+	router.HandleFunc("PUT /api/students/{id}", corsHandler(write(student.UpdateById(storage))))
+	router.HandleFunc("DELETE /api/students/{id}", corsHandler(write(student.DeleteById(storage))))
+	router.HandleFunc("PUT /api/students/{id}/photo", corsHandler(upload(student.SetPhoto(storage, photos, cfg.Photos))))
+	router.HandleFunc("GET /api/students/{id}/photo", corsHandler(read(student.GetPhoto(storage, photos))))
+	router.HandleFunc("DELETE /api/students/{id}/photo", corsHandler(write(student.DeletePhoto(storage, photos))))
 
 	// Class API routes with CORS
-	router.HandleFunc("POST /api/classes", corsHandler(class.New(storage)))
-	router.HandleFunc("GET /api/classes/{id}", corsHandler(class.GetById(storage)))
-	router.HandleFunc("GET /api/classes", corsHandler(class.GetList(storage)))
-	router.HandleFunc("PUT /api/classes/{id}", corsHandler(class.UpdateById(storage)))
-	router.HandleFunc("DELETE /api/classes/{id}", corsHandler(class.DeleteById(storage)))
+	router.HandleFunc("POST /api/classes", corsHandler(write(class.New(storage))))
+	router.HandleFunc("GET /api/classes/{id}", corsHandler(read(class.GetById(storage))))
+	router.HandleFunc("GET /api/classes", corsHandler(read(class.GetList(storage))))
+	router.HandleFunc("PUT /api/classes/{id}", corsHandler(write(class.UpdateById(storage))))
+	router.HandleFunc("DELETE /api/classes/{id}", corsHandler(write(class.DeleteById(storage))))
+	router.HandleFunc("PUT /api/classes/{id}/grace", corsHandler(write(class.SetGrace(storage))))
+
+	// Teacher API routes with CORS
+	router.HandleFunc("POST /api/teachers", corsHandler(write(teacher.New(storage))))
+	router.HandleFunc("GET /api/teachers/{id}", corsHandler(read(teacher.GetById(storage))))
+	router.HandleFunc("GET /api/teachers", corsHandler(read(teacher.GetList(storage))))
+	router.HandleFunc("GET /api/teachers/{id}/classes", corsHandler(read(teacher.GetClasses(storage))))
+	router.HandleFunc("PUT /api/teachers/{id}", corsHandler(write(teacher.UpdateById(storage))))
+	router.HandleFunc("DELETE /api/teachers/{id}", corsHandler(write(teacher.DeleteById(storage))))
+
+	// Attendance analytics
+	router.HandleFunc("GET /api/analytics/attendance", corsHandler(read(analytics.Attendance(storage, analytics.NewCache(cfg.Analytics.CacheTTL)))))
+
+	// Printable PDF reports
+	router.HandleFunc("GET /api/classes/{id}/register.pdf", corsHandler(read(report.ClassRegister(storage))))
+	router.HandleFunc("GET /api/students/{id}/report.pdf", corsHandler(read(report.StudentReportCard(storage))))
+
+	// Attendance API routes with CORS
+	router.HandleFunc("POST /api/attendance", corsHandler(write(attendance.New(storage))))
+	router.HandleFunc("GET /api/attendance", corsHandler(read(attendance.GetList(storage))))
+	router.HandleFunc("PUT /api/attendance/{id}", corsHandler(write(attendance.UpdateById(storage))))
+	router.HandleFunc("DELETE /api/attendance/{id}", corsHandler(write(attendance.DeleteById(storage))))
+	router.HandleFunc("POST /api/attendance/sync", corsHandler(write(attendance.Sync(storage))))
+
+	// Guardians and a student's emergency contacts
+	router.HandleFunc("POST /api/guardians", corsHandler(write(guardian.New(storage))))
+	router.HandleFunc("GET /api/guardians/{id}", corsHandler(read(guardian.GetById(storage))))
+	router.HandleFunc("GET /api/guardians", corsHandler(read(guardian.GetList(storage))))
+	router.HandleFunc("PUT /api/guardians/{id}", corsHandler(write(guardian.UpdateById(storage))))
+	router.HandleFunc("DELETE /api/guardians/{id}", corsHandler(write(guardian.DeleteById(storage))))
+	router.HandleFunc("PUT /api/guardians/{id}/password", corsHandler(write(keys.Require(guardian.SetPassword(storage)))))
+	router.HandleFunc("GET /api/students/{id}/guardians", corsHandler(read(guardian.GetForStudent(storage))))
+	router.HandleFunc("POST /api/students/{id}/guardians", corsHandler(write(guardian.LinkToStudent(storage))))
+	router.HandleFunc("PUT /api/students/{id}/guardians/{guardianId}", corsHandler(write(guardian.UpdateLink(storage))))
+	router.HandleFunc("DELETE /api/students/{id}/guardians/{guardianId}", corsHandler(write(guardian.Unlink(storage))))
+
+	// Guardian portal; everything but login needs the login's bearer token
+	router.HandleFunc("POST /api/guardian/login", corsHandler(write(guardian.Login(storage, cfg.Guardians.SessionTTL))))
+	router.HandleFunc("POST /api/guardian/logout", corsHandler(write(guardian.Authenticate(storage, guardian.Logout(storage)))))
+	router.HandleFunc("GET /api/guardian/students", corsHandler(read(guardian.Authenticate(storage, guardian.MyStudents(storage)))))
+	router.HandleFunc("POST /api/guardian/leave-requests", corsHandler(write(guardian.Authenticate(storage, leave.GuardianNew(storage)))))
+	router.HandleFunc("GET /api/guardian/leave-requests", corsHandler(read(guardian.Authenticate(storage, leave.GuardianList(storage)))))
+	router.HandleFunc("POST /api/guardian/leave-requests/{id}/attachments", corsHandler(upload(guardian.Authenticate(storage, attachment.GuardianUpload(storage, blobs, cfg.Attachments.AllowedTypes)))))
+	router.HandleFunc("GET /api/guardian/leave-requests/{id}/attachments", corsHandler(read(guardian.Authenticate(storage, attachment.GuardianList(storage)))))
+	router.HandleFunc("GET /api/guardian/attachments/{id}/content", corsHandler(read(guardian.Authenticate(storage, attachment.GuardianDownload(storage, blobs)))))
+
+	// Leave requests; approving one excuses the absences it covers
+	router.HandleFunc("POST /api/leave-requests", corsHandler(write(leave.New(storage))))
+	router.HandleFunc("GET /api/leave-requests", corsHandler(read(leave.GetList(storage))))
+	router.HandleFunc("GET /api/leave-requests/{id}", corsHandler(read(leave.GetById(storage))))
+	router.HandleFunc("PUT /api/leave-requests/{id}/review", corsHandler(write(keys.Require(leave.Review(storage)))))
+
+	// Attachments of students, leave requests and attendance records
+	router.HandleFunc("POST /api/students/{id}/attachments", corsHandler(upload(keys.Require(attachment.Upload(storage, blobs, cfg.Attachments.AllowedTypes, types.OwnerStudent)))))
+	router.HandleFunc("GET /api/students/{id}/attachments", corsHandler(read(keys.Require(attachment.List(storage, types.OwnerStudent)))))
+	router.HandleFunc("POST /api/leave-requests/{id}/attachments", corsHandler(upload(keys.Require(attachment.Upload(storage, blobs, cfg.Attachments.AllowedTypes, types.OwnerLeaveRequest)))))
+	router.HandleFunc("GET /api/leave-requests/{id}/attachments", corsHandler(read(keys.Require(attachment.List(storage, types.OwnerLeaveRequest)))))
+	router.HandleFunc("POST /api/attendance/{id}/attachments", corsHandler(upload(keys.Require(attachment.Upload(storage, blobs, cfg.Attachments.AllowedTypes, types.OwnerAttendance)))))
+	router.HandleFunc("GET /api/attendance/{id}/attachments", corsHandler(read(keys.Require(attachment.List(storage, types.OwnerAttendance)))))
+	router.HandleFunc("GET /api/attachments/{id}", corsHandler(read(keys.Require(attachment.GetById(storage)))))
+	router.HandleFunc("GET /api/attachments/{id}/content", corsHandler(read(keys.Require(attachment.Download(storage, blobs)))))
+	router.HandleFunc("DELETE /api/attachments/{id}", corsHandler(write(keys.Require(attachment.Delete(storage, blobs)))))
+
+	// Absence notifications to guardians
+	router.HandleFunc("GET /api/notifications", corsHandler(read(notification.GetList(storage))))
+	router.HandleFunc("GET /api/notifications/templates", corsHandler(read(notification.GetTemplates(storage))))
+	router.HandleFunc("PUT /api/notifications/templates/{channel}", corsHandler(write(notification.UpdateTemplate(storage))))
+
+	// Webhook subscriptions, their deliveries and the dead-letter list
+	router.HandleFunc("POST /api/webhooks", corsHandler(write(keys.Require(webhook.New(storage)))))
+	router.HandleFunc("GET /api/webhooks", corsHandler(read(keys.Require(webhook.GetList(storage)))))
+	router.HandleFunc("GET /api/webhooks/events", corsHandler(read(keys.Require(webhook.GetEvents()))))
+	router.HandleFunc("GET /api/webhooks/deliveries", corsHandler(read(keys.Require(webhook.GetDeliveries(storage)))))
+	router.HandleFunc("POST /api/webhooks/deliveries/{id}/redeliver", corsHandler(write(keys.Require(webhook.Redeliver(storage, hooks)))))
+	router.HandleFunc("GET /api/webhooks/{id}", corsHandler(read(keys.Require(webhook.GetById(storage)))))
+	router.HandleFunc("PUT /api/webhooks/{id}", corsHandler(write(keys.Require(webhook.UpdateById(storage)))))
+	router.HandleFunc("DELETE /api/webhooks/{id}", corsHandler(write(keys.Require(webhook.DeleteById(storage)))))
+	router.HandleFunc("GET /api/webhooks/{id}/deliveries", corsHandler(read(keys.Require(webhook.GetDeliveries(storage)))))
+
+	// Change feed of students, classes and attendance for incremental sync
+	router.HandleFunc("GET /api/changes", corsHandler(read(changes.GetList(storage))))
+
+	// Live attendance as Server-Sent Events
+	router.HandleFunc("GET /api/stream/attendance", corsHandler(read(stream.Attendance(storage, events, cfg.Stream.Heartbeat))))
+
+	// Kiosk self check-in with QR badges
+	router.HandleFunc("GET /api/students/{id}/qr", corsHandler(read(keys.Require(kiosk.StudentQR(storage, signer)))))
+	router.HandleFunc("POST /api/students/{id}/qr/revoke", corsHandler(write(keys.Require(kiosk.RevokeBadge(storage)))))
+	router.HandleFunc("POST /api/checkin/sessions", corsHandler(write(keys.Require(kiosk.OpenSession(storage, signer, cfg.CheckIn)))))
+	router.HandleFunc("POST /api/checkin", corsHandler(write(kiosk.CheckIn(storage, signer))))
+	router.HandleFunc("GET /api/checkins", corsHandler(read(keys.Require(kiosk.GetList(storage)))))
+	router.HandleFunc("PUT /api/checkins/{id}", corsHandler(write(keys.Require(kiosk.Override(storage)))))
+
+	// Subject section enrollments
+	router.HandleFunc("POST /api/enrollments", corsHandler(write(enrollment.New(storage))))
+	router.HandleFunc("GET /api/enrollments", corsHandler(read(enrollment.GetList(storage))))
+	router.HandleFunc("GET /api/enrollments/{id}", corsHandler(read(enrollment.GetById(storage))))
+	router.HandleFunc("PUT /api/enrollments/{id}", corsHandler(write(enrollment.UpdateById(storage))))
+	router.HandleFunc("POST /api/enrollments/{id}/drop", corsHandler(write(enrollment.Drop(storage))))
+
+	// Periods, subjects and class timetables
+	router.HandleFunc("GET /api/periods", corsHandler(read(timetable.GetPeriods(storage))))
+	router.HandleFunc("POST /api/periods", corsHandler(write(timetable.CreatePeriod(storage))))
+	router.HandleFunc("PUT /api/periods/{id}", corsHandler(write(timetable.UpdatePeriod(storage))))
+	router.HandleFunc("DELETE /api/periods/{id}", corsHandler(write(timetable.DeletePeriod(storage))))
+	router.HandleFunc("GET /api/subjects", corsHandler(read(timetable.GetSubjects(storage))))
+	router.HandleFunc("POST /api/subjects", corsHandler(write(timetable.CreateSubject(storage))))
+	router.HandleFunc("DELETE /api/subjects/{id}", corsHandler(write(timetable.DeleteSubject(storage))))
+	router.HandleFunc("GET /api/classes/{id}/timetable", corsHandler(read(timetable.GetClassTimetable(storage))))
+	router.HandleFunc("PUT /api/classes/{id}/timetable", corsHandler(write(timetable.SetClassTimetable(storage))))
+
+	// School calendar
+	router.HandleFunc("GET /api/calendar/years", corsHandler(read(calendar.GetYears(storage))))
+	router.HandleFunc("POST /api/calendar/years", corsHandler(write(calendar.CreateYear(storage))))
+	router.HandleFunc("DELETE /api/calendar/years/{id}", corsHandler(write(calendar.DeleteYear(storage))))
+	router.HandleFunc("GET /api/calendar/terms", corsHandler(read(calendar.GetTerms(storage))))
+	router.HandleFunc("POST /api/calendar/terms", corsHandler(write(calendar.CreateTerm(storage))))
+	router.HandleFunc("DELETE /api/calendar/terms/{id}", corsHandler(write(calendar.DeleteTerm(storage))))
+	router.HandleFunc("GET /api/calendar/closures", corsHandler(read(calendar.GetClosures(storage))))
+	router.HandleFunc("POST /api/calendar/closures", corsHandler(write(calendar.CreateClosure(storage))))
+	router.HandleFunc("DELETE /api/calendar/closures/{id}", corsHandler(write(calendar.DeleteClosure(storage))))
+	router.HandleFunc("POST /api/calendar/closures/import", corsHandler(upload(calendar.ImportClosures(storage))))
+	router.HandleFunc("GET /api/calendar/days", corsHandler(read(calendar.GetDays(storage))))
+
+	// At-risk student alerts
+	router.HandleFunc("GET /api/alerts", corsHandler(read(alert.GetList(storage))))
+	router.HandleFunc("GET /api/alerts/{id}", corsHandler(read(alert.GetById(storage))))
+	router.HandleFunc("PUT /api/alerts/{id}", corsHandler(write(alert.UpdateById(storage))))
+
+	// Bulk import routes with CORS
+	router.HandleFunc("POST /api/import/students", corsHandler(upload(importer.Students(storage, cfg.MaxUploadBytes))))
+	router.HandleFunc("POST /api/import/classes", corsHandler(upload(importer.Classes(storage, cfg.MaxUploadBytes))))
 
 	// Serve static files
 	router.Handle("/", http.FileServer(http.Dir("web/")))
 
-	// Apply logging middleware
-	handler := middleware.LoggingMiddleware(router)
+	// Apply request ID and logging middleware
+	handler := middleware.RequestID(keys.Identify(middleware.LoggingMiddleware(router)))
 	//setup server
 	server := http.Server{
 		Addr:    cfg.Addr,
 		Handler: handler,
 	}
+	// Shutdown waits for handlers to return; closing the bus ends the streams
+	server.RegisterOnShutdown(events.Close)
 	slog.Info("server started", slog.String("address", cfg.Addr))
 	// create a channel to store signal values
 	done := make(chan os.Signal, 1)
@@ -90,7 +396,7 @@ func main() {
 	*/
 	go func() {
 		err := server.ListenAndServe()
-		if err != nil {
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal("failed to start server")
 		}
 	}()
@@ -100,8 +406,12 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	stopJobs()
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("failed to shutdodn server", slog.String("error", err.Error()))
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", slog.String("error", err.Error()))
+	}
 	slog.Info("server shutdown successfully")
 }
@@ -0,0 +1,220 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Engine evaluates the absenteeism rules and keeps the alert records in
+// step: triggered rules raise or refresh an alert, cleared rules resolve it
+type Engine struct {
+	storage   storage.Storage
+	rules     config.Alerts
+	nightlyAt time.Duration // offset from midnight
+	now       func() time.Time
+}
+
+func NewEngine(storage storage.Storage, rules config.Alerts) (*Engine, error) {
+	at, err := time.Parse("15:04", rules.NightlyAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alerts.nightly_at %q: %w", rules.NightlyAt, err)
+	}
+	return &Engine{
+		storage:   storage,
+		rules:     rules,
+		nightlyAt: time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute,
+		now:       time.Now,
+	}, nil
+}
+
+// EvaluateStudent applies the rules to the student's records in the window
+// ending today. The rate is taken over the instructional days from the
+// start of the window, or from the student's first record in it if later.
+func (e *Engine) EvaluateStudent(ctx context.Context, studentID int64) error {
+	to := e.now()
+	from := to.AddDate(0, 0, -e.rules.WindowDays+1)
+	records, err := e.storage.GetAttendance(ctx, types.AttendanceFilter{
+		StudentID: studentID,
+		From:      from,
+		To:        to,
+	})
+	if err != nil {
+		return err
+	}
+	var days int
+	if len(records) > 0 {
+		if first := records[0].Date; first.After(from) {
+			from = first
+		}
+		calendar, err := e.storage.GetCalendarDays(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		for _, d := range calendar {
+			if d.Instructional {
+				days++
+			}
+		}
+	}
+
+	for _, f := range Evaluate(e.rules, records, days) {
+		if f.Triggered {
+			_, err = e.storage.RaiseAlert(ctx, types.Alert{
+				StudentID: studentID,
+				Rule:      f.Rule,
+				Message:   f.Message,
+				Value:     f.Value,
+				Threshold: f.Threshold,
+			})
+		} else {
+			err = e.storage.ResolveAlerts(ctx, studentID, f.Rule, "condition cleared")
+		}
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", f.Rule, err)
+		}
+	}
+	return nil
+}
+
+// EvaluateAll evaluates every student, carrying on past individual failures
+func (e *Engine) EvaluateAll(ctx context.Context) error {
+	var ids []int64
+	err := e.storage.StreamStudents(ctx, types.StudentFilter{}, func(s types.Student) error {
+		ids = append(ids, s.Id)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := e.EvaluateStudent(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("student %d: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunNightly evaluates every student once a day at the configured time
+// until ctx is cancelled
+func (e *Engine) RunNightly(ctx context.Context) {
+	for {
+		now := e.now()
+		y, m, d := now.Date()
+		next := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).Add(e.nightlyAt)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		slog.Info("evaluating absenteeism rules")
+		if err := e.EvaluateAll(ctx); err != nil {
+			slog.Error("error evaluating absenteeism rules", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Watch wraps storage so that every attendance write re-evaluates the
+// student it belongs to. Evaluation errors are logged and never fail the write.
+func (e *Engine) Watch(s storage.Storage) storage.Storage {
+	return &watched{Storage: s, engine: e}
+}
+
+type watched struct {
+	storage.Storage
+	engine *Engine
+}
+
+func (w *watched) CreateAttendanceRecord(ctx context.Context, studentID, classID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (int64, error) {
+	id, err := w.Storage.CreateAttendanceRecord(ctx, studentID, classID, date, status, remarks, times)
+	if err == nil {
+		w.evaluate(ctx, studentID)
+	}
+	return id, err
+}
+
+func (w *watched) CreatePeriodAttendance(ctx context.Context, studentID, classID, periodID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (int64, error) {
+	id, err := w.Storage.CreatePeriodAttendance(ctx, studentID, classID, periodID, date, status, remarks, times)
+	if err == nil {
+		w.evaluate(ctx, studentID)
+	}
+	return id, err
+}
+
+func (w *watched) UpdateAttendanceRecord(ctx context.Context, id int64, status, remarks string, times types.AttendanceTimes) error {
+	err := w.Storage.UpdateAttendanceRecord(ctx, id, status, remarks, times)
+	if err == nil {
+		if record, err := w.Storage.GetAttendanceById(ctx, id); err == nil {
+			w.evaluate(ctx, record.StudentID)
+		}
+	}
+	return err
+}
+
+func (w *watched) DeleteAttendanceRecord(ctx context.Context, id int64) error {
+	record, lookupErr := w.Storage.GetAttendanceById(ctx, id)
+	err := w.Storage.DeleteAttendanceRecord(ctx, id)
+	if err == nil && lookupErr == nil {
+		w.evaluate(ctx, record.StudentID)
+	}
+	return err
+}
+
+func (w *watched) SyncAttendance(ctx context.Context, mutation types.SyncMutation) (types.SyncResult, error) {
+	result, err := w.Storage.SyncAttendance(ctx, mutation)
+	if err == nil && result.Outcome == types.SyncApplied && !result.Replayed {
+		w.evaluate(ctx, mutation.StudentID)
+	}
+	return result, err
+}
+
+func (w *watched) CheckIn(ctx context.Context, checkIn types.CheckIn) (int64, error) {
+	id, err := w.Storage.CheckIn(ctx, checkIn)
+	if err == nil {
+		w.evaluate(ctx, checkIn.StudentID)
+	}
+	return id, err
+}
+
+func (w *watched) OverrideCheckIn(ctx context.Context, id int64, status, remarks string) error {
+	err := w.Storage.OverrideCheckIn(ctx, id, status, remarks)
+	if err == nil {
+		if checkIn, err := w.Storage.GetCheckInById(ctx, id); err == nil {
+			w.evaluate(ctx, checkIn.StudentID)
+		}
+	}
+	return err
+}
+
+func (w *watched) ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (int, error) {
+	excused, err := w.Storage.ReviewLeaveRequest(ctx, id, status, reviewedBy, note)
+	if err == nil && excused > 0 {
+		if leave, err := w.Storage.GetLeaveRequestById(ctx, id); err == nil {
+			w.evaluate(ctx, leave.StudentID)
+		}
+	}
+	return excused, err
+}
+
+func (w *watched) evaluate(ctx context.Context, studentID int64) {
+	if err := w.engine.EvaluateStudent(ctx, studentID); err != nil {
+		logger.FromContext(ctx).Error("error evaluating absenteeism rules",
+			slog.Int64("studentId", studentID), slog.String("error", err.Error()))
+	}
+}
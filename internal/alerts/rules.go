@@ -0,0 +1,81 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Rule names stored on alerts
+const (
+	RuleLowAttendance       = "low_attendance"
+	RuleConsecutiveAbsences = "consecutive_absences"
+	RuleRepeatedLateness    = "repeated_lateness"
+)
+
+// Finding is the outcome of one rule for one student
+type Finding struct {
+	Rule      string
+	Triggered bool
+	Message   string
+	Value     float64
+	Threshold float64
+}
+
+// Evaluate applies the enabled rules to a student's daily attendance
+// records in the rolling window, which must be sorted by date. days is the
+// number of instructional days the rate is taken over, so a day with no
+// record counts as missed. Excused days count for no rule and neither break
+// nor extend a run of absences.
+func Evaluate(rules config.Alerts, records []types.AttendanceRecord, days int) []Finding {
+	var attended, late, streak, longest, excused int
+	for _, r := range records {
+		switch r.Status {
+		case "Excused":
+			excused++
+		case "Present":
+			attended++
+			streak = 0
+		case "Late":
+			attended++
+			late++
+			streak = 0
+		case "Absent":
+			streak++
+			longest = max(longest, streak)
+		}
+	}
+
+	var findings []Finding
+	if rules.MinAttendanceRate > 0 {
+		f := Finding{Rule: RuleLowAttendance, Threshold: rules.MinAttendanceRate}
+		counted := len(records) - excused
+		if days := days - excused; counted > 0 && days > 0 {
+			f.Value = math.Round(min(float64(attended)/float64(days), 1)*10000) / 100
+		}
+		f.Triggered = counted >= rules.MinRecords && counted > 0 && f.Value <= rules.MinAttendanceRate
+		f.Message = fmt.Sprintf("attendance rate %.2f%% over the last %d days is at or below %.2f%%", f.Value, rules.WindowDays, rules.MinAttendanceRate)
+		findings = append(findings, f)
+	}
+	if rules.ConsecutiveAbsences > 0 {
+		findings = append(findings, Finding{
+			Rule:      RuleConsecutiveAbsences,
+			Triggered: longest >= rules.ConsecutiveAbsences,
+			Message:   fmt.Sprintf("%d consecutive absences in the last %d days", longest, rules.WindowDays),
+			Value:     float64(longest),
+			Threshold: float64(rules.ConsecutiveAbsences),
+		})
+	}
+	if rules.LateCount > 0 {
+		findings = append(findings, Finding{
+			Rule:      RuleRepeatedLateness,
+			Triggered: late >= rules.LateCount,
+			Message:   fmt.Sprintf("late %d times in the last %d days", late, rules.WindowDays),
+			Value:     float64(late),
+			Threshold: float64(rules.LateCount),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,91 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func records(statuses ...string) []types.AttendanceRecord {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var out []types.AttendanceRecord
+	for i, s := range statuses {
+		out = append(out, types.AttendanceRecord{StudentID: 1, Date: day.AddDate(0, 0, i), Status: s})
+	}
+	return out
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := config.Alerts{MinAttendanceRate: 90, WindowDays: 30, MinRecords: 5, ConsecutiveAbsences: 3, LateCount: 2}
+
+	findings := Evaluate(rules, records("Present", "Absent", "Absent", "Absent", "Late", "Present", "Late"), 7)
+	got := map[string]Finding{}
+	for _, f := range findings {
+		got[f.Rule] = f
+	}
+	if f := got[RuleLowAttendance]; !f.Triggered || f.Value != 57.14 {
+		t.Errorf("expected low attendance at 57.14%%, got %+v", f)
+	}
+	if f := got[RuleConsecutiveAbsences]; !f.Triggered || f.Value != 3 {
+		t.Errorf("expected 3 consecutive absences, got %+v", f)
+	}
+	if f := got[RuleRepeatedLateness]; !f.Triggered || f.Value != 2 {
+		t.Errorf("expected 2 late arrivals, got %+v", f)
+	}
+
+	for _, f := range Evaluate(rules, records("Present", "Absent", "Present", "Absent"), 4) {
+		if f.Triggered {
+			t.Errorf("rule %s should not trigger: %+v", f.Rule, f)
+		}
+	}
+}
+
+func TestEvaluateRateBoundary(t *testing.T) {
+	rules := config.Alerts{MinAttendanceRate: 90, WindowDays: 30, MinRecords: 5}
+
+	// missing exactly 10% of days is flagged
+	statuses := []string{"Absent", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present"}
+	if f := Evaluate(rules, records(statuses...), 10)[0]; !f.Triggered || f.Value != 90 {
+		t.Errorf("expected a rate of exactly 90%% to trigger, got %+v", f)
+	}
+	if f := Evaluate(rules, records(append(statuses, "Present")...), 11)[0]; f.Triggered {
+		t.Errorf("expected a rate above 90%% not to trigger, got %+v", f)
+	}
+}
+
+func TestEvaluateUnmarkedDays(t *testing.T) {
+	rules := config.Alerts{MinAttendanceRate: 90, WindowDays: 30, MinRecords: 5}
+
+	// five days present out of ten instructional days, with no record for
+	// the other five, is a rate of 50%; one excused day leaves nine
+	statuses := []string{"Present", "Present", "Present", "Present", "Present"}
+	if f := Evaluate(rules, records(statuses...), 10)[0]; !f.Triggered || f.Value != 50 {
+		t.Errorf("expected unmarked days to count as missed, got %+v", f)
+	}
+	if f := Evaluate(rules, records(append(statuses, "Excused")...), 10)[0]; f.Value != 55.56 {
+		t.Errorf("expected the excused day to leave the rate, got %+v", f)
+	}
+}
+
+func TestEvaluateExcused(t *testing.T) {
+	rules := config.Alerts{MinAttendanceRate: 90, WindowDays: 30, MinRecords: 3, ConsecutiveAbsences: 3}
+
+	// a week of sick leave neither lowers the rate nor adds to the run
+	findings := Evaluate(rules, records("Present", "Absent", "Excused", "Excused", "Excused", "Absent", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present", "Present"), 24)
+	for _, f := range findings {
+		if f.Triggered {
+			t.Errorf("rule %s should not trigger: %+v", f.Rule, f)
+		}
+	}
+	if findings := Evaluate(rules, records("Excused", "Excused", "Excused"), 3); findings[0].Triggered {
+		t.Errorf("only excused days should not lower the rate, got %+v", findings[0])
+	}
+}
+
+func TestEvaluateDisabledRules(t *testing.T) {
+	if findings := Evaluate(config.Alerts{}, records("Absent", "Absent"), 2); len(findings) != 0 {
+		t.Errorf("zero thresholds should disable every rule, got %+v", findings)
+	}
+}
@@ -0,0 +1,98 @@
+// Package bus fans outbox events out to in-process subscribers such as
+// live streams. It is fed by the outbox relay, so subscribers see events in
+// seq order once they are committed.
+package bus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Bus delivers every published event to every subscriber. A subscriber that
+// falls behind by more than its buffer is dropped rather than slowing the
+// others down; it can catch up from the change feed.
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// Subscription receives events on C until it is cancelled, it lags or the
+// bus is closed; C is closed then
+type Subscription struct {
+	C      <-chan types.Event
+	c      chan types.Event
+	bus    *Bus
+	lagged bool
+}
+
+func New(buffer int) *Bus {
+	return &Bus{subs: map[*Subscription]struct{}{}, buffer: buffer}
+}
+
+// Subscribe starts receiving events published from now on
+func (b *Bus) Subscribe() *Subscription {
+	c := make(chan types.Event, b.buffer)
+	s := &Subscription{C: c, c: c, bus: b}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(c)
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Cancel stops the subscription; it is safe to call more than once
+func (s *Subscription) Cancel() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// Lagged reports whether the subscription was dropped for falling behind.
+// Read it after C is closed.
+func (s *Subscription) Lagged() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.lagged
+}
+
+// remove closes a subscription; the caller holds the lock
+func (b *Bus) remove(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Publish hands the event to every subscriber without blocking. It
+// satisfies outbox.Publisher and never fails.
+func (b *Bus) Publish(ctx context.Context, event types.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		select {
+		case s.c <- event:
+		default:
+			s.lagged = true
+			b.remove(s)
+		}
+	}
+	return nil
+}
+
+// Close ends every subscription and refuses new ones. Call it when the
+// server shuts down so long-lived streams return.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
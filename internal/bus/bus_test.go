@@ -0,0 +1,48 @@
+package bus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestPublish(t *testing.T) {
+	b := New(2)
+	fast, slow := b.Subscribe(), b.Subscribe()
+
+	for seq := int64(1); seq <= 2; seq++ {
+		b.Publish(context.Background(), types.Event{Seq: seq})
+	}
+	<-fast.C
+	<-fast.C
+	b.Publish(context.Background(), types.Event{Seq: 3})
+
+	if e := <-fast.C; e.Seq != 3 {
+		t.Errorf("fast subscriber got seq %d, want 3", e.Seq)
+	}
+	// the slow one still holds 1 and 2 and was dropped when 3 did not fit
+	var got []int64
+	for e := range slow.C {
+		got = append(got, e.Seq)
+	}
+	if len(got) != 2 || !slow.Lagged() {
+		t.Errorf("slow subscriber got %v, lagged %v; want [1 2] and dropped", got, slow.Lagged())
+	}
+	if fast.Lagged() {
+		t.Error("fast subscriber dropped")
+	}
+}
+
+func TestClose(t *testing.T) {
+	b := New(1)
+	sub := b.Subscribe()
+	b.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription still open after Close")
+	}
+	sub.Cancel()
+	if _, ok := <-b.Subscribe().C; ok {
+		t.Error("subscribed to a closed bus")
+	}
+}
@@ -0,0 +1,160 @@
+// Package checkin signs the tokens of kiosk self check-in. A student's
+// badge, printed as a QR code, carries their id and badge version, which
+// is bumped to revoke the badges printed before; a class-session code,
+// opened by the teacher, says which class and period a kiosk checks in
+// for and until when. Both are signed with the server secret, so neither
+// can be made up, and the session code expires quickly so a photo of it is
+// of little use later.
+package checkin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalid is returned for a badge or session code that is malformed
+	// or whose signature does not match
+	ErrInvalid = errors.New("invalid code")
+	// ErrExpired is returned for a session code past its expiry
+	ErrExpired = errors.New("session code expired")
+	// ErrRevoked is returned for a badge older than the student's current
+	// badge version
+	ErrRevoked = errors.New("badge revoked")
+	// ErrNoSecret is returned by NewSigner for an empty secret
+	ErrNoSecret = errors.New("check-in secret is not set")
+)
+
+// Token prefixes, versioned so the format can change. SB1 badges carry no
+// badge version and are read as version 1.
+const (
+	badgePrefix   = "SB2"
+	badgeV1Prefix = "SB1"
+	sessionPrefix = "SC1"
+)
+
+// Session is what a class-session code stands for: the class, and the
+// period if any, checked in for on Date
+type Session struct {
+	ID        string    `json:"n"`
+	ClassID   int64     `json:"c"`
+	PeriodID  int64     `json:"p,omitempty"`
+	Date      time.Time `json:"d"`
+	ExpiresAt time.Time `json:"e"`
+}
+
+// Signer makes and checks badges and session codes
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner returns a Signer for secret, which must be set: printed
+// badges are only as good as the secret they were signed with
+func NewSigner(secret string) (*Signer, error) {
+	if secret == "" {
+		return nil, ErrNoSecret
+	}
+	return &Signer{secret: []byte(secret)}, nil
+}
+
+// Badge returns the badge token of a student at a badge version
+func (s *Signer) Badge(studentID, version int64) string {
+	payload := badgePrefix + "." + strconv.FormatInt(studentID, 10) + "." + strconv.FormatInt(version, 10)
+	return payload + "." + s.sign(payload)
+}
+
+// ParseBadge returns the student a badge token belongs to and its badge
+// version; whether that version is still current is up to the caller
+func (s *Signer) ParseBadge(token string) (studentID, version int64, err error) {
+	payload, ok := s.verify(token, badgePrefix)
+	if !ok {
+		// badges printed before versions were added
+		if payload, ok = s.verify(token, badgeV1Prefix); ok {
+			payload += ".1"
+		}
+	}
+	id, v, found := strings.Cut(payload, ".")
+	if !ok || !found {
+		return 0, 0, fmt.Errorf("badge: %w", ErrInvalid)
+	}
+	studentID, err = strconv.ParseInt(id, 10, 64)
+	if err != nil || studentID <= 0 {
+		return 0, 0, fmt.Errorf("badge: %w", ErrInvalid)
+	}
+	version, err = strconv.ParseInt(v, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, 0, fmt.Errorf("badge: %w", ErrInvalid)
+	}
+	return studentID, version, nil
+}
+
+// NewSession returns the code of a session, filling in its ID
+func (s *Signer) NewSession(session *Session) (string, error) {
+	session.ID = random(8)
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	payload := sessionPrefix + "." + base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + s.sign(payload), nil
+}
+
+// ParseSession returns the session of a code that has not expired at now
+func (s *Signer) ParseSession(code string, now time.Time) (Session, error) {
+	var session Session
+	payload, ok := s.verify(code, sessionPrefix)
+	if !ok {
+		return session, fmt.Errorf("session: %w", ErrInvalid)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || json.Unmarshal(data, &session) != nil || session.ClassID <= 0 {
+		return session, fmt.Errorf("session: %w", ErrInvalid)
+	}
+	if !now.Before(session.ExpiresAt) {
+		return session, ErrExpired
+	}
+	return session, nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// verify checks the signature of a "<prefix>.<payload>.<signature>" token
+// and returns the payload
+func (s *Signer) verify(token, prefix string) (string, bool) {
+	signed, signature, ok := cutLast(strings.TrimSpace(token), ".")
+	if !ok {
+		return "", false
+	}
+	payload, ok := strings.CutPrefix(signed, prefix+".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(signed))) {
+		return "", false
+	}
+	return payload, true
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+func random(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package checkin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBadge(t *testing.T) {
+	s := newSigner(t, "secret")
+	badge := s.Badge(42, 3)
+	if id, version, err := s.ParseBadge(badge); err != nil || id != 42 || version != 3 {
+		t.Fatalf("ParseBadge(%q) = %d, %d, %v", badge, id, version, err)
+	}
+	if id, _, err := s.ParseBadge(" " + badge + "\n"); err != nil || id != 42 {
+		t.Errorf("scanned badge with whitespace = %d, %v", id, err)
+	}
+	if id, version, err := s.ParseBadge(signed(s, "SB1.42")); err != nil || id != 42 || version != 1 {
+		t.Errorf("SB1 badge = %d, %d, %v, want version 1", id, version, err)
+	}
+
+	for name, token := range map[string]string{
+		"forged id":        strings.Replace(badge, ".42.", ".43.", 1),
+		"forged version":   strings.Replace(badge, ".42.3.", ".42.4.", 1),
+		"other secret":     newSigner(t, "other").Badge(42, 3),
+		"session code":     mustSession(t, s, time.Now().Add(time.Minute)),
+		"no signature":     "SB2.42.3",
+		"garbage":          "hello",
+		"empty":            "",
+		"zero student":     signed(s, "SB2.0.1"),
+		"zero version":     signed(s, "SB2.42.0"),
+		"no version":       signed(s, "SB2.42"),
+		"non-numeric id":   signed(s, "SB2.abc.1"),
+		"SB1 with a dot":   signed(s, "SB1.42.3"),
+		"SB1 zero student": signed(s, "SB1.0"),
+	} {
+		if _, _, err := s.ParseBadge(token); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestNewSignerNeedsSecret(t *testing.T) {
+	if _, err := NewSigner(""); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("err = %v, want ErrNoSecret", err)
+	}
+}
+
+func TestSession(t *testing.T) {
+	s := newSigner(t, "secret")
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	session := Session{ClassID: 3, PeriodID: 2, Date: now.Truncate(24 * time.Hour), ExpiresAt: now.Add(20 * time.Minute)}
+	code, err := s.NewSession(&session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID == "" {
+		t.Error("session ID not set")
+	}
+
+	got, err := s.ParseSession(code, now.Add(19*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != session.ID || got.ClassID != 3 || got.PeriodID != 2 || !got.Date.Equal(session.Date) {
+		t.Errorf("ParseSession = %+v, want %+v", got, session)
+	}
+
+	if _, err := s.ParseSession(code, now.Add(20*time.Minute)); !errors.Is(err, ErrExpired) {
+		t.Errorf("at expiry err = %v, want ErrExpired", err)
+	}
+	if _, err := newSigner(t, "other").ParseSession(code, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("other secret err = %v, want ErrInvalid", err)
+	}
+	if _, err := s.ParseSession(s.Badge(3, 1), now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("badge as session err = %v, want ErrInvalid", err)
+	}
+}
+
+func newSigner(t *testing.T, secret string) *Signer {
+	t.Helper()
+	s, err := NewSigner(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func mustSession(t *testing.T, s *Signer, expires time.Time) string {
+	code, err := s.NewSession(&Session{ClassID: 1, Date: time.Now(), ExpiresAt: expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func signed(s *Signer, payload string) string {
+	return payload + "." + s.sign(payload)
+}
@@ -4,17 +4,221 @@ import (
 	"flag"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type HTTPServer struct {
-	Addr string `yaml:"address" env-required:"true"`
+	Addr           string `yaml:"address" env-required:"true"`
+	MaxBodyBytes   int64  `yaml:"max_body_bytes" env-default:"1048576"`
+	MaxUploadBytes int64  `yaml:"max_upload_bytes" env-default:"10485760"` // file uploads such as imports
 }
+
+// RateLimitRule configures the token bucket for one route group.
+// A zero RequestsPerSecond disables limiting for the group.
+type RateLimitRule struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// APIKey lets staff tools call the API; Name identifies the caller in logs.
+// A key with a TeacherID is a teacher's and only reaches the records of
+// that teacher's students; a key without one is the office's.
+type APIKey struct {
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+	TeacherID int64  `yaml:"teacher_id"`
+}
+
+// Auth configures staff authentication
+type Auth struct {
+	APIKeys []APIKey `yaml:"api_keys"`
+}
+
+type RateLimit struct {
+	Read  RateLimitRule `yaml:"read"`
+	Write RateLimitRule `yaml:"write"`
+}
+
+// Log configures the application logger. Empty values fall back to
+// per-environment defaults (text/debug in dev, json/info otherwise).
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json or text
+}
+
+// Tracing configures OpenTelemetry. Exporter is one of none, stdout or otlp;
+// the stdout exporter writes to File when set, which works offline.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"`
+	File        string  `yaml:"file"`
+	ServiceName string  `yaml:"service_name" env-default:"student-api"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// Health configures the readiness checks
+type Health struct {
+	CheckTimeout  time.Duration `yaml:"check_timeout" env-default:"2s"`
+	MinFreeDiskMB uint64        `yaml:"min_free_disk_mb" env-default:"100"`
+}
+
+// Analytics configures the aggregate endpoints
+type Analytics struct {
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"6h"` // how long closed-period results are kept
+}
+
+// Timetable configures how period attendance rolls up into the daily status
+type Timetable struct {
+	AbsentRatio       float64 `yaml:"absent_ratio" env-default:"0.5"`          // absent for the day when more than this share of periods is missed
+	LateIfFirstMissed bool    `yaml:"late_if_first_missed" env-default:"true"` // late for the day when the first period was missed
+}
+
+// Punctuality configures how check-in and check-out times become a status.
+// A daily record is due from the class's first period of the weekday to
+// its last, or from DayStart to DayEnd when the class has no timetable
+// that day. Classes may set their own grace in place of these.
+type Punctuality struct {
+	LateGrace       time.Duration `yaml:"late_grace" env-default:"5m"`        // checking in later than this after the start is late
+	EarlyLeaveGrace time.Duration `yaml:"early_leave_grace" env-default:"5m"` // checking out earlier than this before the end is leaving early
+	DayStart        string        `yaml:"day_start" env-default:"08:00"`
+	DayEnd          string        `yaml:"day_end" env-default:"15:00"`
+}
+
+// Calendar configures the school week
+type Calendar struct {
+	Weekend []string `yaml:"weekend" env-default:"Saturday,Sunday"` // weekdays without instruction
+}
+
+// Alerts configures the absenteeism rules over a rolling window of days.
+// A zero threshold disables its rule.
+type Alerts struct {
+	WindowDays          int     `yaml:"window_days" env-default:"30"`
+	MinAttendanceRate   float64 `yaml:"min_attendance_rate" env-default:"90"` // percent
+	MinRecords          int     `yaml:"min_records" env-default:"5"`          // records needed before the rate rule applies
+	ConsecutiveAbsences int     `yaml:"consecutive_absences" env-default:"3"`
+	LateCount           int     `yaml:"late_count" env-default:"5"`
+	NightlyAt           string  `yaml:"nightly_at" env-default:"02:00"` // local time of the nightly evaluation
+}
+
+// Guardians configures the guardian portal
+type Guardians struct {
+	SessionTTL time.Duration `yaml:"session_ttl" env-default:"720h"` // how long a login token is valid
+}
+
+// SMTP configures the email channel of notifications
+type SMTP struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port" env-default:"587"`
+	Username string        `yaml:"username" env:"SMTP_USERNAME"`
+	Password string        `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string        `yaml:"from"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"30s"` // bounds the whole exchange with the server
+}
+
+// SMSWebhook configures the SMS channel: messages are posted as JSON to an
+// SMS gateway
+type SMSWebhook struct {
+	URL     string        `yaml:"url"`
+	Token   string        `yaml:"token" env:"SMS_WEBHOOK_TOKEN"` // sent as a bearer token
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// Notifications configures absence notices to guardians. Mode is off,
+// mailbox or live; mailbox writes every message to MailboxDir instead of
+// sending it. Messages only go out between SendFrom and SendUntil, local time.
+type Notifications struct {
+	Mode        string        `yaml:"mode" env:"NOTIFICATIONS_MODE" env-default:"off"`
+	MailboxDir  string        `yaml:"mailbox_dir" env-default:"storage/mailbox"`
+	SchoolName  string        `yaml:"school_name" env-default:"School"`
+	SendFrom    string        `yaml:"send_from" env-default:"07:00"`
+	SendUntil   string        `yaml:"send_until" env-default:"19:00"`
+	Interval    time.Duration `yaml:"interval" env-default:"1m"` // how often queued messages are sent in a batch
+	BatchSize   int           `yaml:"batch_size" env-default:"100"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"3"`
+	SMTP        SMTP          `yaml:"smtp"`
+	SMS         SMSWebhook    `yaml:"sms"`
+}
+
+// Outbox configures the relay of domain events from the outbox table.
+// Published events are kept for Retention so the change feed can serve
+// them; zero keeps them forever.
+type Outbox struct {
+	Interval  time.Duration `yaml:"interval" env-default:"1s"` // how often new events are published
+	BatchSize int           `yaml:"batch_size" env-default:"100"`
+	Retention time.Duration `yaml:"retention" env-default:"720h"`
+}
+
+// CheckIn configures kiosk self check-in. Secret signs student badges and
+// class-session codes and must be set; the server does not start without
+// it. Whether a check-in is late follows Punctuality.
+type CheckIn struct {
+	Secret     string        `yaml:"secret" env:"CHECKIN_SECRET"`
+	SessionTTL time.Duration `yaml:"session_ttl" env-default:"20m"` // how long a session code is accepted
+}
+
+// Attachments configures uploaded files. They are kept under Dir and
+// accepted only when their content, sniffed rather than taken from the
+// upload, is one of AllowedTypes. Uploads are capped by
+// http_server.max_upload_bytes.
+type Attachments struct {
+	Dir          string   `yaml:"dir" env-default:"storage/attachments"`
+	AllowedTypes []string `yaml:"allowed_types" env-default:"application/pdf,image/jpeg,image/png,image/webp,image/heic"`
+}
+
+// Photos configures student profile photos, kept with their thumbnails
+// under Dir. Uploads are capped by http_server.max_upload_bytes, and at
+// most MaxConcurrent are decoded at a time; the others wait their turn.
+type Photos struct {
+	Dir           string `yaml:"dir" env-default:"storage/photos"`
+	MaxConcurrent int    `yaml:"max_concurrent" env-default:"2"`
+}
+
+// Stream configures the live Server-Sent Event streams
+type Stream struct {
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"` // comment sent to keep idle connections open
+	Buffer    int           `yaml:"buffer" env-default:"256"`    // events a client may fall behind before it is dropped
+}
+
+// Webhooks configures delivery of events to webhook subscribers. A failed
+// delivery is retried after BaseBackoff, doubling each time up to
+// MaxBackoff, and is moved to the dead-letter list after MaxAttempts.
+type Webhooks struct {
+	Interval    time.Duration `yaml:"interval" env-default:"5s"` // how often due deliveries are checked
+	BatchSize   int           `yaml:"batch_size" env-default:"50"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"8"`
+	BaseBackoff time.Duration `yaml:"base_backoff" env-default:"30s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env-default:"1h"`
+	// AllowPrivate lets webhooks reach loopback and private addresses, for
+	// receivers on the same machine or network in development
+	AllowPrivate bool `yaml:"allow_private" env-default:"false"`
+}
+
 type Config struct {
 	Env         string `yaml:"env" env:"ENV" env-required:"true" `
 	StoragePath string `yaml:"storage_path" env-required:"true"`
 	HTTPServer  `yaml:"http_server"`
+	RateLimit   RateLimit `yaml:"rate_limit"`
+	Auth        Auth      `yaml:"auth"`
+	Log         Log       `yaml:"log"`
+	Tracing     Tracing   `yaml:"tracing"`
+	Health      Health    `yaml:"health"`
+	Analytics   Analytics `yaml:"analytics"`
+	Alerts      Alerts    `yaml:"alerts"`
+	Calendar    Calendar  `yaml:"calendar"`
+	Timetable   Timetable `yaml:"timetable"`
+	Guardians   Guardians `yaml:"guardians"`
+
+	Notifications Notifications `yaml:"notifications"`
+	Outbox        Outbox        `yaml:"outbox"`
+	Webhooks      Webhooks      `yaml:"webhooks"`
+	Stream        Stream        `yaml:"stream"`
+	CheckIn       CheckIn       `yaml:"checkin"`
+	Punctuality   Punctuality   `yaml:"punctuality"`
+	Attachments   Attachments   `yaml:"attachments"`
+	Photos        Photos        `yaml:"photos"`
 }
 
 func MustLoad() *Config {
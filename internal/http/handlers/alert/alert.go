@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+type updateRequest struct {
+	Status string `json:"status" validate:"required,oneof=open acknowledged resolved"`
+	Note   string `json:"note"`
+}
+
+// GetList lists alerts, newest first, filtered by student_id, status and rule
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("getting alerts")
+
+		q := r.URL.Query()
+		filter := types.AlertFilter{Status: q.Get("status"), Rule: q.Get("rule")}
+		if v := q.Get("student_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid student_id %q", v)))
+				return
+			}
+			filter.StudentID = id
+		}
+
+		alerts, err := storage.GetAlerts(r.Context(), filter)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, alerts)
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("getting an alert", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		alert, err := storage.GetAlertById(r.Context(), intId)
+		if err != nil {
+			log.Error("error getting alert", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, alert)
+	}
+}
+
+// UpdateById moves an alert between open, acknowledged and resolved
+func UpdateById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Updating alert", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		var req updateRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+
+		err = storage.UpdateAlertStatus(r.Context(), intId, req.Status, req.Note)
+		if err != nil {
+			log.Error("error updating alert", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Alert updated successfully"})
+	}
+}
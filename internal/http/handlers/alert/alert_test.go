@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage holds alerts in memory. Other methods fall through to the nil
+// embedded interface.
+type mockStorage struct {
+	storage.Storage
+	alerts map[int64]types.Alert
+	filter types.AlertFilter
+}
+
+func (m *mockStorage) GetAlerts(ctx context.Context, filter types.AlertFilter) ([]types.Alert, error) {
+	m.filter = filter
+	var out []types.Alert
+	for _, a := range m.alerts {
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (m *mockStorage) GetAlertById(ctx context.Context, id int64) (types.Alert, error) {
+	a, ok := m.alerts[id]
+	if !ok {
+		return types.Alert{}, sql.ErrNoRows
+	}
+	return a, nil
+}
+
+func (m *mockStorage) UpdateAlertStatus(ctx context.Context, id int64, status, note string) error {
+	a := m.alerts[id]
+	a.Status = status
+	m.alerts[id] = a
+	return nil
+}
+
+func newMock() *mockStorage {
+	return &mockStorage{alerts: map[int64]types.Alert{1: {Id: 1, StudentID: 7, Status: "open"}}}
+}
+
+func TestGetList(t *testing.T) {
+	m := newMock()
+	rr := httptest.NewRecorder()
+	GetList(m)(rr, httptest.NewRequest("GET", "/api/alerts?student_id=7&status=open", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if m.filter.StudentID != 7 || m.filter.Status != "open" {
+		t.Errorf("unexpected filter %+v", m.filter)
+	}
+
+	rr = httptest.NewRecorder()
+	GetList(m)(rr, httptest.NewRequest("GET", "/api/alerts?student_id=x", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestGetById(t *testing.T) {
+	for id, want := range map[string]int{"1": http.StatusOK, "2": http.StatusNotFound, "x": http.StatusBadRequest} {
+		req := httptest.NewRequest("GET", "/api/alerts/"+id, nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		GetById(newMock())(rr, req)
+		if rr.Code != want {
+			t.Errorf("alert %s: expected status code %d, got %d", id, want, rr.Code)
+		}
+	}
+}
+
+func TestUpdateById(t *testing.T) {
+	cases := []struct {
+		name, body string
+		want       int
+	}{
+		{"acknowledge", `{"status":"acknowledged","note":"called parents"}`, http.StatusOK},
+		{"empty body", ``, http.StatusBadRequest},
+		{"unknown status", `{"status":"closed"}`, http.StatusBadRequest},
+		{"too large", `{"status":"resolved","note":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, c := range cases {
+		m := newMock()
+		req := httptest.NewRequest("PUT", "/api/alerts/1", strings.NewReader(c.body))
+		req.SetPathValue("id", "1")
+		rr := httptest.NewRecorder()
+		middleware.MaxBodySize(64)(UpdateById(m)).ServeHTTP(rr, req)
+		if rr.Code != c.want {
+			t.Errorf("%s: expected status code %d, got %d: %s", c.name, c.want, rr.Code, rr.Body)
+		}
+		if c.want == http.StatusOK && m.alerts[1].Status != "acknowledged" {
+			t.Errorf("%s: status not updated, got %q", c.name, m.alerts[1].Status)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+type AttendanceResponse struct {
+	GroupBy string                  `json:"group_by"`
+	From    string                  `json:"from,omitempty"`
+	To      string                  `json:"to,omitempty"`
+	Cached  bool                    `json:"cached"`
+	Data    []types.AttendanceStats `json:"data"`
+}
+
+// Attendance serves attendance aggregates grouped by class, grade, section,
+// day, week, month or weekday over an optional date range, narrowed by
+// class_id or grade. sort=rate orders the groups from the lowest rate up.
+func Attendance(storage storage.Storage, cache *Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		q := r.URL.Query()
+
+		query := types.AnalyticsQuery{GroupBy: q.Get("group_by"), Grade: q.Get("grade")}
+		if query.GroupBy == "" {
+			query.GroupBy = "class"
+		}
+		var err error
+		query.From, query.To, err = dates.QueryRange(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if v := q.Get("class_id"); v != "" {
+			query.ClassID, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid class_id %q", v)))
+				return
+			}
+		}
+		log.Info("computing attendance analytics", slog.String("group_by", query.GroupBy))
+
+		key := fmt.Sprintf("%s|%s|%s|%d|%s", query.GroupBy, query.From.Format(dates.Layout), query.To.Format(dates.Layout), query.ClassID, query.Grade)
+		closed := !query.To.IsZero() && query.To.Before(today())
+
+		stats, cached := []types.AttendanceStats(nil), false
+		if closed {
+			stats, cached = cache.get(key)
+		}
+		if !cached {
+			stats, err = storage.GetAttendanceAnalytics(r.Context(), query)
+			if err != nil {
+				log.Error("error computing attendance analytics", slog.String("error", err.Error()))
+				response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+				return
+			}
+			if closed {
+				cache.set(key, stats)
+			}
+		}
+
+		if q.Get("sort") == "rate" {
+			stats = append([]types.AttendanceStats(nil), stats...)
+			sort.SliceStable(stats, func(i, j int) bool { return stats[i].AttendanceRate < stats[j].AttendanceRate })
+		}
+
+		resp := AttendanceResponse{GroupBy: query.GroupBy, Cached: cached, Data: stats}
+		if !query.From.IsZero() {
+			resp.From = query.From.Format(dates.Layout)
+		}
+		if !query.To.IsZero() {
+			resp.To = query.To.Format(dates.Layout)
+		}
+		response.WriteJson(w, http.StatusOK, resp)
+	}
+}
+
+// errorStatus is 400 for a grouping the storage does not know, and 500 for
+// anything else
+func errorStatus(err error) int {
+	if errors.Is(err, storage.ErrInvalidGroupBy) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
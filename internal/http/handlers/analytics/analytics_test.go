@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage fails analytics with err. Other methods fall through to the
+// nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	err error
+}
+
+func (m *mockStorage) GetAttendanceAnalytics(ctx context.Context, q types.AnalyticsQuery) ([]types.AttendanceStats, error) {
+	return nil, m.err
+}
+
+func TestAttendanceErrorStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w %q", storage.ErrInvalidGroupBy, "year"), http.StatusBadRequest},
+		{errors.New("database is locked"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		Attendance(&mockStorage{err: c.err}, NewCache(time.Hour))(rr, httptest.NewRequest("GET", "/api/analytics/attendance", nil))
+		if rr.Code != c.want {
+			t.Errorf("%v: expected status code %d, got %d", c.err, c.want, rr.Code)
+		}
+	}
+}
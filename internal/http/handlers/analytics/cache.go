@@ -0,0 +1,58 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// maxEntries bounds the cache; expired entries are swept once it is reached
+const maxEntries = 512
+
+// Cache keeps the analytics of closed periods, i.e. ranges that ended
+// before today, so repeated dashboard loads skip the aggregate queries.
+// Late corrections to a closed period show up once the entry expires.
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	stats   []types.AttendanceStats
+	expires time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]cacheEntry), now: time.Now}
+}
+
+func (c *Cache) get(key string) ([]types.AttendanceStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.stats, true
+}
+
+func (c *Cache) set(key string, stats []types.AttendanceStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxEntries {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= maxEntries {
+		return
+	}
+	c.entries[key] = cacheEntry{stats: stats, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,24 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := NewCache(time.Hour)
+	cache.now = func() time.Time { return now }
+
+	cache.set("class|2024-01-01|2024-01-31|0|", []types.AttendanceStats{{Key: "1", Records: 10}})
+	if stats, ok := cache.get("class|2024-01-01|2024-01-31|0|"); !ok || len(stats) != 1 {
+		t.Fatalf("expected cached stats, got %v %v", stats, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := cache.get("class|2024-01-01|2024-01-31|0|"); ok {
+		t.Error("entry should expire after the ttl")
+	}
+}
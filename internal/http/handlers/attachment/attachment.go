@@ -0,0 +1,422 @@
+// Package attachment handles files kept for students, leave requests and
+// attendance records. Uploads are multipart; the content type is sniffed
+// from the bytes rather than trusted from the client, and the content is
+// written to the blob store with its SHA-256 recorded alongside.
+//
+// The staff handlers expect middleware.APIKeys.Require in front of them. A
+// teacher's key only reaches the attachments of the teacher's students;
+// the others are reported as not found, like a guardian's.
+package attachment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/tukesh1/student-api/internal/http/handlers/guardian"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// maxFilename caps the length of a stored filename, in bytes
+const maxFilename = 200
+
+// errUnsupportedType is returned for content outside the allowed types
+var errUnsupportedType = errors.New("unsupported file type")
+
+// Upload stores the multipart "file" of a request as an attachment of the
+// owner in the path, of the given type, uploaded by the staff user whose
+// API key middleware.APIKeys.Require accepted
+func Upload(storage storage.Storage, blobs storage.BlobStore, allowed []string, ownerType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		ownerID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		log.Info("uploading an attachment", slog.String("owner", ownerType), slog.Int64("ownerId", ownerID))
+		if !owner(w, r, storage, ownerType, ownerID) {
+			return
+		}
+		staff, _ := middleware.StaffFromContext(r.Context())
+		save(w, r, storage, blobs, allowed, types.Attachment{OwnerType: ownerType, OwnerID: ownerID, UploadedBy: "staff:" + staff})
+	}
+}
+
+// List lists the attachments of the owner in the path
+func List(storage storage.Storage, ownerType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		ownerID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		log.Info("getting attachments", slog.String("owner", ownerType), slog.Int64("ownerId", ownerID))
+		if !owner(w, r, storage, ownerType, ownerID) {
+			return
+		}
+		list(w, r, storage, ownerType, ownerID)
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := staffAttachment(w, r, storage)
+		if !ok {
+			return
+		}
+		response.WriteJson(w, http.StatusOK, a)
+	}
+}
+
+// Download serves the content of an attachment
+func Download(storage storage.Storage, blobs storage.BlobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := staffAttachment(w, r, storage)
+		if !ok {
+			return
+		}
+		serve(w, r, blobs, a)
+	}
+}
+
+// Delete deletes an attachment and then its content
+func Delete(storage storage.Storage, blobs storage.BlobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		a, ok := staffAttachment(w, r, storage)
+		if !ok {
+			return
+		}
+		if err := storage.DeleteAttachment(r.Context(), a.Id); err != nil {
+			log.Error("error deleting attachment", slog.Int64("id", a.Id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		// the record is gone, so a blob left behind is only wasted space
+		if err := blobs.Delete(r.Context(), a.BlobKey); err != nil {
+			log.Warn("error deleting attachment content", slog.Int64("id", a.Id), slog.String("error", err.Error()))
+		}
+		log.Info("attachment deleted", slog.Int64("id", a.Id))
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Attachment deleted successfully"})
+	}
+}
+
+// GuardianUpload attaches a file, such as a medical certificate, to a
+// leave request the logged-in guardian made
+func GuardianUpload(storage storage.Storage, blobs storage.BlobStore, allowed []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("guardian uploading an attachment")
+		leave, ok := guardianLeave(w, r, storage)
+		if !ok {
+			return
+		}
+		save(w, r, storage, blobs, allowed, types.Attachment{OwnerType: types.OwnerLeaveRequest, OwnerID: leave.Id,
+			UploadedBy: fmt.Sprintf("guardian:%d", guardian.FromContext(r.Context()))})
+	}
+}
+
+// GuardianList lists the attachments of a leave request the logged-in
+// guardian made
+func GuardianList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting the attachments of a guardian's leave request")
+		leave, ok := guardianLeave(w, r, storage)
+		if !ok {
+			return
+		}
+		list(w, r, storage, types.OwnerLeaveRequest, leave.Id)
+	}
+}
+
+// GuardianDownload serves an attachment of one of the logged-in guardian's
+// students. Attachments of other students are reported as not found.
+func GuardianDownload(storage storage.Storage, blobs storage.BlobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := attachment(w, r, storage)
+		if !ok {
+			return
+		}
+		students, err := storage.GetGuardianStudents(r.Context(), guardian.FromContext(r.Context()))
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if !slices.ContainsFunc(students, func(s types.Student) bool { return s.Id == a.StudentID }) {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("no attachment found with id %d", a.Id)))
+			return
+		}
+		serve(w, r, blobs, a)
+	}
+}
+
+// save sniffs, checks and stores the uploaded file, then records it as a.
+// The content is deleted again if it cannot be recorded.
+func save(w http.ResponseWriter, r *http.Request, storage storage.Storage, blobs storage.BlobStore, allowed []string, a types.Attachment) {
+	log := logger.FromRequest(r)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.WriteJson(w, uploadStatus(err), response.GeneralError(fmt.Errorf("reading file field: %w", err)))
+		return
+	}
+	defer file.Close()
+	if header.Size == 0 {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("empty file")))
+		return
+	}
+
+	contentType, err := sniff(file, allowed)
+	if err != nil {
+		response.WriteJson(w, uploadStatus(err), response.GeneralError(err))
+		return
+	}
+	a.ContentType = contentType.String()
+	a.Filename = filename(header, contentType)
+	a.Size = header.Size
+	a.BlobKey = newKey()
+
+	hash := sha256.New()
+	if err := blobs.Put(r.Context(), a.BlobKey, io.TeeReader(file, hash)); err != nil {
+		log.Error("error storing attachment", slog.String("error", err.Error()))
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	a.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if a.Id, err = storage.CreateAttachment(r.Context(), a); err != nil {
+		log.Error("error creating attachment", slog.String("error", err.Error()))
+		if err := blobs.Delete(r.Context(), a.BlobKey); err != nil {
+			log.Warn("error deleting orphaned attachment content", slog.String("key", a.BlobKey), slog.String("error", err.Error()))
+		}
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	log.Info("attachment created successfully", slog.Int64("id", a.Id), slog.String("type", a.ContentType), slog.Int64("size", a.Size))
+	a, err = storage.GetAttachmentById(r.Context(), a.Id)
+	if err != nil {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	response.WriteJson(w, http.StatusCreated, a)
+}
+
+// serve writes the content of an attachment. It is always sent as a
+// download, with the stored type and without sniffing, and its checksum
+// doubles as the ETag so conditional and range requests work.
+func serve(w http.ResponseWriter, r *http.Request, blobs storage.BlobStore, a types.Attachment) {
+	content, err := blobs.Open(r.Context(), a.BlobKey)
+	if err != nil {
+		logger.FromRequest(r).Error("error opening attachment content", slog.Int64("id", a.Id), slog.String("error", err.Error()))
+		response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+		return
+	}
+	defer content.Close()
+
+	h := w.Header()
+	h.Set("Content-Type", a.ContentType)
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Cache-Control", "private, no-cache")
+	h.Set("ETag", `"`+a.SHA256+`"`)
+	if sum, err := hex.DecodeString(a.SHA256); err == nil {
+		h.Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+	}
+	http.ServeContent(w, r, "", a.CreatedAt, content)
+}
+
+func list(w http.ResponseWriter, r *http.Request, storage storage.Storage, ownerType string, ownerID int64) {
+	attachments, err := storage.GetAttachments(r.Context(), ownerType, ownerID)
+	if err != nil {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	response.WriteJson(w, http.StatusOK, attachments)
+}
+
+// attachment reads the attachment in the path, writing the error response
+// itself
+func attachment(w http.ResponseWriter, r *http.Request, storage storage.Storage) (types.Attachment, bool) {
+	log := logger.FromRequest(r)
+	id := r.PathValue("id")
+	log.Info("getting an attachment", slog.String("id", id))
+	intId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+		return types.Attachment{}, false
+	}
+	a, err := storage.GetAttachmentById(r.Context(), intId)
+	if err != nil {
+		log.Error("error getting attachment", slog.String("id", id), slog.String("error", err.Error()))
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+		return a, false
+	}
+	return a, true
+}
+
+// staffAttachment reads the attachment in the path like attachment, and
+// reports it as not found unless the staff user may see its student
+func staffAttachment(w http.ResponseWriter, r *http.Request, storage storage.Storage) (types.Attachment, bool) {
+	a, ok := attachment(w, r, storage)
+	if !ok {
+		return a, false
+	}
+	if ok, err := mayAccess(r, storage, a.StudentID); err != nil {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return a, false
+	} else if !ok {
+		logger.FromRequest(r).Warn("attachment of another teacher's student refused", slog.Int64("id", a.Id))
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("no attachment found with id %d", a.Id)))
+		return a, false
+	}
+	return a, true
+}
+
+// owner checks that the owner of attachments exists and that the staff
+// user may see its student, writing the error response itself
+func owner(w http.ResponseWriter, r *http.Request, storage storage.Storage, ownerType string, id int64) bool {
+	studentID, err := ownerStudent(r, storage, ownerType, id)
+	if err != nil {
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+		return false
+	}
+	if ok, err := mayAccess(r, storage, studentID); err != nil {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return false
+	} else if !ok {
+		logger.FromRequest(r).Warn("attachments of another teacher's student refused", slog.String("owner", ownerType), slog.Int64("ownerId", id))
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("no %s found with id %d", strings.ReplaceAll(ownerType, "_", " "), id)))
+		return false
+	}
+	return true
+}
+
+// mayAccess reports whether the staff user may see the records of a
+// student: an office key sees every student, a teacher's key the students
+// of the teacher's classes
+func mayAccess(r *http.Request, storage storage.Storage, studentID int64) (bool, error) {
+	teacherID := middleware.TeacherFromContext(r.Context())
+	if teacherID == 0 {
+		return true, nil
+	}
+	return storage.TeachesStudent(r.Context(), teacherID, studentID)
+}
+
+// guardianLeave reads the leave request in the path, which must be one the
+// logged-in guardian made
+func guardianLeave(w http.ResponseWriter, r *http.Request, storage storage.Storage) (types.LeaveRequest, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+		return types.LeaveRequest{}, false
+	}
+	leave, err := storage.GetLeaveRequestById(r.Context(), id)
+	if err != nil || leave.GuardianID != guardian.FromContext(r.Context()) {
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("no leave request found with id %d", id)))
+		return leave, false
+	}
+	return leave, true
+}
+
+// ownerStudent returns the student the owner of an attachment belongs to,
+// failing when the owner does not exist
+func ownerStudent(r *http.Request, storage storage.Storage, ownerType string, id int64) (int64, error) {
+	switch ownerType {
+	case types.OwnerStudent:
+		student, err := storage.GetStudentById(r.Context(), id)
+		return student.Id, err
+	case types.OwnerLeaveRequest:
+		leave, err := storage.GetLeaveRequestById(r.Context(), id)
+		return leave.StudentID, err
+	case types.OwnerAttendance:
+		record, err := storage.GetAttendanceById(r.Context(), id)
+		return record.StudentID, err
+	}
+	return 0, fmt.Errorf("unknown attachment owner %q", ownerType)
+}
+
+// sniff detects the type of a file from its first bytes and checks it is
+// allowed, leaving the file at its start
+func sniff(file multipart.File, allowed []string) (*mimetype.MIME, error) {
+	contentType, err := mimetype.DetectReader(file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for _, t := range allowed {
+		if contentType.Is(t) {
+			return contentType, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %s, expected one of %s", errUnsupportedType, contentType, strings.Join(allowed, ", "))
+}
+
+// filename returns a safe name to store for an upload: the last element of
+// the client's name without control characters, or a generic name with
+// the extension of the sniffed type
+func filename(header *multipart.FileHeader, contentType *mimetype.MIME) string {
+	name := path.Base(strings.ReplaceAll(header.Filename, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "attachment" + contentType.Extension()
+	}
+	for len(name) > maxFilename {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// newKey returns a random blob key, spread over directories by its first
+// two characters
+func newKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	key := hex.EncodeToString(b)
+	return key[:2] + "/" + key
+}
+
+func uploadStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,152 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/storage/localfs"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage has attachment 1 of student 1, taught by teacher 4, and
+// attachment 2 of student 2, taught by nobody. Other methods fall through
+// to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+}
+
+var attachments = map[int64]types.Attachment{
+	1: {Id: 1, OwnerType: types.OwnerStudent, OwnerID: 1, StudentID: 1, Filename: "note.pdf", ContentType: "application/pdf", SHA256: "00", BlobKey: "ab/one"},
+	2: {Id: 2, OwnerType: types.OwnerStudent, OwnerID: 2, StudentID: 2, Filename: "note.pdf", ContentType: "application/pdf", SHA256: "00", BlobKey: "ab/two"},
+}
+
+func (m *mockStorage) GetAttachmentById(ctx context.Context, id int64) (types.Attachment, error) {
+	a, ok := attachments[id]
+	if !ok {
+		return a, fmt.Errorf("no attachment found with id %d", id)
+	}
+	return a, nil
+}
+
+func (m *mockStorage) GetAttachments(ctx context.Context, ownerType string, ownerID int64) ([]types.Attachment, error) {
+	return []types.Attachment{attachments[ownerID]}, nil
+}
+
+func (m *mockStorage) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	return types.Student{Id: id}, nil
+}
+
+func (m *mockStorage) TeachesStudent(ctx context.Context, teacherID, studentID int64) (bool, error) {
+	return teacherID == 4 && studentID == 1, nil
+}
+
+func request(method, url, id, key string) *http.Request {
+	req := httptest.NewRequest(method, url, nil)
+	req.SetPathValue("id", id)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	return req
+}
+
+func TestStaffAccess(t *testing.T) {
+	blobs, err := localfs.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"ab/one", "ab/two"} {
+		if err := blobs.Put(context.Background(), key, strings.NewReader("%PDF-1.4 "+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := middleware.NewAPIKeys([]config.APIKey{{Name: "office", Key: "office-key"}, {Name: "sam-lee", Key: "lee-key", TeacherID: 4}})
+	m := &mockStorage{}
+	download := keys.Require(Download(m, blobs))
+	list := keys.Require(List(m, types.OwnerStudent))
+
+	cases := []struct {
+		handler http.HandlerFunc
+		url     string
+		id, key string
+		want    int
+	}{
+		{download, "/api/attachments/1/content", "1", "", http.StatusUnauthorized},
+		{download, "/api/attachments/1/content", "1", "wrong", http.StatusUnauthorized},
+		{download, "/api/attachments/2/content", "2", "office-key", http.StatusOK},
+		{download, "/api/attachments/1/content", "1", "lee-key", http.StatusOK},
+		{download, "/api/attachments/2/content", "2", "lee-key", http.StatusNotFound},
+		{list, "/api/students/1/attachments", "1", "", http.StatusUnauthorized},
+		{list, "/api/students/1/attachments", "1", "lee-key", http.StatusOK},
+		{list, "/api/students/2/attachments", "2", "lee-key", http.StatusNotFound},
+		{list, "/api/students/2/attachments", "2", "office-key", http.StatusOK},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		c.handler(rr, request("GET", c.url, c.id, c.key))
+		if rr.Code != c.want {
+			t.Errorf("%s with key %q: expected status code %d, got %d", c.url, c.key, c.want, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	download(rr, request("GET", "/api/attachments/1/content", "1", "lee-key"))
+	if body := rr.Body.String(); body != "%PDF-1.4 ab/one" || rr.Header().Get("Content-Disposition") != `attachment; filename=note.pdf` {
+		t.Errorf("unexpected download %q with headers %v", body, rr.Header())
+	}
+}
+
+// uploadStorage records the attachment it is given as attachment 3
+type uploadStorage struct {
+	mockStorage
+	created types.Attachment
+}
+
+func (m *uploadStorage) CreateAttachment(ctx context.Context, a types.Attachment) (int64, error) {
+	a.Id = 3
+	m.created = a
+	return a.Id, nil
+}
+
+func (m *uploadStorage) GetAttachmentById(ctx context.Context, id int64) (types.Attachment, error) {
+	if id == m.created.Id {
+		return m.created, nil
+	}
+	return m.mockStorage.GetAttachmentById(ctx, id)
+}
+
+func TestUploadRecordsStaff(t *testing.T) {
+	blobs, err := localfs.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := middleware.NewAPIKeys([]config.APIKey{{Name: "office", Key: "office-key"}})
+	m := &uploadStorage{}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("uploaded_by", "someone else")
+	file, _ := form.CreateFormFile("file", "note.pdf")
+	file.Write([]byte("%PDF-1.4 note"))
+	form.Close()
+	req := httptest.NewRequest("POST", "/api/students/1/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-API-Key", "office-key")
+	req.SetPathValue("id", "1")
+	rr := httptest.NewRecorder()
+	keys.Require(Upload(m, blobs, []string{"application/pdf"}, types.OwnerStudent))(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	if m.created.UploadedBy != "staff:office" {
+		t.Errorf("expected the upload to be recorded as staff:office, got %q", m.created.UploadedBy)
+	}
+}
@@ -0,0 +1,217 @@
+package attendance
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/export"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// markRequest is the body for marking attendance; date is YYYY-MM-DD. With
+// a period_id the mark is for that period and rolls up into the daily record.
+// Without a status, check_in_at decides between Present and Late.
+type markRequest struct {
+	StudentID  int64      `json:"student_id" validate:"required"`
+	ClassID    int64      `json:"class_id" validate:"required"`
+	PeriodID   int64      `json:"period_id"`
+	Date       string     `json:"date" validate:"required"`
+	Status     string     `json:"status" validate:"required_without=CheckInAt,omitempty,oneof=Present Absent Late Excused"`
+	Remarks    string     `json:"remarks"`
+	CheckInAt  *time.Time `json:"check_in_at"`
+	CheckOutAt *time.Time `json:"check_out_at"`
+}
+
+// updateRequest changes a record; times left out keep the recorded ones
+type updateRequest struct {
+	Status     string     `json:"status" validate:"required_without_all=CheckInAt CheckOutAt,omitempty,oneof=Present Absent Late Excused"`
+	Remarks    string     `json:"remarks"`
+	CheckInAt  *time.Time `json:"check_in_at"`
+	CheckOutAt *time.Time `json:"check_out_at"`
+}
+
+var exportTable = export.Table[types.AttendanceRecord]{
+	Columns: []string{"id", "student_id", "class_id", "period_id", "date", "status", "remarks", "check_in_at", "check_out_at", "minutes_late", "minutes_early"},
+	Row: func(a types.AttendanceRecord) []any {
+		return []any{a.Id, a.StudentID, a.ClassID, a.PeriodID, a.Date, a.Status, a.Remarks, timestamp(a.CheckInAt), timestamp(a.CheckOutAt), a.MinutesLate, a.MinutesEarly}
+	},
+}
+
+func New(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("marking attendance")
+		var req markRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		date, err := dates.Parse(req.Date)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		times := types.AttendanceTimes{CheckInAt: req.CheckInAt, CheckOutAt: req.CheckOutAt}
+		if err := checkTimes(times); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		var lastId int64
+		if req.PeriodID != 0 {
+			lastId, err = storage.CreatePeriodAttendance(r.Context(), req.StudentID, req.ClassID, req.PeriodID, date, req.Status, req.Remarks, times)
+		} else {
+			lastId, err = storage.CreateAttendanceRecord(r.Context(), req.StudentID, req.ClassID, date, req.Status, req.Remarks, times)
+		}
+		if rejected(err) {
+			response.WriteJson(w, http.StatusUnprocessableEntity, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			log.Error("error marking attendance", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("attendance marked successfully", slog.String("attendanceId", fmt.Sprint(lastId)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": lastId})
+	}
+}
+
+// GetList lists attendance records filtered by student_id, class_id,
+// period_id, status and a date or from/to range; scope=period|all includes
+// period records, which are left out by default. It also serves csv, xlsx and ndjson
+// exports through content negotiation.
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("getting attendance records")
+		filter, err := parseFilter(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if format := export.Negotiate(r); format != export.JSON {
+			export.Stream(w, r, format, "attendance", exportTable, func(fn func(types.AttendanceRecord) error) error {
+				return storage.StreamAttendance(r.Context(), filter, fn)
+			})
+			return
+		}
+
+		records, err := storage.GetAttendance(r.Context(), filter)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, records)
+	}
+}
+
+func UpdateById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Updating attendance record", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var req updateRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		times := types.AttendanceTimes{CheckInAt: req.CheckInAt, CheckOutAt: req.CheckOutAt}
+		if err := checkTimes(times); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		err = storage.UpdateAttendanceRecord(r.Context(), intId, req.Status, req.Remarks, times)
+		if err != nil {
+			log.Error("error updating attendance record", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Attendance record updated successfully"})
+	}
+}
+
+func DeleteById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting attendance record", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		err = storage.DeleteAttendanceRecord(r.Context(), intId)
+		if err != nil {
+			log.Error("error deleting attendance record", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Attendance record deleted successfully"})
+	}
+}
+
+// rejected reports whether a write was refused by the school calendar or
+// the class timetable
+func rejected(err error) bool {
+	return errors.Is(err, storage.ErrNonInstructionalDay) || errors.Is(err, storage.ErrPeriodNotScheduled)
+}
+
+// timestamp writes a check-in or check-out time in full, as exports write
+// other times as dates
+func timestamp(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+// checkTimes refuses a check-out that is not after the check-in
+func checkTimes(times types.AttendanceTimes) error {
+	if times.CheckInAt != nil && times.CheckOutAt != nil && !times.CheckOutAt.After(*times.CheckInAt) {
+		return fmt.Errorf("check_out_at must be after check_in_at")
+	}
+	return nil
+}
+
+func parseFilter(r *http.Request) (types.AttendanceFilter, error) {
+	var filter types.AttendanceFilter
+	q := r.URL.Query()
+	for name, dst := range map[string]*int64{"student_id": &filter.StudentID, "class_id": &filter.ClassID, "period_id": &filter.PeriodID} {
+		if v := q.Get(name); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s %q", name, v)
+			}
+			*dst = id
+		}
+	}
+	filter.Status = q.Get("status")
+	switch filter.Scope = q.Get("scope"); filter.Scope {
+	case "", types.ScopeDaily, types.ScopePeriod, types.ScopeAll:
+	default:
+		return filter, fmt.Errorf("invalid scope %q", filter.Scope)
+	}
+
+	var err error
+	filter.From, filter.To, err = dates.QueryRange(r)
+	return filter, err
+}
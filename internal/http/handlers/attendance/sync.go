@@ -0,0 +1,140 @@
+package attendance
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// syncDeltaLimit caps the changes returned; the rest are read from the
+// change feed
+const syncDeltaLimit = 500
+
+// syncRequest is an upload of attendance changes made offline, in the
+// order they were made, with the change feed seq the client last saw. A
+// client with more than 500 changes sends them in batches.
+type syncRequest struct {
+	Since     int64             `json:"since" validate:"min=0"`
+	Mutations []mutationRequest `json:"mutations" validate:"max=500,dive"`
+}
+
+// mutationRequest is one change; an upsert marks the record and a delete
+// removes it. Date is YYYY-MM-DD; modified_at and the check-in and
+// check-out times are RFC 3339.
+type mutationRequest struct {
+	ClientID   string     `json:"client_id" validate:"required,uuid"`
+	Op         string     `json:"op" validate:"required,oneof=upsert delete"`
+	StudentID  int64      `json:"student_id" validate:"required"`
+	ClassID    int64      `json:"class_id" validate:"required_if=Op upsert"`
+	PeriodID   int64      `json:"period_id"`
+	Date       string     `json:"date" validate:"required"`
+	Status     string     `json:"status" validate:"required_if=Op upsert,omitempty,oneof=Present Absent Late Excused"`
+	Remarks    string     `json:"remarks"`
+	CheckInAt  *time.Time `json:"check_in_at"`
+	CheckOutAt *time.Time `json:"check_out_at"`
+	ModifiedAt time.Time  `json:"modified_at" validate:"required"`
+}
+
+// syncResponse has one result per mutation, in upload order, and the
+// server changes after since. Reset says since is older than the changes
+// still kept: the client must reload and continue from next.
+type syncResponse struct {
+	Results []types.SyncResult `json:"results"`
+	Changes []types.Event      `json:"changes"`
+	Next    int64              `json:"next"`
+	HasMore bool               `json:"has_more"`
+	Reset   bool               `json:"reset,omitempty"`
+}
+
+// Sync applies a batch of attendance changes a client made offline and
+// answers with what became of each and the changes it has not seen. Every
+// mutation is applied on its own, so a failed upload can be sent again as
+// it is: mutations already applied return their first outcome. Conflicts
+// with the server copy go to the last writer by modified_at; see
+// SyncAttendance in the storage for the rules.
+func Sync(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		var req syncRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		log.Info("syncing attendance", slog.Int("mutations", len(req.Mutations)), slog.Int64("since", req.Since))
+
+		result := syncResponse{Results: make([]types.SyncResult, 0, len(req.Mutations))}
+		counts := map[string]int{}
+		for _, m := range req.Mutations {
+			outcome, err := apply(r, storage, m)
+			if err != nil {
+				log.Error("error syncing attendance", slog.String("clientId", m.ClientID), slog.String("error", err.Error()))
+				response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+			counts[outcome.Outcome]++
+			result.Results = append(result.Results, outcome)
+		}
+
+		if err := delta(r, storage, req.Since, &result); err != nil {
+			log.Error("error getting changes for sync", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("attendance synced", slog.Int("applied", counts[types.SyncApplied]),
+			slog.Int("conflicts", counts[types.SyncConflict]), slog.Int("rejected", counts[types.SyncRejected]))
+		response.WriteJson(w, http.StatusOK, result)
+	}
+}
+
+func apply(r *http.Request, storage storage.Storage, m mutationRequest) (types.SyncResult, error) {
+	date, err := dates.Parse(m.Date)
+	if err == nil {
+		err = checkTimes(types.AttendanceTimes{CheckInAt: m.CheckInAt, CheckOutAt: m.CheckOutAt})
+	}
+	if err != nil {
+		return types.SyncResult{ClientID: m.ClientID, Outcome: types.SyncRejected, Error: err.Error()}, nil
+	}
+	return storage.SyncAttendance(r.Context(), types.SyncMutation{
+		ClientID:   m.ClientID,
+		Op:         m.Op,
+		StudentID:  m.StudentID,
+		ClassID:    m.ClassID,
+		PeriodID:   m.PeriodID,
+		Date:       date,
+		Status:     m.Status,
+		Remarks:    m.Remarks,
+		CheckInAt:  m.CheckInAt,
+		CheckOutAt: m.CheckOutAt,
+		ModifiedAt: m.ModifiedAt,
+	})
+}
+
+// delta fills in the change feed after since, the client's own changes
+// included
+func delta(r *http.Request, storage storage.Storage, since int64, result *syncResponse) error {
+	first, last, err := storage.GetEventSeqRange(r.Context())
+	if err != nil {
+		return err
+	}
+	if first > 0 && since < first-1 {
+		result.Changes, result.Next, result.Reset = []types.Event{}, last, true
+		return nil
+	}
+	events, err := storage.GetEvents(r.Context(), since, syncDeltaLimit+1)
+	if err != nil {
+		return err
+	}
+	result.Changes, result.Next = events, since
+	if len(events) > syncDeltaLimit {
+		result.Changes, result.HasMore = events[:syncDeltaLimit], true
+	}
+	if n := len(result.Changes); n > 0 {
+		result.Next = result.Changes[n-1].Seq
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package attendance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage keeps sync outcomes by client id like the real storage, and
+// treats a record deleted at 10:00 as the server copy of student 2. Other
+// methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	outcomes map[string]types.SyncResult
+	applied  int
+	events   []types.Event
+}
+
+func newMock() *mockStorage {
+	return &mockStorage{outcomes: map[string]types.SyncResult{}}
+}
+
+func (m *mockStorage) SyncAttendance(ctx context.Context, mutation types.SyncMutation) (types.SyncResult, error) {
+	if result, ok := m.outcomes[mutation.ClientID]; ok {
+		result.Replayed = true
+		return result, nil
+	}
+	result := types.SyncResult{ClientID: mutation.ClientID, Outcome: types.SyncApplied, RecordID: mutation.StudentID}
+	if mutation.StudentID == 2 && mutation.ModifiedAt.Hour() < 10 {
+		result.Outcome, result.Deleted = types.SyncConflict, true
+	} else {
+		m.applied++
+		m.events = append(m.events, types.Event{Seq: int64(len(m.events) + 1), Type: "attendance.updated", ResourceID: mutation.StudentID})
+	}
+	m.outcomes[mutation.ClientID] = result
+	return result, nil
+}
+
+func (m *mockStorage) GetEventSeqRange(ctx context.Context) (int64, int64, error) {
+	if len(m.events) == 0 {
+		return 0, 0, nil
+	}
+	return m.events[0].Seq, m.events[len(m.events)-1].Seq, nil
+}
+
+func (m *mockStorage) GetEvents(ctx context.Context, since int64, limit int) ([]types.Event, error) {
+	var events []types.Event
+	for _, e := range m.events {
+		if e.Seq > since && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+const syncBody = `{"since":0,"mutations":[
+{"client_id":"7d3c4a1e-0b5f-4c1a-9f2e-1a2b3c4d5e6f","op":"upsert","student_id":1,"class_id":1,"date":"2025-03-10","status":"Present","modified_at":"2025-03-10T09:00:00Z"},
+{"client_id":"0f8e2d4c-6b1a-4e3f-8c7d-9a0b1c2d3e4f","op":"upsert","student_id":2,"class_id":1,"date":"2025-03-10","status":"Absent","modified_at":"2025-03-10T09:30:00Z"},
+{"client_id":"5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d","op":"delete","student_id":3,"date":"2025-03-32","modified_at":"2025-03-10T09:45:00Z"}]}`
+
+func sync(t *testing.T, m *mockStorage, body string) (int, syncResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	Sync(m)(rr, httptest.NewRequest("POST", "/api/attendance/sync", strings.NewReader(body)))
+	var result syncResponse
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rr.Code, result
+}
+
+func TestSync(t *testing.T) {
+	m := newMock()
+	code, result := sync(t, m, syncBody)
+	if code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+	want := []string{types.SyncApplied, types.SyncConflict, types.SyncRejected}
+	if len(result.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), result.Results)
+	}
+	for i, r := range result.Results {
+		if r.Outcome != want[i] || r.Replayed {
+			t.Errorf("mutation %d: expected %s, got %+v", i, want[i], r)
+		}
+	}
+	if !result.Results[1].Deleted {
+		t.Errorf("expected the conflict to report the deleted server copy, got %+v", result.Results[1])
+	}
+	if len(result.Changes) != 1 || result.Next != 1 {
+		t.Errorf("expected the applied change in the feed, got %+v next %d", result.Changes, result.Next)
+	}
+}
+
+func TestSyncReplay(t *testing.T) {
+	m := newMock()
+	if code, _ := sync(t, m, syncBody); code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+
+	// a retried upload returns the first outcomes and writes nothing
+	code, result := sync(t, m, strings.Replace(syncBody, `"since":0`, `"since":1`, 1))
+	if code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+	if m.applied != 1 {
+		t.Errorf("expected one write, got %d", m.applied)
+	}
+	for i, want := range []string{types.SyncApplied, types.SyncConflict} {
+		if r := result.Results[i]; r.Outcome != want || !r.Replayed {
+			t.Errorf("mutation %d: expected a replayed %s, got %+v", i, want, r)
+		}
+	}
+	if len(result.Changes) != 0 || result.Next != 1 {
+		t.Errorf("expected no new changes, got %+v next %d", result.Changes, result.Next)
+	}
+}
+
+func TestSyncInvalid(t *testing.T) {
+	m := newMock()
+	for _, body := range []string{
+		``,
+		`{"mutations":[{"client_id":"not-a-uuid","op":"upsert","student_id":1,"class_id":1,"date":"2025-03-10","status":"Present","modified_at":"2025-03-10T09:00:00Z"}]}`,
+		`{"mutations":[{"client_id":"7d3c4a1e-0b5f-4c1a-9f2e-1a2b3c4d5e6f","op":"upsert","student_id":1,"date":"2025-03-10","status":"Present","modified_at":"2025-03-10T09:00:00Z"}]}`,
+	} {
+		if code, _ := sync(t, m, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", body, http.StatusBadRequest, code)
+		}
+	}
+	if len(m.outcomes) != 0 {
+		t.Errorf("an invalid upload should apply nothing, got %+v", m.outcomes)
+	}
+}
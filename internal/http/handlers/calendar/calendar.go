@@ -0,0 +1,328 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/ical"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// maxDays bounds the range of GET /api/calendar/days
+const maxDays = 366
+
+type yearRequest struct {
+	Name      string `json:"name" validate:"required"`
+	StartDate string `json:"start_date" validate:"required"`
+	EndDate   string `json:"end_date" validate:"required"`
+}
+
+type termRequest struct {
+	AcademicYearID int64  `json:"academic_year_id" validate:"required"`
+	Name           string `json:"name" validate:"required"`
+	StartDate      string `json:"start_date" validate:"required"`
+	EndDate        string `json:"end_date" validate:"required"`
+}
+
+// closureRequest describes a closure; end_date defaults to start_date
+type closureRequest struct {
+	Name      string `json:"name" validate:"required"`
+	Kind      string `json:"kind" validate:"required,oneof=holiday break closure"`
+	StartDate string `json:"start_date" validate:"required"`
+	EndDate   string `json:"end_date"`
+}
+
+func CreateYear(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating an academic year")
+		var req yearRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		start, end, err := parseRange(req.StartDate, req.EndDate)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		id, err := storage.CreateAcademicYear(r.Context(), req.Name, start, end)
+		if err != nil {
+			log.Error("error creating academic year", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("academic year created successfully", slog.String("yearId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+func GetYears(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting academic years")
+		years, err := storage.GetAcademicYears(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, years)
+	}
+}
+
+// DeleteYear deletes an academic year and its terms
+func DeleteYear(storage storage.Storage) http.HandlerFunc {
+	return deleteHandler("academic year", storage.DeleteAcademicYear)
+}
+
+// CreateTerm adds a term, which must lie within its academic year
+func CreateTerm(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating a term")
+		var req termRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		start, end, err := parseRange(req.StartDate, req.EndDate)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		year, err := storage.GetAcademicYearById(r.Context(), req.AcademicYearID)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if start.Before(year.StartDate) || end.After(year.EndDate) {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("term must lie within academic year %s", year.Name)))
+			return
+		}
+
+		id, err := storage.CreateTerm(r.Context(), req.AcademicYearID, req.Name, start, end)
+		if err != nil {
+			log.Error("error creating term", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("term created successfully", slog.String("termId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+// GetTerms lists terms, optionally of one academic_year_id
+func GetTerms(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting terms")
+		var yearID int64
+		if v := r.URL.Query().Get("academic_year_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid academic_year_id %q", v)))
+				return
+			}
+			yearID = id
+		}
+		terms, err := storage.GetTerms(r.Context(), yearID)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, terms)
+	}
+}
+
+func DeleteTerm(storage storage.Storage) http.HandlerFunc {
+	return deleteHandler("term", storage.DeleteTerm)
+}
+
+func CreateClosure(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating a closure")
+		var req closureRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		if req.EndDate == "" {
+			req.EndDate = req.StartDate
+		}
+		start, end, err := parseRange(req.StartDate, req.EndDate)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		id, err := storage.CreateClosure(r.Context(), req.Name, req.Kind, start, end)
+		if err != nil {
+			log.Error("error creating closure", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("closure created successfully", slog.String("closureId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+// GetClosures lists closures overlapping the optional from/to range
+func GetClosures(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting closures")
+		from, to, err := dates.QueryRange(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		closures, err := storage.GetClosures(r.Context(), from, to)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, closures)
+	}
+}
+
+func DeleteClosure(storage storage.Storage) http.HandlerFunc {
+	return deleteHandler("closure", storage.DeleteClosure)
+}
+
+// ImportClosures reads the events of an iCalendar file, sent as the raw body
+// or as the multipart "file" field, and stores each as a closure of the
+// given kind (holiday by default). With dry_run=true the parsed closures are
+// returned without being stored.
+func ImportClosures(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("importing closures from iCalendar")
+
+		kind := r.URL.Query().Get("kind")
+		if kind == "" {
+			kind = types.ClosureHoliday
+		}
+		if kind != types.ClosureHoliday && kind != types.ClosureBreak && kind != types.ClosureAdHoc {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid kind %q", kind)))
+			return
+		}
+
+		var src io.Reader = r.Body
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("missing file field: %w", err)))
+				return
+			}
+			defer file.Close()
+			src = file
+		}
+		events, err := ical.Parse(src)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if len(events) == 0 {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("no events found")))
+			return
+		}
+
+		closures := make([]types.Closure, 0, len(events))
+		for _, e := range events {
+			name := e.Summary
+			if name == "" {
+				name = "Untitled"
+			}
+			closures = append(closures, types.Closure{Name: name, Kind: kind, StartDate: e.Start, EndDate: e.End})
+		}
+		if r.URL.Query().Get("dry_run") == "true" {
+			response.WriteJson(w, http.StatusOK, closures)
+			return
+		}
+
+		ids, err := storage.ImportClosures(r.Context(), closures)
+		if err != nil {
+			log.Error("error importing closures", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		for i := range closures {
+			closures[i].Id = ids[i]
+		}
+		log.Info("closures imported successfully", slog.Int("count", len(ids)))
+		response.WriteJson(w, http.StatusCreated, closures)
+	}
+}
+
+// GetDays lists each day between from and to with whether it is an
+// instructional day
+func GetDays(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting calendar days")
+		from, to, err := dates.QueryRange(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if from.IsZero() || to.IsZero() {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("from and to are required")))
+			return
+		}
+		if to.Sub(from) >= maxDays*24*time.Hour {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("range must not exceed %d days", maxDays)))
+			return
+		}
+
+		days, err := storage.GetCalendarDays(r.Context(), from, to)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, days)
+	}
+}
+
+func deleteHandler(noun string, remove func(context.Context, int64) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting "+noun, slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := remove(r.Context(), intId); err != nil {
+			log.Error("error deleting "+noun, slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Deleted successfully"})
+	}
+}
+
+func parseRange(startValue, endValue string) (start, end time.Time, err error) {
+	if start, err = dates.Parse(startValue); err != nil {
+		return start, end, err
+	}
+	if end, err = dates.Parse(endValue); err != nil {
+		return start, end, err
+	}
+	if end.Before(start) {
+		return start, end, fmt.Errorf("end_date must not be before start_date")
+	}
+	return start, end, nil
+}
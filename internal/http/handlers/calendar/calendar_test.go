@@ -0,0 +1,134 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage keeps one academic year and records created closures. Other
+// methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	closures []types.Closure
+	terms    int
+}
+
+func (m *mockStorage) GetAcademicYearById(ctx context.Context, id int64) (types.AcademicYear, error) {
+	start, _ := time.Parse("2006-01-02", "2024-06-01")
+	end, _ := time.Parse("2006-01-02", "2025-05-31")
+	return types.AcademicYear{Id: id, Name: "2024-25", StartDate: start, EndDate: end}, nil
+}
+
+func (m *mockStorage) CreateTerm(ctx context.Context, academicYearID int64, name string, startDate, endDate time.Time) (int64, error) {
+	m.terms++
+	return int64(m.terms), nil
+}
+
+func (m *mockStorage) CreateClosure(ctx context.Context, name, kind string, startDate, endDate time.Time) (int64, error) {
+	m.closures = append(m.closures, types.Closure{Name: name, Kind: kind, StartDate: startDate, EndDate: endDate})
+	return int64(len(m.closures)), nil
+}
+
+func (m *mockStorage) ImportClosures(ctx context.Context, closures []types.Closure) ([]int64, error) {
+	var ids []int64
+	for _, c := range closures {
+		m.closures = append(m.closures, c)
+		ids = append(ids, int64(len(m.closures)))
+	}
+	return ids, nil
+}
+
+func (m *mockStorage) GetCalendarDays(ctx context.Context, from, to time.Time) ([]types.CalendarDay, error) {
+	return []types.CalendarDay{{Date: from, Instructional: true}}, nil
+}
+
+func serve(h http.HandlerFunc, method, url, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest(method, url, strings.NewReader(body)))
+	return rr
+}
+
+func TestCreateClosure(t *testing.T) {
+	m := &mockStorage{}
+	rr := serve(CreateClosure(m), "POST", "/api/calendar/closures", `{"name":"Holi","kind":"holiday","start_date":"2025-03-14"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	if len(m.closures) != 1 || !m.closures[0].EndDate.Equal(m.closures[0].StartDate) {
+		t.Errorf("end_date should default to start_date, got %+v", m.closures)
+	}
+
+	for _, body := range []string{
+		`{"name":"Holi","kind":"festival","start_date":"2025-03-14"}`,
+		`{"name":"Holi","kind":"holiday","start_date":"2025-03-14","end_date":"2025-03-13"}`,
+		`{"name":"Holi","kind":"holiday","start_date":"14/03/2025"}`,
+	} {
+		if rr := serve(CreateClosure(m), "POST", "/api/calendar/closures", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", body, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestCreateTermWithinYear(t *testing.T) {
+	m := &mockStorage{}
+	rr := serve(CreateTerm(m), "POST", "/api/calendar/terms", `{"academic_year_id":1,"name":"Term 1","start_date":"2024-06-03","end_date":"2024-09-30"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	rr = serve(CreateTerm(m), "POST", "/api/calendar/terms", `{"academic_year_id":1,"name":"Term 3","start_date":"2025-04-01","end_date":"2025-06-30"}`)
+	if rr.Code != http.StatusBadRequest || m.terms != 1 {
+		t.Errorf("a term outside its year should be rejected, got %d", rr.Code)
+	}
+}
+
+func TestImportClosures(t *testing.T) {
+	ics := "BEGIN:VEVENT\r\nSUMMARY:Founders day\r\nDTSTART;VALUE=DATE:20250401\r\nEND:VEVENT\r\n"
+
+	m := &mockStorage{}
+	rr := serve(ImportClosures(m), "POST", "/api/calendar/closures/import?dry_run=true", ics)
+	if rr.Code != http.StatusOK || len(m.closures) != 0 {
+		t.Fatalf("dry run should not store closures, got %d %+v", rr.Code, m.closures)
+	}
+
+	rr = serve(ImportClosures(m), "POST", "/api/calendar/closures/import?kind=break", ics)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	var closures []types.Closure
+	json.Unmarshal(rr.Body.Bytes(), &closures)
+	if len(closures) != 1 || closures[0].Id != 1 || closures[0].Kind != types.ClosureBreak || closures[0].Name != "Founders day" {
+		t.Errorf("unexpected closures %+v", closures)
+	}
+
+	recurring := "BEGIN:VEVENT\r\nSUMMARY:Assembly\r\nDTSTART;VALUE=DATE:20250303\r\nRRULE:FREQ=WEEKLY\r\nEND:VEVENT\r\n"
+	for _, body := range []string{recurring, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"} {
+		if rr := serve(ImportClosures(m), "POST", "/api/calendar/closures/import", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d: %s", http.StatusBadRequest, rr.Code, rr.Body)
+		}
+	}
+	if rr := serve(ImportClosures(m), "POST", "/api/calendar/closures/import?kind=party", ics); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown kind to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestGetDaysRange(t *testing.T) {
+	m := &mockStorage{}
+	cases := map[string]int{
+		"/api/calendar/days?from=2025-03-01&to=2025-03-31": http.StatusOK,
+		"/api/calendar/days?from=2025-03-01":               http.StatusBadRequest,
+		"/api/calendar/days?from=2024-01-01&to=2025-03-31": http.StatusBadRequest,
+	}
+	for url, want := range cases {
+		if rr := serve(GetDays(m), "GET", url, ""); rr.Code != want {
+			t.Errorf("%s: expected status code %d, got %d", url, want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package changes
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// page is one page of the change feed. Next is the since to ask for the
+// following page; HasMore says whether there is one already.
+type page struct {
+	Changes []types.Event `json:"changes"`
+	Next    int64         `json:"next"`
+	HasMore bool          `json:"has_more"`
+}
+
+// GetList serves the change feed: the events after ?since=<seq>, oldest
+// first, up to ?limit=. A client keeps the returned next and asks again
+// with it to resume. When since is older than the events still kept, the
+// client has missed changes and gets 410 Gone; it must reload everything
+// and resume from the current seq given in the error.
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		q := r.URL.Query()
+
+		since, err := queryInt(q.Get("since"), 0)
+		if err != nil || since < 0 {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid since %q", q.Get("since"))))
+			return
+		}
+		limit, err := queryInt(q.Get("limit"), defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid limit %q, expected 1 to %d", q.Get("limit"), maxLimit)))
+			return
+		}
+		log.Info("getting changes", slog.Int64("since", since), slog.Int64("limit", limit))
+
+		first, last, err := storage.GetEventSeqRange(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if first > 0 && since < first-1 {
+			err := fmt.Errorf("changes after %d are no longer kept; reload and resume from since=%d", since, last)
+			response.WriteJson(w, http.StatusGone, response.GeneralError(err))
+			return
+		}
+
+		events, err := storage.GetEvents(r.Context(), since, int(limit)+1)
+		if err != nil {
+			log.Error("error getting changes", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		result := page{Changes: events, Next: since}
+		if len(events) > int(limit) {
+			result.Changes, result.HasMore = events[:limit], true
+		}
+		if n := len(result.Changes); n > 0 {
+			result.Next = result.Changes[n-1].Seq
+		}
+		response.WriteJson(w, http.StatusOK, result)
+	}
+}
+
+func queryInt(value string, fallback int64) (int64, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,73 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage keeps events 5 to 9; the ones before were pruned. Other
+// methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+}
+
+func (m *mockStorage) GetEventSeqRange(ctx context.Context) (int64, int64, error) {
+	return 5, 9, nil
+}
+
+func (m *mockStorage) GetEvents(ctx context.Context, since int64, limit int) ([]types.Event, error) {
+	events := []types.Event{}
+	for seq := max(since+1, 5); seq <= 9 && len(events) < limit; seq++ {
+		events = append(events, types.Event{Seq: seq, Type: "student.created", Resource: "student", ResourceID: seq})
+	}
+	return events, nil
+}
+
+func TestGetList(t *testing.T) {
+	cases := []struct {
+		query   string
+		want    int
+		seqs    []int64
+		next    int64
+		hasMore bool
+	}{
+		{"?since=4&limit=2", http.StatusOK, []int64{5, 6}, 6, true},
+		{"?since=6&limit=2", http.StatusOK, []int64{7, 8}, 8, true},
+		{"?since=8&limit=2", http.StatusOK, []int64{9}, 9, false},
+		{"?since=9", http.StatusOK, nil, 9, false},
+		{"?since=3", http.StatusGone, nil, 0, false},
+		{"", http.StatusGone, nil, 0, false},
+		{"?since=-1", http.StatusBadRequest, nil, 0, false},
+		{"?since=4&limit=0", http.StatusBadRequest, nil, 0, false},
+		{"?since=4&limit=1001", http.StatusBadRequest, nil, 0, false},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		GetList(&mockStorage{})(rr, httptest.NewRequest("GET", "/api/changes"+c.query, nil))
+		if rr.Code != c.want {
+			t.Errorf("%q: expected status code %d, got %d: %s", c.query, c.want, rr.Code, rr.Body)
+			continue
+		}
+		if rr.Code != http.StatusOK {
+			continue
+		}
+		var got page
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		var seqs []int64
+		for _, e := range got.Changes {
+			seqs = append(seqs, e.Seq)
+		}
+		if !slices.Equal(seqs, c.seqs) || got.Next != c.next || got.HasMore != c.hasMore {
+			t.Errorf("%q: expected %v, next %d and has_more %v, got %v, %d and %v", c.query, c.seqs, c.next, c.hasMore, seqs, got.Next, got.HasMore)
+		}
+	}
+}
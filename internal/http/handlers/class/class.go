@@ -1,69 +1,65 @@
 package class
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/tukesh1/student-api/internal/logger"
 	"github.com/tukesh1/student-api/internal/storage"
 	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/export"
 	"github.com/tukesh1/student-api/internal/utils/response"
 )
 
 func New(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("creating class")
+		log := logger.FromRequest(r)
+		log.Info("creating class")
 		var class types.Class
-		err := json.NewDecoder(r.Body).Decode(&class)
-		if errors.Is(err, io.EOF) {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("empty body")))
+		if !response.Decode(w, r, &class) {
 			return
 		}
+
+		teacherID, err := resolveTeacher(r.Context(), storage, class)
 		if err != nil {
 			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
 
-		// validating request
-		if err := validator.New().Struct(class); err != nil {
-			validateErrs := err.(validator.ValidationErrors)
-			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(validateErrs))
-			return
-		}
-
 		lastId, err := storage.CreateClass(
+			r.Context(),
 			class.Name,
 			class.Grade,
 			class.Section,
-			class.TeacherName,
+			teacherID,
+			class.Capacity,
 		)
-		slog.Info("class created successfully", slog.String("classId", fmt.Sprint(lastId)))
-
 		if err != nil {
+			log.Error("error creating class", slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
+		log.Info("class created successfully", slog.String("classId", fmt.Sprint(lastId)))
 		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": lastId})
 	}
 }
 
 func GetById(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
 		id := r.PathValue("id")
-		slog.Info("Getting a class", slog.String("id", id))
+		log.Info("Getting a class", slog.String("id", id))
 		intId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
 			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
-		class, err := storage.GetClassById(intId)
+		class, err := storage.GetClassById(r.Context(), intId)
 		if err != nil {
-			slog.Error("error getting class", slog.String("id", id))
+			log.Error("error getting class", slog.String("id", id), slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
@@ -73,9 +69,29 @@ func GetById(storage storage.Storage) http.HandlerFunc {
 
 func GetList(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("getting all classes")
-		classes, err := storage.GetClasses()
+		log := logger.FromRequest(r)
+		log.Info("getting all classes")
+		filter := types.ClassFilter{
+			Grade:   r.URL.Query().Get("grade"),
+			Section: r.URL.Query().Get("section"),
+		}
+		if v := r.URL.Query().Get("teacher_id"); v != "" {
+			teacherID, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid teacher_id %q", v)))
+				return
+			}
+			filter.TeacherID = teacherID
+		}
 
+		if format := export.Negotiate(r); format != export.JSON {
+			export.Stream(w, r, format, "classes", exportTable, func(fn func(types.Class) error) error {
+				return storage.StreamClasses(r.Context(), filter, fn)
+			})
+			return
+		}
+
+		classes, err := storage.GetClasses(r.Context(), filter)
 		if err != nil {
 			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -86,8 +102,9 @@ func GetList(storage storage.Storage) http.HandlerFunc {
 
 func UpdateById(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
 		id := r.PathValue("id")
-		slog.Info("Updating class", slog.String("id", id))
+		log.Info("Updating class", slog.String("id", id))
 
 		intId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
@@ -96,26 +113,19 @@ func UpdateById(storage storage.Storage) http.HandlerFunc {
 		}
 
 		var class types.Class
-		err = json.NewDecoder(r.Body).Decode(&class)
-		if errors.Is(err, io.EOF) {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("empty body")))
+		if !response.Decode(w, r, &class) {
 			return
 		}
+
+		teacherID, err := resolveTeacher(r.Context(), storage, class)
 		if err != nil {
 			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
 
-		// validating request
-		if err := validator.New().Struct(class); err != nil {
-			validateErrs := err.(validator.ValidationErrors)
-			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(validateErrs))
-			return
-		}
-
-		err = storage.UpdateClass(intId, class.Name, class.Grade, class.Section, class.TeacherName)
+		err = storage.UpdateClass(r.Context(), intId, class.Name, class.Grade, class.Section, teacherID, class.Capacity)
 		if err != nil {
-			slog.Error("error updating class", slog.String("id", id))
+			log.Error("error updating class", slog.String("id", id), slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -124,10 +134,21 @@ func UpdateById(storage storage.Storage) http.HandlerFunc {
 	}
 }
 
+// resolveTeacher returns the class's teacher id. Clients that still send
+// only teacher_name get the existing teacher of that name.
+func resolveTeacher(ctx context.Context, storage storage.Storage, class types.Class) (int64, error) {
+	if class.TeacherID != 0 {
+		_, err := storage.GetTeacherById(ctx, class.TeacherID)
+		return class.TeacherID, err
+	}
+	return storage.ResolveTeacher(ctx, class.TeacherName)
+}
+
 func DeleteById(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
 		id := r.PathValue("id")
-		slog.Info("Deleting class", slog.String("id", id))
+		log.Info("Deleting class", slog.String("id", id))
 
 		intId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
@@ -135,9 +156,9 @@ func DeleteById(storage storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		err = storage.DeleteClass(intId)
+		err = storage.DeleteClass(r.Context(), intId)
 		if err != nil {
-			slog.Error("error deleting class", slog.String("id", id))
+			log.Error("error deleting class", slog.String("id", id), slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -145,3 +166,45 @@ func DeleteById(storage storage.Storage) http.HandlerFunc {
 		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Class deleted successfully"})
 	}
 }
+
+// graceRequest sets a class's grace in minutes; null or a missing field
+// uses the school's
+type graceRequest struct {
+	LateGraceMinutes       *int `json:"late_grace_minutes" validate:"omitempty,gte=0,lte=240"`
+	EarlyLeaveGraceMinutes *int `json:"early_leave_grace_minutes" validate:"omitempty,gte=0,lte=240"`
+}
+
+// SetGrace sets how late a student of the class may check in and how early
+// they may leave before it counts against them
+func SetGrace(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Setting class grace", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		var req graceRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+
+		err = storage.SetClassGrace(r.Context(), intId, req.LateGraceMinutes, req.EarlyLeaveGraceMinutes)
+		if err != nil {
+			log.Error("error setting class grace", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		class, err := storage.GetClassById(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, class)
+	}
+}
@@ -0,0 +1,13 @@
+package class
+
+import (
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/export"
+)
+
+var exportTable = export.Table[types.Class]{
+	Columns: []string{"id", "name", "grade", "section", "teacher_id", "teacher_name", "capacity"},
+	Row: func(c types.Class) []any {
+		return []any{c.Id, c.Name, c.Grade, c.Section, c.TeacherID, c.TeacherName, c.Capacity}
+	},
+}
@@ -0,0 +1,216 @@
+package enrollment
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// enrollRequest enrolls a student in a subject section; start_date defaults
+// to today and a missing end_date leaves the enrollment open-ended
+type enrollRequest struct {
+	StudentID int64  `json:"student_id" validate:"required"`
+	ClassID   int64  `json:"class_id" validate:"required"`
+	SubjectID int64  `json:"subject_id" validate:"required"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type updateRequest struct {
+	Status  string `json:"status" validate:"required,oneof=active dropped completed"`
+	EndDate string `json:"end_date"`
+}
+
+func New(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("enrolling a student")
+		var req enrollRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		start := today()
+		if req.StartDate != "" {
+			var err error
+			if start, err = dates.Parse(req.StartDate); err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+				return
+			}
+		}
+		end, err := parseEndDate(req.EndDate, start)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		id, err := storage.CreateEnrollment(r.Context(), req.StudentID, req.ClassID, req.SubjectID, start, end)
+		if err != nil {
+			log.Error("error enrolling student", slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		log.Info("student enrolled successfully", slog.String("enrollmentId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+// GetList lists enrollments filtered by student_id, class_id, subject_id and
+// status
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("getting enrollments")
+
+		q := r.URL.Query()
+		filter := types.EnrollmentFilter{Status: q.Get("status")}
+		for name, dst := range map[string]*int64{"student_id": &filter.StudentID, "class_id": &filter.ClassID, "subject_id": &filter.SubjectID} {
+			if v := q.Get(name); v != "" {
+				id, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid %s %q", name, v)))
+					return
+				}
+				*dst = id
+			}
+		}
+
+		enrollments, err := storage.GetEnrollments(r.Context(), filter)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, enrollments)
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("getting an enrollment", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		enrollment, err := storage.GetEnrollmentById(r.Context(), intId)
+		if err != nil {
+			log.Error("error getting enrollment", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, enrollment)
+	}
+}
+
+// UpdateById changes an enrollment's status and end date
+func UpdateById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("updating enrollment", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var req updateRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		enrollment, err := storage.GetEnrollmentById(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		end, err := parseEndDate(req.EndDate, enrollment.StartDate)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := storage.UpdateEnrollment(r.Context(), intId, req.Status, end); err != nil {
+			log.Error("error updating enrollment", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Enrollment updated successfully"})
+	}
+}
+
+// Drop ends an active enrollment on the date given by ?date=, today by
+// default. The enrollment is kept with status dropped.
+func Drop(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("dropping enrollment", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		enrollment, err := storage.GetEnrollmentById(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		if enrollment.Status != types.EnrollmentActive {
+			response.WriteJson(w, http.StatusConflict, response.GeneralError(fmt.Errorf("enrollment %d is %s", intId, enrollment.Status)))
+			return
+		}
+		date := today()
+		if v := r.URL.Query().Get("date"); v != "" {
+			if date, err = parseEndDate(v, enrollment.StartDate); err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+				return
+			}
+		}
+
+		if err := storage.UpdateEnrollment(r.Context(), intId, types.EnrollmentDropped, date); err != nil {
+			log.Error("error dropping enrollment", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Enrollment dropped successfully"})
+	}
+}
+
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// parseEndDate parses an optional end date, which must not be before start
+func parseEndDate(value string, start time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	end, err := dates.Parse(value)
+	if err != nil {
+		return end, err
+	}
+	if end.Before(start) {
+		return end, fmt.Errorf("end_date must not be before start_date")
+	}
+	return end, nil
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, storage.ErrClassFull) || errors.Is(err, storage.ErrConflict) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,318 @@
+package guardian
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// linkRequest links a guardian to a student: an existing one by guardian_id,
+// or a new one created from the contact fields
+type linkRequest struct {
+	GuardianID       int64  `json:"guardian_id"`
+	Name             string `json:"name" validate:"required_without=GuardianID"`
+	Email            string `json:"email" validate:"omitempty,email"`
+	Phone            string `json:"phone"`
+	AltPhone         string `json:"alt_phone"`
+	PreferredChannel string `json:"preferred_channel" validate:"omitempty,oneof=phone sms email"`
+	Relationship     string `json:"relationship" validate:"required,oneof=mother father parent guardian grandparent sibling other"`
+	IsPrimary        bool   `json:"is_primary"`
+}
+
+type updateLinkRequest struct {
+	Relationship string `json:"relationship" validate:"required,oneof=mother father parent guardian grandparent sibling other"`
+	IsPrimary    bool   `json:"is_primary"`
+}
+
+type passwordRequest struct {
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+func New(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating guardian")
+		var guardian types.Guardian
+		if !response.Decode(w, r, &guardian) {
+			return
+		}
+
+		lastId, err := storage.CreateGuardian(r.Context(), guardian)
+		if err != nil {
+			log.Error("error creating guardian", slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		log.Info("guardian created successfully", slog.String("guardianId", fmt.Sprint(lastId)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": lastId})
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Getting a guardian", slog.String("id", id))
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		guardian, err := storage.GetGuardianById(r.Context(), intId)
+		if err != nil {
+			log.Error("error getting guardian", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, guardian)
+	}
+}
+
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting all guardians")
+		guardians, err := storage.GetGuardians(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, guardians)
+	}
+}
+
+func UpdateById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Updating guardian", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var guardian types.Guardian
+		if !response.Decode(w, r, &guardian) {
+			return
+		}
+
+		if err := storage.UpdateGuardian(r.Context(), intId, guardian); err != nil {
+			log.Error("error updating guardian", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Guardian updated successfully"})
+	}
+}
+
+// DeleteById deletes a guardian along with their links to students
+func DeleteById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting guardian", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := storage.DeleteGuardian(r.Context(), intId); err != nil {
+			log.Error("error deleting guardian", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Guardian deleted successfully"})
+	}
+}
+
+// SetPassword sets the password a guardian logs in with and ends their
+// current sessions
+func SetPassword(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Setting guardian password", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var req passwordRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		hash, err := hashPassword(req.Password)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if err := storage.SetGuardianPassword(r.Context(), intId, hash); err != nil {
+			log.Error("error setting guardian password", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Password set successfully"})
+	}
+}
+
+// GetForStudent lists a student's guardians, primary contact first
+func GetForStudent(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Getting a student's guardians", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if _, err := storage.GetStudentById(r.Context(), intId); err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		guardians, err := storage.GetStudentGuardians(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, guardians)
+	}
+}
+
+// LinkToStudent links an existing or new guardian to a student
+func LinkToStudent(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Linking a guardian to a student", slog.String("id", id))
+
+		studentID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var req linkRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		if _, err := storage.GetStudentById(r.Context(), studentID); err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+
+		guardianID := req.GuardianID
+		if guardianID == 0 {
+			guardian := types.Guardian{Name: req.Name, Email: req.Email, Phone: req.Phone, AltPhone: req.AltPhone, PreferredChannel: req.PreferredChannel}
+			if err := validator.New().Struct(guardian); err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.ValidationError(err.(validator.ValidationErrors)))
+				return
+			}
+			if guardianID, err = storage.CreateGuardian(r.Context(), guardian); err != nil {
+				log.Error("error creating guardian", slog.String("error", err.Error()))
+				response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+				return
+			}
+		}
+
+		if err := storage.LinkGuardian(r.Context(), studentID, guardianID, req.Relationship, req.IsPrimary); err != nil {
+			log.Error("error linking guardian", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("guardian linked successfully", slog.String("guardianId", fmt.Sprint(guardianID)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"guardian_id": guardianID})
+	}
+}
+
+// UpdateLink changes how a guardian is related to a student
+func UpdateLink(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		studentID, guardianID, ok := linkIds(w, r)
+		if !ok {
+			return
+		}
+		log.Info("Updating a student's guardian", slog.Int64("studentId", studentID), slog.Int64("guardianId", guardianID))
+		var req updateLinkRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		if !isLinked(w, r, storage, studentID, guardianID) {
+			return
+		}
+
+		if err := storage.LinkGuardian(r.Context(), studentID, guardianID, req.Relationship, req.IsPrimary); err != nil {
+			log.Error("error updating guardian link", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Guardian updated successfully"})
+	}
+}
+
+// Unlink removes a guardian from a student; the guardian record is kept
+func Unlink(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		studentID, guardianID, ok := linkIds(w, r)
+		if !ok {
+			return
+		}
+		log.Info("Unlinking a student's guardian", slog.Int64("studentId", studentID), slog.Int64("guardianId", guardianID))
+
+		if err := storage.UnlinkGuardian(r.Context(), studentID, guardianID); err != nil {
+			log.Error("error unlinking guardian", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Guardian unlinked successfully"})
+	}
+}
+
+// linkIds reads the student and guardian ids of a nested guardian route
+func linkIds(w http.ResponseWriter, r *http.Request) (studentID, guardianID int64, ok bool) {
+	studentID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+		return 0, 0, false
+	}
+	guardianID, err = strconv.ParseInt(r.PathValue("guardianId"), 10, 64)
+	if err != nil {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+		return 0, 0, false
+	}
+	return studentID, guardianID, true
+}
+
+func isLinked(w http.ResponseWriter, r *http.Request, storage storage.Storage, studentID, guardianID int64) bool {
+	guardians, err := storage.GetStudentGuardians(r.Context(), studentID)
+	if err != nil {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return false
+	}
+	for _, g := range guardians {
+		if g.Id == guardianID {
+			return true
+		}
+	}
+	response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("guardian %d is not linked to student %d", guardianID, studentID)))
+	return false
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, storage.ErrConflict) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
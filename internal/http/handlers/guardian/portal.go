@@ -0,0 +1,156 @@
+package guardian
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/utils/response"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type loginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type loginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type guardianKey struct{}
+
+// errInvalidLogin is returned for every failed login so that responses do
+// not reveal which emails belong to guardians
+var errInvalidLogin = fmt.Errorf("invalid email or password")
+
+// dummyHash is compared against when the email is unknown or has no
+// password yet, so those logins take as long as a wrong password. It is a
+// bcrypt.DefaultCost hash, like the ones hashPassword stores.
+const dummyHash = "$2a$10$S6LGPnMXKpIUG0wJAaoWN.BtaRz0U4Dg3XxhkPJOPMO6Eknn30X7m"
+
+// Login exchanges a guardian's email and password for a bearer token that
+// is valid for ttl
+func Login(storage storage.Storage, ttl time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("guardian logging in")
+		var req loginRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+
+		id, hash, err := storage.GetGuardianLogin(r.Context(), req.Email)
+		known := err == nil && hash != ""
+		if !known {
+			hash = dummyHash
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil || !known {
+			log.Warn("guardian login failed")
+			response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(errInvalidLogin))
+			return
+		}
+
+		token, err := newToken()
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		expiresAt := time.Now().Add(ttl).UTC()
+		if err := storage.CreateGuardianSession(r.Context(), id, hashToken(token), expiresAt); err != nil {
+			log.Error("error creating guardian session", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("guardian logged in", slog.String("guardianId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusOK, loginResponse{Token: token, ExpiresAt: expiresAt})
+	}
+}
+
+// Logout ends the session of the request's bearer token
+func Logout(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("guardian logging out")
+		if err := storage.DeleteGuardianSession(r.Context(), hashToken(bearer(r))); err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+	}
+}
+
+// MyStudents lists the students linked to the logged-in guardian
+func MyStudents(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("getting the guardian's students")
+		students, err := storage.GetGuardianStudents(r.Context(), FromContext(r.Context()))
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, students)
+	}
+}
+
+// Authenticate lets a request through only with the bearer token of an
+// unexpired guardian session, recording the guardian on the context
+func Authenticate(storage storage.Storage, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := bearer(r)
+		if token == "" {
+			response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(fmt.Errorf("missing bearer token")))
+			return
+		}
+		id, err := storage.GetGuardianSession(r.Context(), hashToken(token))
+		if err != nil {
+			response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(err))
+			return
+		}
+		ctx := context.WithValue(r.Context(), guardianKey{}, id)
+		ctx = logger.WithUser(ctx, fmt.Sprintf("guardian:%d", id))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// FromContext returns the id of the authenticated guardian, or 0
+func FromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(guardianKey{}).(int64)
+	return id
+}
+
+func bearer(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// newToken returns a random session token; only its hash is stored
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package guardian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mockStorage keeps one guardian with a password and their sessions.
+// Methods the portal never calls fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	hash     string
+	sessions map[string]time.Time
+}
+
+func (m *mockStorage) GetGuardianLogin(ctx context.Context, email string) (int64, string, error) {
+	if email != "parent@example.com" {
+		return 0, "", fmt.Errorf("no guardian found with email %s", email)
+	}
+	return 7, m.hash, nil
+}
+
+func (m *mockStorage) CreateGuardianSession(ctx context.Context, guardianID int64, tokenHash string, expiresAt time.Time) error {
+	m.sessions[tokenHash] = expiresAt
+	return nil
+}
+
+func (m *mockStorage) GetGuardianSession(ctx context.Context, tokenHash string) (int64, error) {
+	if expiresAt, ok := m.sessions[tokenHash]; ok && time.Now().Before(expiresAt) {
+		return 7, nil
+	}
+	return 0, fmt.Errorf("session not found or expired")
+}
+
+func (m *mockStorage) GetGuardianStudents(ctx context.Context, guardianID int64) ([]types.Student, error) {
+	return []types.Student{{Id: 1, Name: fmt.Sprintf("child of %d", guardianID)}}, nil
+}
+
+func login(t *testing.T, m *mockStorage, ttl time.Duration, password string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(loginRequest{Email: "parent@example.com", Password: password})
+	rr := httptest.NewRecorder()
+	Login(m, ttl)(rr, httptest.NewRequest(http.MethodPost, "/api/guardian/login", bytes.NewReader(body)))
+	return rr
+}
+
+func TestPortal(t *testing.T) {
+	hash, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockStorage{hash: hash, sessions: map[string]time.Time{}}
+
+	if rr := login(t, m, time.Hour, "wrong password"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: got status %d, want 401", rr.Code)
+	}
+
+	rr := login(t, m, time.Hour, "correct horse")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login: got status %d, want 200: %s", rr.Code, rr.Body)
+	}
+	var resp loginResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Token == "" {
+		t.Fatalf("login response %+v, %v", resp, err)
+	}
+	if _, stored := m.sessions[resp.Token]; stored {
+		t.Error("the raw token was stored instead of its hash")
+	}
+
+	students := Authenticate(m, MyStudents(m))
+	for _, tc := range []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"unknown token", "Bearer nope", http.StatusUnauthorized},
+		{"valid token", "Bearer " + resp.Token, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/guardian/students", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rr := httptest.NewRecorder()
+		students(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: got status %d, want %d", tc.name, rr.Code, tc.want)
+		}
+	}
+
+	expired := login(t, m, -time.Minute, "correct horse")
+	json.NewDecoder(expired.Body).Decode(&resp)
+	req := httptest.NewRequest(http.MethodGet, "/api/guardian/students", nil)
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	rr = httptest.NewRecorder()
+	students(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expired token: got status %d, want 401", rr.Code)
+	}
+}
+
+func TestLoginWithoutPassword(t *testing.T) {
+	if cost, err := bcrypt.Cost([]byte(dummyHash)); err != nil || cost != bcrypt.DefaultCost {
+		t.Fatalf("dummy hash should cost as much as a stored one, got %d (%v)", cost, err)
+	}
+
+	// no password set yet: even the dummy hash's own password is refused
+	m := &mockStorage{sessions: map[string]time.Time{}}
+	if rr := login(t, m, time.Hour, "no guardian has this password"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("got status %d, want 401", rr.Code)
+	}
+	if len(m.sessions) != 0 {
+		t.Errorf("no session should be created, got %d", len(m.sessions))
+	}
+}
@@ -0,0 +1,23 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// DiskSpaceCheck fails when the filesystem holding path has less than
+// minFreeBytes available
+func DiskSpaceCheck(path string, minFreeBytes uint64) Check {
+	dir := filepath.Dir(path)
+	return func(ctx context.Context) error {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("only %d MB free in %s, need %d MB", bToMb(free), dir, bToMb(minFreeBytes))
+		}
+		return nil
+	}
+}
@@ -1,19 +1,24 @@
 package health
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"runtime"
+	"sync"
 	"time"
+
+	"github.com/tukesh1/student-api/internal/utils/response"
+	"github.com/tukesh1/student-api/internal/version"
 )
 
 type HealthStatus struct {
-	Status    string         `json:"status"`
-	Timestamp time.Time      `json:"timestamp"`
-	Version   string         `json:"version"`
-	Uptime    string         `json:"uptime"`
-	System    SystemInfo     `json:"system"`
-	Database  DatabaseStatus `json:"database"`
+	Status    string                 `json:"status"`
+	Timestamp time.Time              `json:"timestamp"`
+	Version   string                 `json:"version"`
+	Commit    string                 `json:"commit"`
+	Uptime    string                 `json:"uptime"`
+	System    SystemInfo             `json:"system"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
 }
 
 type SystemInfo struct {
@@ -22,36 +27,123 @@ type SystemInfo struct {
 	MemoryMB     uint64 `json:"memory_mb"`
 }
 
-type DatabaseStatus struct {
-	Connected bool   `json:"connected"`
-	Type      string `json:"type"`
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Check reports whether one dependency is usable. It must respect ctx,
+// which carries the per-check timeout.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks registered by each subsystem
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
 }
 
 var startTime = time.Now()
 
-func HealthCheck(db interface{}) http.HandlerFunc {
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a readiness check; registering an existing name replaces it
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.checks {
+		if c.checks[i].name == name {
+			c.checks[i].check = check
+			return
+		}
+	}
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run executes all checks concurrently and reports whether every one passed
+func (c *Checker) Run(ctx context.Context) (map[string]CheckResult, bool) {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make(map[string]CheckResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(ctx)
+			result := CheckResult{Status: "ok", Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[nc.name] = result
+			if err != nil {
+				ready = false
+			}
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+	return results, ready
+}
+
+// Livez reports that the process is up. It checks no dependencies so a slow
+// database never gets the process restarted.
+func Livez() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var m runtime.MemStats
-		runtime.ReadMemStats(&m)
-
-		health := HealthStatus{
-			Status:    "healthy",
-			Timestamp: time.Now(),
-			Version:   "1.0.0",
-			Uptime:    time.Since(startTime).String(),
-			System: SystemInfo{
-				GoVersion:    runtime.Version(),
-				NumGoroutine: runtime.NumGoroutine(),
-				MemoryMB:     bToMb(m.Alloc),
-			},
-			Database: DatabaseStatus{
-				Connected: true, // You can add actual DB ping check here
-				Type:      "SQLite",
-			},
+		response.WriteJson(w, http.StatusOK, newStatus("alive"))
+	}
+}
+
+// Readyz runs every registered check and answers 503 when any fails
+func (c *Checker) Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results, ready := c.Run(r.Context())
+
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "not ready", http.StatusServiceUnavailable
 		}
+		health := newStatus(status)
+		health.Checks = results
+		response.WriteJson(w, code, health)
+	}
+}
+
+func newStatus(status string) HealthStatus {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(health)
+	return HealthStatus{
+		Status:    status,
+		Timestamp: time.Now(),
+		Version:   version.Version,
+		Commit:    version.Commit,
+		Uptime:    time.Since(startTime).String(),
+		System: SystemInfo{
+			GoVersion:    runtime.Version(),
+			NumGoroutine: runtime.NumGoroutine(),
+			MemoryMB:     bToMb(m.Alloc),
+		},
 	}
 }
 
@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyzReportsFailingCheck(t *testing.T) {
+	checker := NewChecker(50 * time.Millisecond)
+	checker.Register("database", func(ctx context.Context) error { return nil })
+	checker.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.Register("broken", func(ctx context.Context) error { return errors.New("boom") })
+
+	rr := httptest.NewRecorder()
+	checker.Readyz().ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status code %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	var status HealthStatus
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	if status.Checks["database"].Status != "ok" {
+		t.Errorf("database check should pass, got %+v", status.Checks["database"])
+	}
+	if status.Checks["slow"].Status != "error" || status.Checks["broken"].Error != "boom" {
+		t.Errorf("unexpected check results %+v", status.Checks)
+	}
+}
+
+func TestReadyzAllPassing(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", func(ctx context.Context) error { return nil })
+
+	rr := httptest.NewRecorder()
+	checker.Readyz().ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+}
@@ -0,0 +1,302 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+	"github.com/tukesh1/student-api/internal/utils/spreadsheet"
+)
+
+// column is one importable field. By default it is matched to the header
+// cell equal to its JSON name; the mapping parameter can point it at any
+// other header.
+type column[T any] struct {
+	field string
+	set   func(v *T, value string) error
+}
+
+var studentColumns = []column[types.Student]{
+	{"name", func(s *types.Student, v string) error { s.Name = v; return nil }},
+	{"email", func(s *types.Student, v string) error { s.Email = v; return nil }},
+	{"age", func(s *types.Student, v string) error { return parseInt(v, "Age", &s.Age) }},
+	{"class_id", func(s *types.Student, v string) error { return parseInt(v, "ClassID", &s.ClassID) }},
+	{"roll_no", func(s *types.Student, v string) error { s.RollNo = v; return nil }},
+}
+
+var classColumns = []column[types.Class]{
+	{"name", func(c *types.Class, v string) error { c.Name = v; return nil }},
+	{"grade", func(c *types.Class, v string) error { c.Grade = v; return nil }},
+	{"section", func(c *types.Class, v string) error { c.Section = v; return nil }},
+	{"teacher_id", func(c *types.Class, v string) error { return parseInt(v, "TeacherID", &c.TeacherID) }},
+	{"teacher_name", func(c *types.Class, v string) error { c.TeacherName = v; return nil }},
+	{"capacity", func(c *types.Class, v string) error { return parseInt(v, "Capacity", &c.Capacity) }},
+}
+
+// unzipRatio is how many times the upload cap an XLSX workbook may grow to
+// when unzipped; sheet XML compresses well but not without bound
+const unzipRatio = 10
+
+// Students imports a CSV or XLSX roster of at most maxBytes. Rows are
+// validated with the same rules as student.New; with dry_run=true only the
+// report is returned.
+func Students(storage storage.Storage, maxBytes int64) http.HandlerFunc {
+	return importHandler("students", studentColumns, nil, storage.ImportStudents, maxBytes)
+}
+
+// Classes imports a CSV or XLSX list of classes of at most maxBytes. A
+// teacher given by id or by name must exist; rows naming one that does not
+// are reported like other invalid rows, dry run or not.
+func Classes(storage storage.Storage, maxBytes int64) http.HandlerFunc {
+	return importHandler("classes", classColumns, classTeacher(storage), storage.ImportClasses, maxBytes)
+}
+
+// classTeacher resolves the teacher a class row names to its id, failing
+// with storage.ErrUnknownTeacher when there is no such teacher
+func classTeacher(storage storage.Storage) func(context.Context, *types.Class) error {
+	return func(ctx context.Context, c *types.Class) error {
+		if c.TeacherID == 0 {
+			id, err := storage.ResolveTeacher(ctx, c.TeacherName)
+			c.TeacherID = id
+			return err
+		}
+		teachers, err := storage.GetTeachers(ctx)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(teachers, func(t types.Teacher) bool { return t.Id == c.TeacherID }) {
+			return unknownTeacher(c.TeacherID)
+		}
+		return nil
+	}
+}
+
+func unknownTeacher(id int64) error {
+	return fmt.Errorf("teacher %d: %w", id, storage.ErrUnknownTeacher)
+}
+
+// importHandler reads, validates and saves the rows of an upload. check,
+// when set, looks up what a valid row refers to; a check failing with
+// storage.ErrUnknownTeacher makes the row invalid.
+func importHandler[T any](noun string, columns []column[T], check func(context.Context, *T) error, save func(context.Context, []T) ([]int64, error), maxBytes int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+		log.Info("importing "+noun, slog.Bool("dry_run", dryRun))
+
+		rows, err := readUpload(r, maxBytes*unzipRatio)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if len(rows) == 0 {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("empty file")))
+			return
+		}
+
+		mapping, err := parseMapping(r.FormValue("mapping"))
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		index, err := mapColumns(rows[0], columns, mapping)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		report := types.ImportReport{DryRun: dryRun, Errors: []types.ImportRowError{}}
+		validate := validator.New()
+		var valid []T
+		for i, row := range rows[1:] {
+			if isBlank(row) {
+				continue
+			}
+			report.TotalRows++
+
+			var item T
+			var rowErrs []string
+			for c, col := range columns {
+				if err := col.set(&item, cell(row, index[c])); err != nil {
+					rowErrs = append(rowErrs, err.Error())
+				}
+			}
+			if err := validate.Struct(item); err != nil {
+				var validateErrs validator.ValidationErrors
+				if errors.As(err, &validateErrs) {
+					rowErrs = append(rowErrs, response.ValidationMessages(validateErrs)...)
+				}
+			}
+			if check != nil && len(rowErrs) == 0 {
+				err := check(r.Context(), &item)
+				if err != nil && !errors.Is(err, storage.ErrUnknownTeacher) {
+					log.Error("error checking "+noun, slog.String("error", err.Error()))
+					response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+					return
+				}
+				if err != nil {
+					rowErrs = append(rowErrs, err.Error())
+				}
+			}
+			if len(rowErrs) > 0 {
+				// +2: one for the header row, one because rows are 1-based
+				report.Errors = append(report.Errors, types.ImportRowError{Row: i + 2, Errors: rowErrs})
+				continue
+			}
+			valid = append(valid, item)
+		}
+		report.ValidRows = len(valid)
+
+		if dryRun {
+			response.WriteJson(w, http.StatusOK, report)
+			return
+		}
+		if len(report.Errors) > 0 {
+			response.WriteJson(w, http.StatusUnprocessableEntity, report)
+			return
+		}
+
+		ids, err := save(r.Context(), valid)
+		if errors.Is(err, storage.ErrUnknownTeacher) {
+			response.WriteJson(w, http.StatusUnprocessableEntity, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			log.Error("error importing "+noun, slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		report.Ids = ids
+		report.Imported = len(ids)
+		log.Info(noun+" imported successfully", slog.Int("count", len(ids)))
+		response.WriteJson(w, http.StatusCreated, report)
+	}
+}
+
+// readUpload reads the spreadsheet from a multipart "file" field or, for any
+// other content type, from the raw request body
+func readUpload(r *http.Request, maxUnzipped int64) ([][]string, error) {
+	var (
+		src         io.Reader = r.Body
+		filename    string
+		contentType = r.Header.Get("Content-Type")
+	)
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing file field: %w", err)
+		}
+		defer file.Close()
+		src, filename, contentType = file, header.Filename, header.Header.Get("Content-Type")
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+	format := spreadsheet.DetectFormat(filename, contentType, data)
+	return spreadsheet.ReadAll(bytes.NewReader(data), format, maxUnzipped)
+}
+
+// parseMapping decodes the optional {"field": "Header in file"} mapping
+func parseMapping(raw string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if raw == "" {
+		return mapping, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		return nil, fmt.Errorf("invalid mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// mapColumns returns, for each column, the index of its cell in a row, or -1
+// when the file has no such column
+func mapColumns[T any](header []string, columns []column[T], mapping map[string]string) ([]int, error) {
+	known := map[string]bool{}
+	for _, col := range columns {
+		known[col.field] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("invalid mapping: unknown field %s", field)
+		}
+	}
+
+	positions := map[string]int{}
+	for i, h := range header {
+		positions[normalize(h)] = i
+	}
+
+	index := make([]int, len(columns))
+	for c, col := range columns {
+		name := col.field
+		if h, ok := mapping[col.field]; ok {
+			name = h
+		}
+		pos, ok := positions[normalize(name)]
+		if !ok {
+			if _, mapped := mapping[col.field]; mapped {
+				return nil, fmt.Errorf("invalid mapping: no column %q in file", name)
+			}
+			pos = -1
+		}
+		index[c] = pos
+	}
+	return index, nil
+}
+
+func normalize(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
+
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseInt[N int | int64](value, field string, dst *N) error {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("field %s is invalid", field)
+	}
+	*dst = N(n)
+	return nil
+}
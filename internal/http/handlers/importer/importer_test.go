@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage records the imported students and classes and knows one
+// teacher, Sam Lee with id 4. Other methods fall through to the nil
+// embedded interface.
+type mockStorage struct {
+	storage.Storage
+	imported []types.Student
+	classes  []types.Class
+}
+
+func (m *mockStorage) ImportStudents(ctx context.Context, students []types.Student) ([]int64, error) {
+	m.imported = append(m.imported, students...)
+	ids := make([]int64, len(students))
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	return ids, nil
+}
+
+func (m *mockStorage) ImportClasses(ctx context.Context, classes []types.Class) ([]int64, error) {
+	m.classes = append(m.classes, classes...)
+	return make([]int64, len(classes)), nil
+}
+
+func (m *mockStorage) ResolveTeacher(ctx context.Context, name string) (int64, error) {
+	if name != "Sam Lee" {
+		return 0, fmt.Errorf("teacher %q: %w", name, storage.ErrUnknownTeacher)
+	}
+	return 4, nil
+}
+
+func (m *mockStorage) GetTeachers(ctx context.Context) ([]types.Teacher, error) {
+	return []types.Teacher{{Id: 4, Name: "Sam Lee"}}, nil
+}
+
+func importCSV(t *testing.T, m *mockStorage, query, body string) (*httptest.ResponseRecorder, types.ImportReport) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/import/students"+query, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+	Students(m, 1<<20)(rr, req)
+
+	var report types.ImportReport
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	return rr, report
+}
+
+func TestImportStudents(t *testing.T) {
+	m := &mockStorage{}
+	rr, report := importCSV(t, m, "", "Name,Email,Age\nAda,ada@example.com,12\nCy,cy@example.com,13\n")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	if report.Imported != 2 || len(m.imported) != 2 || m.imported[1].Age != 13 {
+		t.Errorf("unexpected report %+v, imported %+v", report, m.imported)
+	}
+}
+
+func TestImportStudentsPartialFailure(t *testing.T) {
+	data := "name,email,age\nAda,ada@example.com,12\n,bo@example.com,11\n\nCy,cy@example.com,old\n"
+
+	m := &mockStorage{}
+	rr, report := importCSV(t, m, "", data)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusUnprocessableEntity, rr.Code, rr.Body)
+	}
+	if len(m.imported) != 0 {
+		t.Errorf("no rows should be saved when any row is invalid, got %+v", m.imported)
+	}
+	if report.TotalRows != 3 || report.ValidRows != 1 || report.Imported != 0 {
+		t.Errorf("unexpected counts %+v", report)
+	}
+	// the blank line still counts towards row numbers
+	if len(report.Errors) != 2 || report.Errors[0].Row != 3 || report.Errors[1].Row != 5 {
+		t.Fatalf("unexpected row errors %+v", report.Errors)
+	}
+	if report.Errors[1].Errors[0] != "field Age is invalid" {
+		t.Errorf("unexpected message %q", report.Errors[1].Errors[0])
+	}
+
+	rr, report = importCSV(t, m, "?dry_run=true", data)
+	if rr.Code != http.StatusOK || !report.DryRun || len(report.Errors) != 2 || len(m.imported) != 0 {
+		t.Errorf("dry run should report without saving, got %d %+v", rr.Code, report)
+	}
+}
+
+func TestImportStudentsMalformed(t *testing.T) {
+	m := &mockStorage{}
+	if rr, _ := importCSV(t, m, "", "name,email,age\n\"Ada,ada@example.com,12\n"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for malformed csv, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "roster.xlsx")
+	part.Write([]byte("PK\x03\x04 not really a workbook"))
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/api/import/students", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	Students(m, 1<<20)(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for malformed xlsx, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if len(m.imported) != 0 {
+		t.Errorf("nothing should be imported, got %+v", m.imported)
+	}
+}
+
+func TestImportClassesUnknownTeacher(t *testing.T) {
+	const body = "name,grade,section,teacher_id,teacher_name\n5A,5,A,,Sam Lee\n5B,5,B,,Kim Park\n5C,5,C,9,\n5D,5,D,4,\n"
+	for _, query := range []string{"?dry_run=true", ""} {
+		m := &mockStorage{}
+		req := httptest.NewRequest("POST", "/api/import/classes"+query, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "text/csv")
+		rr := httptest.NewRecorder()
+		Classes(m, 1<<20)(rr, req)
+
+		var report types.ImportReport
+		json.Unmarshal(rr.Body.Bytes(), &report)
+		if report.ValidRows != 2 || len(report.Errors) != 2 || report.Errors[0].Row != 3 || report.Errors[1].Row != 4 {
+			t.Errorf("%q: expected rows 3 and 4 to name unknown teachers, got %+v", query, report)
+		}
+		if query == "" && (rr.Code != http.StatusUnprocessableEntity || len(m.classes) != 0) {
+			t.Errorf("expected status code %d and nothing imported, got %d and %+v", http.StatusUnprocessableEntity, rr.Code, m.classes)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Package kiosk serves self check-in: student badges as QR codes, class
+// sessions opened by the teacher, and the check-ins a kiosk posts when a
+// student scans their badge.
+package kiosk
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/checkin"
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/qrcode"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// sessionRequest opens a check-in session for a class today, for one of
+// its periods or for the whole day
+type sessionRequest struct {
+	ClassID  int64 `json:"class_id" validate:"required"`
+	PeriodID int64 `json:"period_id"`
+}
+
+// session is the answer to opening a session; the kiosk posts Code with
+// every check-in until ExpiresAt
+type session struct {
+	Code      string    `json:"code"`
+	SessionID string    `json:"session_id"`
+	ClassID   int64     `json:"class_id"`
+	PeriodID  int64     `json:"period_id,omitempty"`
+	Date      time.Time `json:"date"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// checkInRequest is what a kiosk posts when a badge is scanned
+type checkInRequest struct {
+	Session string `json:"session" validate:"required"`
+	Badge   string `json:"badge" validate:"required"`
+}
+
+type overrideRequest struct {
+	Status  string `json:"status" validate:"required,oneof=Present Absent Late Excused"`
+	Remarks string `json:"remarks"`
+}
+
+// StudentQR serves the student's current badge as a QR code to print on
+// their ID card: PNG by default, SVG with ?format=svg or an Accept of
+// image/svg+xml. ?scale= sets the pixels per module, 1 to 40.
+func StudentQR(storage storage.Storage, signer *checkin.Signer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		scale := 8
+		if v := r.URL.Query().Get("scale"); v != "" {
+			if scale, err = strconv.Atoi(v); err != nil || scale < 1 || scale > 40 {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid scale %q, expected 1 to 40", v)))
+				return
+			}
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" && strings.Contains(r.Header.Get("Accept"), "image/svg+xml") {
+			format = "svg"
+		}
+		if !slices.Contains([]string{"", "png", "svg"}, format) {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid format %q, expected png or svg", format)))
+			return
+		}
+		version, err := storage.GetBadgeVersion(r.Context(), id)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		log.Info("drawing student badge", slog.Int64("studentId", id), slog.Int64("version", version), slog.String("format", format))
+
+		badge := signer.Badge(id, version)
+		ext, contentType, image := "png", "image/png", qrcode.PNG
+		if format == "svg" {
+			ext, contentType, image = "svg", "image/svg+xml", qrcode.SVG
+		}
+		data, err := image(badge, scale)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="student-%d.%s"`, id, ext))
+		w.Write(data)
+	}
+}
+
+// RevokeBadge invalidates every badge printed for the student, for a lost
+// or stolen ID card; print the badge again from StudentQR
+func RevokeBadge(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		version, err := storage.RevokeBadge(r.Context(), id)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		log.Info("student badge revoked", slog.Int64("studentId", id), slog.Int64("version", version))
+		response.WriteJson(w, http.StatusOK, map[string]int64{"student_id": id, "badge_version": version})
+	}
+}
+
+// OpenSession starts a check-in session for a class and returns its code,
+// which the kiosk sends with each check-in. The code is signed and expires
+// after cfg.SessionTTL; open a new session to keep checking in after that.
+func OpenSession(storage storage.Storage, signer *checkin.Signer, cfg config.CheckIn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		var req sessionRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		if _, err := storage.GetClassById(r.Context(), req.ClassID); err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+
+		if req.PeriodID != 0 {
+			if _, err := storage.GetPeriodById(r.Context(), req.PeriodID); err != nil {
+				response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+				return
+			}
+		}
+
+		now := time.Now().Truncate(time.Second)
+		s := checkin.Session{ClassID: req.ClassID, PeriodID: req.PeriodID, Date: dateOf(now), ExpiresAt: now.Add(cfg.SessionTTL)}
+		code, err := signer.NewSession(&s)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("check-in session opened", slog.String("sessionId", s.ID), slog.Int64("classId", s.ClassID), slog.Int64("periodId", s.PeriodID))
+		response.WriteJson(w, http.StatusCreated, session{
+			Code: code, SessionID: s.ID, ClassID: s.ClassID, PeriodID: s.PeriodID, Date: s.Date, ExpiresAt: s.ExpiresAt,
+		})
+	}
+}
+
+// CheckIn records a scanned badge against the kiosk's session, at the
+// server clock; whether that is Present or Late follows the schedule and
+// grace of the class. The badge must be the student's current one, and the
+// student must be in the class, by homeroom or an active enrollment. Each student checks in once per day and period, so
+// scanning again or replaying the request gets 409, as does checking in
+// when a teacher already marked the student.
+func CheckIn(storage storage.Storage, signer *checkin.Signer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		var req checkInRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		now := time.Now()
+		s, err := signer.ParseSession(req.Session, now)
+		if errors.Is(err, checkin.ErrExpired) {
+			response.WriteJson(w, http.StatusGone, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		studentID, version, err := signer.ParseBadge(req.Badge)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		student, err := storage.GetStudentById(r.Context(), studentID)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		if current, err := storage.GetBadgeVersion(r.Context(), studentID); err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		} else if version != current {
+			log.Warn("revoked badge scanned", slog.Int64("studentId", studentID), slog.Int64("version", version))
+			response.WriteJson(w, http.StatusForbidden, response.GeneralError(checkin.ErrRevoked))
+			return
+		}
+		if ok, err := inClass(r, storage, student, s.ClassID); err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		} else if !ok {
+			response.WriteJson(w, http.StatusForbidden, response.GeneralError(fmt.Errorf("student %d is not in class %d", studentID, s.ClassID)))
+			return
+		}
+
+		c := types.CheckIn{
+			StudentID:   studentID,
+			ClassID:     s.ClassID,
+			PeriodID:    s.PeriodID,
+			Date:        s.Date,
+			SessionID:   s.ID,
+			CheckedInAt: now,
+		}
+		id, err := storage.CheckIn(r.Context(), c)
+		if err != nil {
+			log.Warn("check-in refused", slog.Int64("studentId", studentID), slog.String("sessionId", s.ID), slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		if c, err = storage.GetCheckInById(r.Context(), id); err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("student checked in", slog.Int64("studentId", studentID), slog.String("sessionId", s.ID), slog.String("status", c.Status))
+		response.WriteJson(w, http.StatusCreated, struct {
+			types.CheckIn
+			StudentName string `json:"student_name"`
+		}{c, student.Name})
+	}
+}
+
+// GetList lists check-ins by ?student_id=, ?class_id=, ?session_id= and
+// ?date=
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := types.CheckInFilter{SessionID: q.Get("session_id")}
+		for name, dst := range map[string]*int64{"student_id": &filter.StudentID, "class_id": &filter.ClassID} {
+			if v := q.Get(name); v != "" {
+				id, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid %s %q", name, v)))
+					return
+				}
+				*dst = id
+			}
+		}
+		if v := q.Get("date"); v != "" {
+			date, err := dates.Parse(v)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+				return
+			}
+			filter.Date = date
+		}
+
+		checkIns, err := storage.GetCheckIns(r.Context(), filter)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, checkIns)
+	}
+}
+
+// Override lets the teacher set the attendance status a check-in marked;
+// the check-in keeps what it recorded and shows the override
+func Override(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var req overrideRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		if err := storage.OverrideCheckIn(r.Context(), id, req.Status, req.Remarks); err != nil {
+			log.Error("error overriding check-in", slog.Int64("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("check-in overridden", slog.Int64("id", id), slog.String("status", req.Status))
+		checkIn, err := storage.GetCheckInById(r.Context(), id)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, checkIn)
+	}
+}
+
+// inClass reports whether the student belongs to the class, as their
+// homeroom or through an active enrollment
+func inClass(r *http.Request, storage storage.Storage, student types.Student, classID int64) (bool, error) {
+	if student.ClassID == classID {
+		return true, nil
+	}
+	enrollments, err := storage.GetEnrollments(r.Context(), types.EnrollmentFilter{StudentID: student.Id, ClassID: classID, Status: types.EnrollmentActive})
+	return len(enrollments) > 0, err
+}
+
+// dateOf returns the calendar day of t as stored for attendance
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrNonInstructionalDay), errors.Is(err, storage.ErrPeriodNotScheduled):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,111 @@
+package kiosk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/checkin"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage has student 1 in class 1 with a badge version that can be
+// revoked. Other methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	version  int64
+	checkIns []types.CheckIn
+}
+
+func (m *mockStorage) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	if id != 1 {
+		return types.Student{}, fmt.Errorf("no student found with id %d", id)
+	}
+	return types.Student{Id: 1, Name: "Asha Rao", ClassID: 1}, nil
+}
+
+func (m *mockStorage) GetBadgeVersion(ctx context.Context, studentID int64) (int64, error) {
+	if studentID != 1 {
+		return 0, fmt.Errorf("no student found with id %d", studentID)
+	}
+	return m.version, nil
+}
+
+func (m *mockStorage) RevokeBadge(ctx context.Context, studentID int64) (int64, error) {
+	if studentID != 1 {
+		return 0, fmt.Errorf("no student found with id %d", studentID)
+	}
+	m.version++
+	return m.version, nil
+}
+
+func (m *mockStorage) CheckIn(ctx context.Context, c types.CheckIn) (int64, error) {
+	m.checkIns = append(m.checkIns, c)
+	return int64(len(m.checkIns)), nil
+}
+
+func (m *mockStorage) GetCheckInById(ctx context.Context, id int64) (types.CheckIn, error) {
+	c := m.checkIns[id-1]
+	c.Id, c.Status = id, "Present"
+	return c, nil
+}
+
+func TestCheckInRevokedBadge(t *testing.T) {
+	m := &mockStorage{version: 1}
+	signer, err := checkin.NewSigner("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := signer.NewSession(&checkin.Session{ClassID: 1, Date: dateOf(now), ExpiresAt: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIn := func(badge string) int {
+		rr := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"session":%q,"badge":%q}`, code, badge)
+		CheckIn(m, signer)(rr, httptest.NewRequest("POST", "/api/checkin", strings.NewReader(body)))
+		return rr.Code
+	}
+	old := signer.Badge(1, 1)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/students/1/qr/revoke", nil)
+	req.SetPathValue("id", "1")
+	RevokeBadge(m)(rr, req)
+	if rr.Code != http.StatusOK || m.version != 2 {
+		t.Fatalf("expected the badge to be revoked, got status code %d and version %d", rr.Code, m.version)
+	}
+
+	if code := checkIn(old); code != http.StatusForbidden {
+		t.Errorf("revoked badge: expected status code %d, got %d", http.StatusForbidden, code)
+	}
+	if code := checkIn(signer.Badge(1, 2)); code != http.StatusCreated {
+		t.Errorf("current badge: expected status code %d, got %d", http.StatusCreated, code)
+	}
+	if len(m.checkIns) != 1 {
+		t.Errorf("expected one check-in, got %+v", m.checkIns)
+	}
+}
+
+func TestStudentQRUsesCurrentVersion(t *testing.T) {
+	m := &mockStorage{version: 3}
+	signer, err := checkin.NewSigner("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]int{"1": http.StatusOK, "2": http.StatusNotFound} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/students/"+id+"/qr?format=svg", nil)
+		req.SetPathValue("id", id)
+		StudentQR(m, signer)(rr, req)
+		if rr.Code != want {
+			t.Errorf("student %s: expected status code %d, got %d", id, want, rr.Code)
+		}
+	}
+}
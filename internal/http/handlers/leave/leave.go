@@ -0,0 +1,244 @@
+// Package leave handles leave requests: a guardian, the student or staff
+// asks for a student to be excused over a range of days, and a teacher or
+// admin approves or rejects the request. Approval excuses the student's
+// absences in the range.
+package leave
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/http/handlers/guardian"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// createRequest is a leave request; submitted_by defaults to student
+type createRequest struct {
+	StudentID     int64  `json:"student_id" validate:"required"`
+	StartDate     string `json:"start_date" validate:"required"`
+	EndDate       string `json:"end_date" validate:"required"`
+	Reason        string `json:"reason" validate:"required"`
+	AttachmentURL string `json:"attachment_url" validate:"omitempty,url"`
+	SubmittedBy   string `json:"submitted_by" validate:"omitempty,oneof=student staff"`
+}
+
+type reviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+	Note   string `json:"note"`
+}
+
+// reviewResponse is a reviewed leave request and the number of absences
+// its approval excused
+type reviewResponse struct {
+	types.LeaveRequest
+	Excused int `json:"excused"`
+}
+
+// New records a leave request made by the student or by staff
+func New(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating a leave request")
+		var req createRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		leave, err := parse(req)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if _, err := storage.GetStudentById(r.Context(), req.StudentID); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		leave.SubmittedBy = req.SubmittedBy
+		if leave.SubmittedBy == "" {
+			leave.SubmittedBy = "student"
+		}
+		create(w, r, storage, leave)
+	}
+}
+
+// GetList lists leave requests, filtered by student_id, status and the
+// from and to dates they overlap
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting leave requests")
+		var filter types.LeaveFilter
+		var err error
+		if filter.From, filter.To, err = dates.QueryRange(r); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if v := r.URL.Query().Get("student_id"); v != "" {
+			if filter.StudentID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid student_id %q", v)))
+				return
+			}
+		}
+		filter.Status = r.URL.Query().Get("status")
+		if filter.Status != "" && !slices.Contains([]string{types.LeavePending, types.LeaveApproved, types.LeaveRejected}, filter.Status) {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid status %q", filter.Status)))
+			return
+		}
+		list(w, r, storage, filter)
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("getting a leave request", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		leave, err := storage.GetLeaveRequestById(r.Context(), intId)
+		if err != nil {
+			log.Error("error getting leave request", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, leave)
+	}
+}
+
+// Review approves or rejects a pending leave request on behalf of the
+// staff user whose API key middleware.APIKeys.Require accepted. A request
+// is reviewed once; reviewing it again is a conflict.
+func Review(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("reviewing a leave request", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		reviewer, ok := middleware.StaffFromContext(r.Context())
+		if !ok {
+			response.WriteJson(w, http.StatusUnauthorized, response.GeneralError(fmt.Errorf("a staff API key is required")))
+			return
+		}
+		var req reviewRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		if _, err := storage.GetLeaveRequestById(r.Context(), intId); err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+
+		excused, err := storage.ReviewLeaveRequest(r.Context(), intId, req.Status, reviewer, req.Note)
+		if err != nil {
+			log.Error("error reviewing leave request", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		log.Info("leave request reviewed", slog.String("id", id), slog.String("status", req.Status), slog.Int("excused", excused))
+		leave, err := storage.GetLeaveRequestById(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, reviewResponse{LeaveRequest: leave, Excused: excused})
+	}
+}
+
+// GuardianNew records a leave request made through the guardian portal,
+// for one of the logged-in guardian's students
+func GuardianNew(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("guardian creating a leave request")
+		var req createRequest
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		leave, err := parse(req)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		guardianID := guardian.FromContext(r.Context())
+		students, err := storage.GetGuardianStudents(r.Context(), guardianID)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if !slices.ContainsFunc(students, func(s types.Student) bool { return s.Id == req.StudentID }) {
+			response.WriteJson(w, http.StatusForbidden, response.GeneralError(fmt.Errorf("student %d is not linked to this guardian", req.StudentID)))
+			return
+		}
+		leave.SubmittedBy = "guardian"
+		leave.GuardianID = guardianID
+		create(w, r, storage, leave)
+	}
+}
+
+// GuardianList lists the leave requests the logged-in guardian made
+func GuardianList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting the guardian's leave requests")
+		list(w, r, storage, types.LeaveFilter{GuardianID: guardian.FromContext(r.Context())})
+	}
+}
+
+func create(w http.ResponseWriter, r *http.Request, storage storage.Storage, leave types.LeaveRequest) {
+	log := logger.FromRequest(r)
+	id, err := storage.CreateLeaveRequest(r.Context(), leave)
+	if err != nil {
+		log.Error("error creating leave request", slog.String("error", err.Error()))
+		response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+		return
+	}
+	log.Info("leave request created successfully", slog.String("leaveId", fmt.Sprint(id)))
+	response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+}
+
+func list(w http.ResponseWriter, r *http.Request, storage storage.Storage, filter types.LeaveFilter) {
+	leaves, err := storage.GetLeaveRequests(r.Context(), filter)
+	if err != nil {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	response.WriteJson(w, http.StatusOK, leaves)
+}
+
+// parse reads the dates and reason of a request into a leave request
+func parse(req createRequest) (types.LeaveRequest, error) {
+	leave := types.LeaveRequest{StudentID: req.StudentID, Reason: req.Reason, AttachmentURL: req.AttachmentURL}
+	var err error
+	if leave.StartDate, err = dates.Parse(req.StartDate); err != nil {
+		return leave, err
+	}
+	if leave.EndDate, err = dates.Parse(req.EndDate); err != nil {
+		return leave, err
+	}
+	if leave.EndDate.Before(leave.StartDate) {
+		return leave, fmt.Errorf("end_date must not be before start_date")
+	}
+	return leave, nil
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, storage.ErrAlreadyReviewed) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,99 @@
+package leave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage keeps leave requests in memory; approving one excuses the
+// absences listed for its student. Other methods fall through to the nil
+// embedded interface.
+type mockStorage struct {
+	storage.Storage
+	leaves   map[int64]types.LeaveRequest
+	absences map[int64]int
+}
+
+func (m *mockStorage) GetLeaveRequestById(ctx context.Context, id int64) (types.LeaveRequest, error) {
+	leave, ok := m.leaves[id]
+	if !ok {
+		return leave, fmt.Errorf("no leave request found with id %d", id)
+	}
+	return leave, nil
+}
+
+func (m *mockStorage) ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (int, error) {
+	leave := m.leaves[id]
+	if leave.Status != types.LeavePending {
+		return 0, fmt.Errorf("leave request %d is %s: %w", id, leave.Status, storage.ErrAlreadyReviewed)
+	}
+	leave.Status, leave.ReviewedBy, leave.ReviewNote = status, reviewedBy, note
+	m.leaves[id] = leave
+	if status != types.LeaveApproved {
+		return 0, nil
+	}
+	excused := m.absences[leave.StudentID]
+	m.absences[leave.StudentID] = 0
+	return excused, nil
+}
+
+var keys = middleware.NewAPIKeys([]config.APIKey{{Name: "ms-lee", Key: "lee-key"}})
+
+func review(m *mockStorage, id, key, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/api/leave-requests/"+id+"/review", strings.NewReader(body))
+	req.SetPathValue("id", id)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	keys.Require(Review(m))(rr, req)
+	return rr
+}
+
+func TestReview(t *testing.T) {
+	m := &mockStorage{
+		leaves:   map[int64]types.LeaveRequest{1: {Id: 1, StudentID: 7, Status: types.LeavePending}},
+		absences: map[int64]int{7: 2},
+	}
+
+	rr := review(m, "1", "lee-key", `{"status":"approved","reviewed_by":"someone else"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body)
+	}
+	var got reviewResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != types.LeaveApproved || got.Excused != 2 || got.ReviewedBy != "ms-lee" {
+		t.Errorf("expected an approval by ms-lee that excused 2 absences, got %+v", got)
+	}
+
+	cases := []struct {
+		id, key, body string
+		want          int
+	}{
+		{"1", "", `{"status":"rejected"}`, http.StatusUnauthorized},
+		{"1", "lee-key", `{"status":"rejected"}`, http.StatusConflict},
+		{"2", "lee-key", `{"status":"approved"}`, http.StatusNotFound},
+		{"1", "lee-key", `{"status":"maybe"}`, http.StatusBadRequest},
+		{"x", "lee-key", `{"status":"approved"}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		if rr := review(m, c.id, c.key, c.body); rr.Code != c.want {
+			t.Errorf("%s %s with key %q: expected status code %d, got %d", c.id, c.body, c.key, c.want, rr.Code)
+		}
+	}
+	if m.leaves[1].Status != types.LeaveApproved {
+		t.Errorf("a second review should not change the request, got %+v", m.leaves[1])
+	}
+}
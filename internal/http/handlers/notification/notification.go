@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/notify"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// GetList lists absence notifications with their delivery status, filtered
+// by student_id, status and a date or from/to range
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("getting notifications")
+
+		q := r.URL.Query()
+		filter := types.NotificationFilter{Status: q.Get("status")}
+		if v := q.Get("student_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid student_id %q", v)))
+				return
+			}
+			filter.StudentID = id
+		}
+		var err error
+		if filter.From, filter.To, err = dates.QueryRange(r); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		notifications, err := storage.GetNotifications(r.Context(), filter)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, notifications)
+	}
+}
+
+func GetTemplates(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting notification templates")
+		templates, err := storage.GetNotificationTemplates(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, templates)
+	}
+}
+
+// UpdateTemplate replaces the template of the email or sms channel. It is
+// rendered against sample data first so a broken template is rejected.
+func UpdateTemplate(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		channel := r.PathValue("channel")
+		log.Info("updating notification template", slog.String("channel", channel))
+
+		if channel != notify.ChannelEmail && channel != notify.ChannelSMS {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("unknown channel %q, expected email or sms", channel)))
+			return
+		}
+		var template types.NotificationTemplate
+		if !response.Decode(w, r, &template) {
+			return
+		}
+		template.Channel = channel
+		if err := notify.Check(template); err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := storage.SaveNotificationTemplate(r.Context(), template); err != nil {
+			log.Error("error saving notification template", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Template updated successfully"})
+	}
+}
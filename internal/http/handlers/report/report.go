@@ -0,0 +1,127 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/reports"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/dates"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// ClassRegister serves the printable monthly register of a class as PDF.
+// The month query parameter is YYYY-MM and defaults to the current month.
+func ClassRegister(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Generating class register", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		month := time.Now()
+		if v := r.URL.Query().Get("month"); v != "" {
+			month, err = time.Parse("2006-01", v)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid month %q, expected YYYY-MM", v)))
+				return
+			}
+		}
+		first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+		last := first.AddDate(0, 1, -1)
+
+		class, err := storage.GetClassById(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		students, err := storage.GetStudents(r.Context(), types.StudentFilter{ClassID: intId})
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		records, err := storage.GetAttendance(r.Context(), types.AttendanceFilter{ClassID: intId, From: first, To: last})
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		days, err := storage.GetCalendarDays(r.Context(), first, last)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		err = reports.ClassRegister(&buf, reports.RegisterData{Class: class, Month: first, Students: students, Records: records, Days: days})
+		if err != nil {
+			log.Error("error generating class register", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		writePDF(w, fmt.Sprintf("register-%s-%s.pdf", id, first.Format("2006-01")), buf.Bytes())
+	}
+}
+
+// StudentReportCard serves a student's attendance report card as PDF for
+// the optional from/to date range
+func StudentReportCard(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Generating report card", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		from, to, err := dates.QueryRange(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		student, err := storage.GetStudentById(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		report, err := storage.GetAttendanceReport(r.Context(), intId, from, to)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		records, err := storage.GetAttendanceByStudent(r.Context(), intId, from, to)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		err = reports.ReportCard(&buf, reports.ReportCardData{Student: student, Report: report, From: from, To: to, Records: records})
+		if err != nil {
+			log.Error("error generating report card", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		writePDF(w, fmt.Sprintf("report-%s.pdf", id), buf.Bytes())
+	}
+}
+
+func writePDF(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
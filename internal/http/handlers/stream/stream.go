@@ -0,0 +1,195 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/bus"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// replayBatch is how many stored events are read at a time when resuming
+const replayBatch = 500
+
+// sseNames are the SSE event names of the attendance event types
+var sseNames = map[string]string{
+	"attendance.created": "marked",
+	"attendance.updated": "updated",
+	"attendance.deleted": "deleted",
+}
+
+// filter picks the attendance events of one class or one grade; zero
+// values match all. Class grades are looked up once and remembered.
+type filter struct {
+	classID int64
+	grade   string
+	grades  map[int64]string
+	storage storage.Storage
+}
+
+func (f *filter) match(ctx context.Context, event types.Event) (types.AttendanceRecord, bool) {
+	var record types.AttendanceRecord
+	if _, ok := sseNames[event.Type]; !ok {
+		return record, false
+	}
+	if err := json.Unmarshal(event.Data, &record); err != nil {
+		return record, false
+	}
+	if f.classID != 0 && record.ClassID != f.classID {
+		return record, false
+	}
+	if f.grade != "" {
+		grade, ok := f.grades[record.ClassID]
+		if !ok {
+			if class, err := f.storage.GetClassById(ctx, record.ClassID); err == nil {
+				grade = class.Grade
+			}
+			f.grades[record.ClassID] = grade
+		}
+		if grade != f.grade {
+			return record, false
+		}
+	}
+	return record, true
+}
+
+// Attendance streams attendance as Server-Sent Events: "marked", "updated"
+// and "deleted", each carrying the record, with the change feed seq as the
+// event id. ?class_id= or ?grade= narrow the stream. A reconnecting client
+// sends Last-Event-ID, or ?last_event_id=, and first gets what it missed;
+// if that is no longer kept it gets a "reset" event and should reload. A
+// comment is sent every heartbeat to keep proxies from closing the
+// connection. The stream ends when the client goes, when it falls too far
+// behind, or when the server shuts down and closes the bus.
+func Attendance(storage storage.Storage, events *bus.Bus, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		q := r.URL.Query()
+
+		f := &filter{grade: q.Get("grade"), grades: map[int64]string{}, storage: storage}
+		if v := q.Get("class_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid class_id %q", v)))
+				return
+			}
+			f.classID = id
+		}
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = q.Get("last_event_id")
+		}
+		var since int64
+		if lastID != "" {
+			var err error
+			if since, err = strconv.ParseInt(lastID, 10, 64); err != nil || since < 0 {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid last event id %q", lastID)))
+				return
+			}
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(fmt.Errorf("streaming not supported")))
+			return
+		}
+
+		// subscribe before reading the backlog so nothing falls in between;
+		// events seen in both are skipped by seq
+		sub := events.Subscribe()
+		defer sub.Cancel()
+
+		first, last, err := storage.GetEventSeqRange(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "retry: 3000\n\n")
+		log.Info("attendance stream opened", slog.Int64("classId", f.classID), slog.String("grade", f.grade), slog.Int64("since", since))
+
+		sent := last
+		switch {
+		case lastID == "":
+		case first > 0 && since < first-1:
+			fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", last)
+		default:
+			if sent, err = replay(r.Context(), w, storage, f, since); err != nil {
+				log.Error("error replaying attendance events", slog.String("error", err.Error()))
+				return
+			}
+		}
+		flusher.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Info("attendance stream closed by client")
+				return
+			case <-ticker.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			case event, ok := <-sub.C:
+				if !ok {
+					log.Info("attendance stream ended", slog.Bool("lagged", sub.Lagged()))
+					return
+				}
+				if event.Seq <= sent {
+					continue
+				}
+				sent = event.Seq
+				if record, ok := f.match(r.Context(), event); ok {
+					if err := write(w, event, record); err != nil {
+						return
+					}
+					flusher.Flush()
+				}
+			}
+		}
+	}
+}
+
+// replay writes the matching events after since and returns the last seq
+// it read
+func replay(ctx context.Context, w http.ResponseWriter, storage storage.Storage, f *filter, since int64) (int64, error) {
+	for {
+		events, err := storage.GetEvents(ctx, since, replayBatch)
+		if err != nil {
+			return since, err
+		}
+		for _, event := range events {
+			since = event.Seq
+			if record, ok := f.match(ctx, event); ok {
+				if err := write(w, event, record); err != nil {
+					return since, err
+				}
+			}
+		}
+		if len(events) < replayBatch {
+			return since, nil
+		}
+	}
+}
+
+func write(w http.ResponseWriter, event types.Event, record types.AttendanceRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, sseNames[event.Type], data)
+	return err
+}
@@ -0,0 +1,112 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/bus"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage serves a fixed outbox and two classes in different grades.
+// Other methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	events []types.Event
+}
+
+func (m *mockStorage) GetEventSeqRange(ctx context.Context) (int64, int64, error) {
+	return m.events[0].Seq, m.events[len(m.events)-1].Seq, nil
+}
+
+func (m *mockStorage) GetEvents(ctx context.Context, since int64, limit int) ([]types.Event, error) {
+	var events []types.Event
+	for _, e := range m.events {
+		if e.Seq > since && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockStorage) GetClassById(ctx context.Context, id int64) (types.Class, error) {
+	return types.Class{Id: id, Grade: map[int64]string{1: "5", 2: "6"}[id]}, nil
+}
+
+func attendance(seq int64, eventType string, classID int64) types.Event {
+	data, _ := json.Marshal(types.AttendanceRecord{Id: seq, StudentID: 1, ClassID: classID, Status: "Present"})
+	return types.Event{Seq: seq, Type: eventType, Resource: "attendance", Data: data}
+}
+
+// readEvents reads SSE events off the stream until n have arrived
+func readEvents(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var events []string
+	var current []string
+	for len(events) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended after %v: %v", events, err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && len(current) > 0:
+			events = append(events, strings.Join(current, "|"))
+			current = nil
+		case strings.HasPrefix(line, "id:"), strings.HasPrefix(line, "event:"):
+			current = append(current, line)
+		}
+	}
+	return events
+}
+
+func TestAttendanceResumesAndFilters(t *testing.T) {
+	m := &mockStorage{events: []types.Event{
+		attendance(1, "attendance.created", 1),
+		attendance(2, "attendance.created", 2),
+		{Seq: 3, Type: "student.updated", Resource: "student", Data: json.RawMessage(`{"id":1}`)},
+		attendance(4, "attendance.updated", 1),
+	}}
+	events := bus.New(8)
+	server := httptest.NewServer(Attendance(m, events, time.Hour))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?grade=5", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	// the backlog after seq 1 holds one grade 5 record
+	if got := readEvents(t, r, 1); got[0] != "id: 4|event: updated" {
+		t.Errorf("replayed %v", got)
+	}
+
+	// seq 4 again from the bus is a duplicate; 5 is grade 6; 6 is new
+	events.Publish(context.Background(), attendance(4, "attendance.updated", 1))
+	events.Publish(context.Background(), attendance(5, "attendance.created", 2))
+	events.Publish(context.Background(), attendance(6, "attendance.deleted", 1))
+	if got := readEvents(t, r, 1); got[0] != "id: 6|event: deleted" {
+		t.Errorf("live events %v", got)
+	}
+
+	// closing the bus, as on shutdown, ends the stream
+	events.Close()
+	if _, err := io.ReadAll(r); err != nil {
+		t.Errorf("stream did not end cleanly: %v", err)
+	}
+}
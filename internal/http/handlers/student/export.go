@@ -0,0 +1,30 @@
+package student
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/export"
+)
+
+var exportTable = export.Table[types.Student]{
+	Columns: []string{"id", "name", "email", "age", "class_id", "roll_no"},
+	Row: func(s types.Student) []any {
+		return []any{s.Id, s.Name, s.Email, s.Age, s.ClassID, s.RollNo}
+	},
+}
+
+// parseFilter reads the list filters shared by the JSON list and exports
+func parseFilter(r *http.Request) (types.StudentFilter, error) {
+	var filter types.StudentFilter
+	if v := r.URL.Query().Get("class_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid class_id %q", v)
+		}
+		filter.ClassID = id
+	}
+	return filter, nil
+}
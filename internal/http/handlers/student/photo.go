@@ -0,0 +1,251 @@
+package student
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/photo"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// photoSizes are the stored renditions of a photo by ?size=, in pixels a
+// side; no size is the photo itself
+var photoSizes = map[string]int{
+	"":                        photo.MaxSide,
+	strconv.Itoa(photo.Small): photo.Small,
+	strconv.Itoa(photo.Large): photo.Large,
+}
+
+// SetPhoto stores a student's photo from a JPEG or PNG, sent as the body
+// or as the multipart field "photo". The photo is stored upright and
+// without metadata, next to its square thumbnails, under a new version;
+// the student's photo_url then moves to it and the old version is deleted,
+// so GetPhoto never serves a mix of the two. Decoding takes a lot of memory, so at most
+// cfg.MaxConcurrent photos are processed at once and other uploads wait.
+func SetPhoto(storage storage.Storage, blobs storage.BlobStore, cfg config.Photos) http.HandlerFunc {
+	jobs := make(chan struct{}, max(cfg.MaxConcurrent, 1))
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		student, ok := photoStudent(w, r, storage)
+		if !ok {
+			return
+		}
+		log.Info("setting student photo", slog.Int64("id", student.Id))
+
+		data, err := readPhoto(r)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.WriteJson(w, http.StatusRequestEntityTooLarge, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		select {
+		case jobs <- struct{}{}:
+			defer func() { <-jobs }()
+		case <-r.Context().Done():
+			response.WriteJson(w, http.StatusServiceUnavailable, response.GeneralError(fmt.Errorf("waiting to process the photo: %w", r.Context().Err())))
+			return
+		}
+		img, err := photo.Decode(data)
+		if err != nil {
+			response.WriteJson(w, http.StatusUnsupportedMediaType, response.GeneralError(err))
+			return
+		}
+		img = photo.Fit(img, photo.MaxSide)
+
+		renditions := map[string][]byte{}
+		for size, side := range photoSizes {
+			var buf bytes.Buffer
+			rendition := img
+			if size != "" {
+				rendition = photo.Thumbnail(img, side)
+			}
+			if err := photo.Encode(&buf, rendition); err != nil {
+				response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+			renditions[size] = buf.Bytes()
+		}
+		// the version in the URL changes with the photo, so a URL's
+		// content never does and can be cached for good
+		sum := sha256.Sum256(renditions[""])
+		version, old := hex.EncodeToString(sum[:6]), photoVersion(student)
+		for size, data := range renditions {
+			if err := blobs.Put(r.Context(), photoKey(student.Id, version, size), bytes.NewReader(data)); err != nil {
+				log.Error("error storing student photo", slog.Int64("id", student.Id), slog.String("error", err.Error()))
+				if version != old {
+					deletePhoto(r, blobs, student.Id, version)
+				}
+				response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+		}
+
+		photoURL := fmt.Sprintf("/api/students/%d/photo?v=%s", student.Id, version)
+		if err := storage.SetStudentPhoto(r.Context(), student.Id, photoURL); err != nil {
+			log.Error("error setting student photo", slog.Int64("id", student.Id), slog.String("error", err.Error()))
+			if version != old {
+				deletePhoto(r, blobs, student.Id, version)
+			}
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if old != "" && old != version {
+			deletePhoto(r, blobs, student.Id, old)
+		}
+		log.Info("student photo set", slog.Int64("id", student.Id), slog.Int("bytes", len(data)))
+		student.PhotoURL = photoURL
+		response.WriteJson(w, http.StatusOK, student)
+	}
+}
+
+// GetPhoto serves a student's photo, or with ?size= one of its thumbnails.
+// Requested with the version of the current photo_url it may be cached
+// for good; otherwise it is revalidated every time.
+func GetPhoto(storage storage.Storage, blobs storage.BlobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		student, ok := photoStudent(w, r, storage)
+		if !ok {
+			return
+		}
+		size := r.URL.Query().Get("size")
+		if _, ok := photoSizes[size]; !ok {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid size %q, expected %d or %d", size, photo.Small, photo.Large)))
+			return
+		}
+		version := photoVersion(student)
+		if version == "" {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("student %d has no photo", student.Id)))
+			return
+		}
+		content, err := blobs.Open(r.Context(), photoKey(student.Id, version, size))
+		if err != nil {
+			logger.FromRequest(r).Error("error opening student photo", slog.Int64("id", student.Id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		defer content.Close()
+
+		h := w.Header()
+		h.Set("Content-Type", "image/jpeg")
+		h.Set("X-Content-Type-Options", "nosniff")
+		etag := version
+		if size != "" {
+			etag += "-" + size
+		}
+		h.Set("ETag", `"`+etag+`"`)
+		if r.URL.Query().Get("v") == version {
+			h.Set("Cache-Control", "private, max-age=31536000, immutable")
+		} else {
+			h.Set("Cache-Control", "private, no-cache")
+		}
+		http.ServeContent(w, r, "", time.Time{}, content)
+	}
+}
+
+// DeletePhoto removes a student's photo and its thumbnails
+func DeletePhoto(storage storage.Storage, blobs storage.BlobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		student, ok := photoStudent(w, r, storage)
+		if !ok {
+			return
+		}
+		log.Info("deleting student photo", slog.Int64("id", student.Id))
+		version := photoVersion(student)
+		if version == "" {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(fmt.Errorf("student %d has no photo", student.Id)))
+			return
+		}
+		if err := storage.SetStudentPhoto(r.Context(), student.Id, ""); err != nil {
+			log.Error("error deleting student photo", slog.Int64("id", student.Id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		deletePhoto(r, blobs, student.Id, version)
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Photo deleted successfully"})
+	}
+}
+
+// photoStudent reads the student in the path, writing the error response
+// itself
+func photoStudent(w http.ResponseWriter, r *http.Request, storage storage.Storage) (types.Student, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+		return types.Student{}, false
+	}
+	student, err := storage.GetStudentById(r.Context(), id)
+	if err != nil {
+		response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+		return student, false
+	}
+	return student, true
+}
+
+// readPhoto reads the image of a request, from its "photo" field when it
+// is multipart and from the body otherwise
+func readPhoto(r *http.Request) ([]byte, error) {
+	src := r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("photo")
+		if err != nil {
+			return nil, fmt.Errorf("reading photo field: %w", err)
+		}
+		defer file.Close()
+		src = file
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty photo")
+	}
+	return data, nil
+}
+
+// photoKey is where a rendition of a version of a student's photo is kept
+func photoKey(studentID int64, version, size string) string {
+	if size == "" {
+		size = "photo"
+	}
+	return fmt.Sprintf("%d/%s-%s.jpg", studentID, version, size)
+}
+
+// deletePhoto removes a version of a student's photo and its thumbnails.
+// Failures are only logged: the version is no longer served.
+func deletePhoto(r *http.Request, blobs storage.BlobStore, studentID int64, version string) {
+	for size := range photoSizes {
+		if err := blobs.Delete(r.Context(), photoKey(studentID, version, size)); err != nil {
+			logger.FromRequest(r).Warn("error deleting student photo file", slog.Int64("id", studentID), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// photoVersion returns the version in a student's photo_url, or "" when
+// the student has no photo
+func photoVersion(student types.Student) string {
+	u, err := url.Parse(student.PhotoURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("v")
+}
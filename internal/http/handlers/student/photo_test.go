@@ -0,0 +1,147 @@
+package student
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/storage/localfs"
+)
+
+// photoStorage records the photo_url a student is given
+type photoStorage struct {
+	*MockStorage
+	photoURL string
+}
+
+func (m *photoStorage) SetStudentPhoto(ctx context.Context, id int64, photoURL string) error {
+	m.photoURL = photoURL
+	student := m.students[id]
+	student.PhotoURL = photoURL
+	m.students[id] = student
+	return nil
+}
+
+func encodePNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSetPhotoReplacesVersion(t *testing.T) {
+	dir := t.TempDir()
+	blobs, err := localfs.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &photoStorage{MockStorage: NewMockStorage()}
+	id, _ := m.CreateStudent(context.Background(), "Asha Rao", "asha@example.com", 10)
+	upload := func(data []byte) {
+		t.Helper()
+		req := httptest.NewRequest("PUT", "/api/students/1/photo", bytes.NewReader(data))
+		req.SetPathValue("id", "1")
+		rr := httptest.NewRecorder()
+		SetPhoto(m, blobs, config.Photos{MaxConcurrent: 1})(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body)
+		}
+	}
+
+	upload(encodePNG(t, color.White))
+	first := photoVersion(m.students[id])
+	upload(encodePNG(t, color.Black))
+	second := photoVersion(m.students[id])
+	if first == second {
+		t.Fatalf("expected a new version for a new photo, got %s twice", first)
+	}
+
+	// only the current version is kept
+	files, err := filepath.Glob(filepath.Join(dir, "1", "*"))
+	if err != nil || len(files) != len(photoSizes) {
+		t.Errorf("expected %d files, got %v (%v)", len(photoSizes), files, err)
+	}
+	for _, f := range files {
+		if !strings.HasPrefix(filepath.Base(f), second+"-") {
+			t.Errorf("expected only files of version %s, got %s", second, f)
+		}
+	}
+	req := httptest.NewRequest("GET", "/api/students/1/photo?size=64", nil)
+	req.SetPathValue("id", "1")
+	rr := httptest.NewRecorder()
+	GetPhoto(m, blobs)(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"`+second+`-64"` {
+		t.Errorf("expected the thumbnail of version %s, got %d with ETag %s", second, rr.Code, rr.Header().Get("ETag"))
+	}
+}
+
+// blockingBlobs holds every Put until release is closed, signalling each
+// one on started
+type blockingBlobs struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBlobs) Put(ctx context.Context, key string, r io.Reader) error {
+	b.started <- struct{}{}
+	<-b.release
+	return nil
+}
+
+func (b *blockingBlobs) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	return nil, nil
+}
+
+func (b *blockingBlobs) Delete(ctx context.Context, key string) error { return nil }
+
+func TestSetPhotoBoundsConcurrency(t *testing.T) {
+	m := &photoStorage{MockStorage: NewMockStorage()}
+	id, _ := m.CreateStudent(context.Background(), "Asha Rao", "asha@example.com", 10)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	blobs := &blockingBlobs{started: make(chan struct{}, 10), release: make(chan struct{})}
+	handler := SetPhoto(m, blobs, config.Photos{MaxConcurrent: 1})
+	upload := func(ctx context.Context) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(ctx, "PUT", "/api/students/1/photo", bytes.NewReader(buf.Bytes()))
+		req.SetPathValue("id", "1")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- upload(context.Background()) }()
+	<-blobs.started
+
+	// the only slot is taken, so a second upload waits until it gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if rr := upload(ctx); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status code %d while the slot is taken, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	close(blobs.release)
+	if rr := <-first; rr.Code != http.StatusOK || m.photoURL == "" {
+		t.Errorf("expected the first upload to set the photo of student %d, got %d", id, rr.Code)
+	}
+	if rr := upload(context.Background()); rr.Code != http.StatusOK {
+		t.Errorf("expected the slot to be free again, got status code %d", rr.Code)
+	}
+}
@@ -1,66 +1,54 @@
 package student
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/tukesh1/student-api/internal/logger"
 	"github.com/tukesh1/student-api/internal/storage"
 	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/export"
 	"github.com/tukesh1/student-api/internal/utils/response"
 )
 
 func New(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("creating student")
+		log := logger.FromRequest(r)
+		log.Info("creating student")
 		var student types.Student
-		err := json.NewDecoder(r.Body).Decode(&student)
-		if errors.Is(err, io.EOF) {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("empty body")))
-			return
-		}
-		if err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
-			return
-		}
-
-		// validating request
-		if err := validator.New().Struct(student); err != nil {
-			validateErrs := err.(validator.ValidationErrors)
-			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(validateErrs))
+		if !response.Decode(w, r, &student) {
 			return
 		}
 		lastId, err := storage.CreateStudent(
+			r.Context(),
 			student.Name,
 			student.Email,
 			student.Age,
 		)
-		slog.Info("user created successfully", slog.String("userId", fmt.Sprint(lastId)))
-
 		if err != nil {
+			log.Error("error creating student", slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusInternalServerError, err)
 			return
 		}
+		log.Info("student created successfully", slog.String("userId", fmt.Sprint(lastId)))
 		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": lastId})
 	}
 }
 func GetById(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
 		id := r.PathValue("id")
-		slog.Info("Getting a student", slog.String("id", id))
+		log.Info("Getting a student", slog.String("id", id))
 		intId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
 			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
-		student, err := storage.GetStudentById(intId)
+		student, err := storage.GetStudentById(r.Context(), intId)
 		if err != nil {
-			slog.Error("error getting user", slog.String("id", id))
+			log.Error("error getting user", slog.String("id", id), slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
@@ -71,9 +59,22 @@ func GetById(storage storage.Storage) http.HandlerFunc {
 
 func GetList(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("getting all students")
-		students, err := storage.GetStudents()
+		log := logger.FromRequest(r)
+		log.Info("getting all students")
+		filter, err := parseFilter(r)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if format := export.Negotiate(r); format != export.JSON {
+			export.Stream(w, r, format, "students", exportTable, func(fn func(types.Student) error) error {
+				return storage.StreamStudents(r.Context(), filter, fn)
+			})
+			return
+		}
 
+		students, err := storage.GetStudents(r.Context(), filter)
 		if err != nil {
 			response.WriteJson(w, http.StatusInternalServerError, err)
 			return
@@ -85,8 +86,9 @@ func GetList(storage storage.Storage) http.HandlerFunc {
 
 func UpdateById(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
 		id := r.PathValue("id")
-		slog.Info("Updating student", slog.String("id", id))
+		log.Info("Updating student", slog.String("id", id))
 
 		intId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
@@ -95,26 +97,13 @@ func UpdateById(storage storage.Storage) http.HandlerFunc {
 		}
 
 		var student types.Student
-		err = json.NewDecoder(r.Body).Decode(&student)
-		if errors.Is(err, io.EOF) {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("empty body")))
-			return
-		}
-		if err != nil {
-			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
-			return
-		}
-
-		// validating request
-		if err := validator.New().Struct(student); err != nil {
-			validateErrs := err.(validator.ValidationErrors)
-			response.WriteJson(w, http.StatusBadRequest, response.ValidationError(validateErrs))
+		if !response.Decode(w, r, &student) {
 			return
 		}
 
-		err = storage.UpdateStudent(intId, student.Name, student.Email, student.Age)
+		err = storage.UpdateStudent(r.Context(), intId, student.Name, student.Email, student.Age)
 		if err != nil {
-			slog.Error("error updating student", slog.String("id", id))
+			log.Error("error updating student", slog.String("id", id), slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -125,8 +114,9 @@ func UpdateById(storage storage.Storage) http.HandlerFunc {
 
 func DeleteById(storage storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
 		id := r.PathValue("id")
-		slog.Info("Deleting student", slog.String("id", id))
+		log.Info("Deleting student", slog.String("id", id))
 
 		intId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
@@ -134,9 +124,9 @@ func DeleteById(storage storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		err = storage.DeleteStudent(intId)
+		err = storage.DeleteStudent(r.Context(), intId)
 		if err != nil {
-			slog.Error("error deleting student", slog.String("id", id))
+			log.Error("error deleting student", slog.String("id", id), slog.String("error", err.Error()))
 			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -2,16 +2,20 @@ package student
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/tukesh1/student-api/internal/storage"
 	"github.com/tukesh1/student-api/internal/types"
 )
 
-// MockStorage implements the Storage interface for testing
+// MockStorage implements the Storage interface for testing. Methods the
+// student handlers never call fall through to the nil embedded interface.
 type MockStorage struct {
+	storage.Storage
 	students map[int64]types.Student
 	nextID   int64
 }
@@ -23,7 +27,7 @@ func NewMockStorage() *MockStorage {
 	}
 }
 
-func (m *MockStorage) CreateStudent(name, email string, age int) (int64, error) {
+func (m *MockStorage) CreateStudent(ctx context.Context, name, email string, age int) (int64, error) {
 	student := types.Student{
 		Id:    m.nextID,
 		Name:  name,
@@ -35,14 +39,14 @@ func (m *MockStorage) CreateStudent(name, email string, age int) (int64, error)
 	return student.Id, nil
 }
 
-func (m *MockStorage) GetStudentById(id int64) (types.Student, error) {
+func (m *MockStorage) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
 	if student, exists := m.students[id]; exists {
 		return student, nil
 	}
 	return types.Student{}, nil
 }
 
-func (m *MockStorage) GetStudents() ([]types.Student, error) {
+func (m *MockStorage) GetStudents(ctx context.Context, filter types.StudentFilter) ([]types.Student, error) {
 	var result []types.Student
 	for _, student := range m.students {
 		result = append(result, student)
@@ -50,7 +54,7 @@ func (m *MockStorage) GetStudents() ([]types.Student, error) {
 	return result, nil
 }
 
-func (m *MockStorage) UpdateStudent(id int64, name, email string, age int) error {
+func (m *MockStorage) UpdateStudent(ctx context.Context, id int64, name, email string, age int) error {
 	if _, exists := m.students[id]; exists {
 		m.students[id] = types.Student{
 			Id:    id,
@@ -62,7 +66,7 @@ func (m *MockStorage) UpdateStudent(id int64, name, email string, age int) error
 	return nil
 }
 
-func (m *MockStorage) DeleteStudent(id int64) error {
+func (m *MockStorage) DeleteStudent(ctx context.Context, id int64) error {
 	delete(m.students, id)
 	return nil
 }
@@ -98,8 +102,8 @@ func TestCreateStudent(t *testing.T) {
 
 func TestGetStudents(t *testing.T) {
 	storage := NewMockStorage()
-	storage.CreateStudent("John Doe", "john@example.com", 25)
-	storage.CreateStudent("Jane Smith", "jane@example.com", 22)
+	storage.CreateStudent(context.Background(), "John Doe", "john@example.com", 25)
+	storage.CreateStudent(context.Background(), "Jane Smith", "jane@example.com", 22)
 
 	handler := GetList(storage)
 	req := httptest.NewRequest("GET", "/api/students", nil)
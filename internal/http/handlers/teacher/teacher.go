@@ -0,0 +1,147 @@
+package teacher
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+func New(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating teacher")
+		var teacher types.Teacher
+		if !response.Decode(w, r, &teacher) {
+			return
+		}
+
+		lastId, err := storage.CreateTeacher(r.Context(), teacher.Name, teacher.Email, teacher.Phone, teacher.EmployeeID)
+		if err != nil {
+			log.Error("error creating teacher", slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		log.Info("teacher created successfully", slog.String("teacherId", fmt.Sprint(lastId)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": lastId})
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Getting a teacher", slog.String("id", id))
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		teacher, err := storage.GetTeacherById(r.Context(), intId)
+		if err != nil {
+			log.Error("error getting teacher", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, teacher)
+	}
+}
+
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting all teachers")
+		teachers, err := storage.GetTeachers(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, teachers)
+	}
+}
+
+// GetClasses lists the classes taught by a teacher
+func GetClasses(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Getting a teacher's classes", slog.String("id", id))
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if _, err := storage.GetTeacherById(r.Context(), intId); err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		classes, err := storage.GetClasses(r.Context(), types.ClassFilter{TeacherID: intId})
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if classes == nil {
+			classes = []types.Class{}
+		}
+		response.WriteJson(w, http.StatusOK, classes)
+	}
+}
+
+func UpdateById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Updating teacher", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var teacher types.Teacher
+		if !response.Decode(w, r, &teacher) {
+			return
+		}
+
+		err = storage.UpdateTeacher(r.Context(), intId, teacher.Name, teacher.Email, teacher.Phone, teacher.EmployeeID)
+		if err != nil {
+			log.Error("error updating teacher", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, errorStatus(err), response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Teacher updated successfully"})
+	}
+}
+
+// DeleteById deletes a teacher; their classes are left without a teacher
+func DeleteById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting teacher", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := storage.DeleteTeacher(r.Context(), intId); err != nil {
+			log.Error("error deleting teacher", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Teacher deleted successfully"})
+	}
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, storage.ErrConflict) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,116 @@
+package teacher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage keeps teachers in memory with unique employee ids. Other
+// methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	teachers map[int64]types.Teacher
+	nextID   int64
+}
+
+func newMock() *mockStorage {
+	return &mockStorage{teachers: map[int64]types.Teacher{}, nextID: 1}
+}
+
+func (m *mockStorage) CreateTeacher(ctx context.Context, name, email, phone, employeeID string) (int64, error) {
+	for _, t := range m.teachers {
+		if employeeID != "" && t.EmployeeID == employeeID {
+			return 0, fmt.Errorf("employee id %s: %w", employeeID, storage.ErrConflict)
+		}
+	}
+	id := m.nextID
+	m.nextID++
+	m.teachers[id] = types.Teacher{Id: id, Name: name, Email: email, Phone: phone, EmployeeID: employeeID}
+	return id, nil
+}
+
+func (m *mockStorage) GetTeacherById(ctx context.Context, id int64) (types.Teacher, error) {
+	t, ok := m.teachers[id]
+	if !ok {
+		return types.Teacher{}, fmt.Errorf("no teacher found with id %d", id)
+	}
+	return t, nil
+}
+
+func (m *mockStorage) GetClasses(ctx context.Context, filter types.ClassFilter) ([]types.Class, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) DeleteTeacher(ctx context.Context, id int64) error {
+	delete(m.teachers, id)
+	return nil
+}
+
+func request(method, url, id, body string) *http.Request {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.SetPathValue("id", id)
+	return req
+}
+
+func TestNew(t *testing.T) {
+	m := newMock()
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"name":"Sarah Johnson","employee_id":"T-1"}`, http.StatusCreated},
+		{`{"name":"Sam Lee","employee_id":"T-1"}`, http.StatusConflict},
+		{`{"email":"nobody@example.com"}`, http.StatusBadRequest},
+		{``, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		New(m)(rr, request("POST", "/api/teachers", "", c.body))
+		if rr.Code != c.want {
+			t.Errorf("%s: expected status code %d, got %d", c.body, c.want, rr.Code)
+		}
+	}
+	if len(m.teachers) != 1 {
+		t.Errorf("Expected 1 teacher, got %+v", m.teachers)
+	}
+}
+
+func TestGetClasses(t *testing.T) {
+	m := newMock()
+	m.CreateTeacher(context.Background(), "Sarah Johnson", "", "", "")
+
+	rr := httptest.NewRecorder()
+	GetClasses(m)(rr, request("GET", "/api/teachers/1/classes", "1", ""))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("Expected an empty list, got %d %s", rr.Code, rr.Body)
+	}
+
+	rr = httptest.NewRecorder()
+	GetClasses(m)(rr, request("GET", "/api/teachers/2/classes", "2", ""))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestDeleteById(t *testing.T) {
+	m := newMock()
+	m.CreateTeacher(context.Background(), "Sarah Johnson", "", "", "")
+
+	rr := httptest.NewRecorder()
+	DeleteById(m)(rr, request("DELETE", "/api/teachers/x", "x", ""))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	DeleteById(m)(rr, request("DELETE", "/api/teachers/1", "1", ""))
+	if rr.Code != http.StatusOK || len(m.teachers) != 0 {
+		t.Errorf("Expected the teacher deleted, got %d %+v", rr.Code, m.teachers)
+	}
+}
@@ -0,0 +1,216 @@
+package timetable
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+func CreatePeriod(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating a period")
+		var period types.Period
+		if !response.Decode(w, r, &period) || !validTimes(w, period) {
+			return
+		}
+
+		id, err := storage.CreatePeriod(r.Context(), period.Name, period.StartTime, period.EndTime)
+		if err != nil {
+			log.Error("error creating period", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("period created successfully", slog.String("periodId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+// GetPeriods lists the periods of the school day in start time order
+func GetPeriods(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting periods")
+		periods, err := storage.GetPeriods(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, periods)
+	}
+}
+
+func UpdatePeriod(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Updating period", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var period types.Period
+		if !response.Decode(w, r, &period) || !validTimes(w, period) {
+			return
+		}
+
+		err = storage.UpdatePeriod(r.Context(), intId, period.Name, period.StartTime, period.EndTime)
+		if err != nil {
+			log.Error("error updating period", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Period updated successfully"})
+	}
+}
+
+// DeletePeriod deletes a period and takes it off every timetable
+func DeletePeriod(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting period", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := storage.DeletePeriod(r.Context(), intId); err != nil {
+			log.Error("error deleting period", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Period deleted successfully"})
+	}
+}
+
+func CreateSubject(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating a subject")
+		var subject types.Subject
+		if !response.Decode(w, r, &subject) {
+			return
+		}
+
+		id, err := storage.CreateSubject(r.Context(), subject.Name, subject.Code)
+		if err != nil {
+			log.Error("error creating subject", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("subject created successfully", slog.String("subjectId", fmt.Sprint(id)))
+		response.WriteJson(w, http.StatusCreated, map[string]int64{"id": id})
+	}
+}
+
+func GetSubjects(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting subjects")
+		subjects, err := storage.GetSubjects(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, subjects)
+	}
+}
+
+// DeleteSubject deletes a subject and takes it off every timetable
+func DeleteSubject(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting subject", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := storage.DeleteSubject(r.Context(), intId); err != nil {
+			log.Error("error deleting subject", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Subject deleted successfully"})
+	}
+}
+
+// GetClassTimetable lists the weekly schedule of a class
+func GetClassTimetable(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("getting class timetable", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		entries, err := storage.GetTimetable(r.Context(), intId)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, entries)
+	}
+}
+
+// SetClassTimetable replaces the weekly schedule of a class with the
+// entries in the body, a JSON array of {weekday, period_id, subject_id}
+func SetClassTimetable(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("setting class timetable", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if _, err := storage.GetClassById(r.Context(), intId); err != nil {
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+
+		var entries []types.TimetableEntry
+		if !response.Decode(w, r, &entries) {
+			return
+		}
+		for i := range entries {
+			if err := validator.New().Struct(entries[i]); err != nil {
+				validateErrs := err.(validator.ValidationErrors)
+				response.WriteJson(w, http.StatusBadRequest, response.ValidationError(validateErrs))
+				return
+			}
+		}
+
+		if err := storage.SetTimetable(r.Context(), intId, entries); err != nil {
+			log.Error("error setting timetable", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Timetable updated successfully"})
+	}
+}
+
+func validTimes(w http.ResponseWriter, period types.Period) bool {
+	// HH:MM strings compare in time order
+	if period.EndTime <= period.StartTime {
+		response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("end_time must be after start_time")))
+		return false
+	}
+	return true
+}
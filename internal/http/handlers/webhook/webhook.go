@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+	"github.com/tukesh1/student-api/internal/utils/response"
+	"github.com/tukesh1/student-api/internal/webhooks"
+)
+
+// request is the body of create and update. A blank secret is generated
+// on create and left unchanged on update; active defaults to true.
+type request struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events" validate:"required,min=1"`
+	Active *bool    `json:"active"`
+}
+
+func (req request) webhook() (types.Webhook, error) {
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return types.Webhook{}, fmt.Errorf("invalid url %q, expected http or https", req.URL)
+	}
+	for _, event := range req.Events {
+		if !webhooks.Valid(event) {
+			return types.Webhook{}, fmt.Errorf("unknown event type %q", event)
+		}
+	}
+	active := req.Active == nil || *req.Active
+	return types.Webhook{URL: req.URL, Secret: req.Secret, Events: req.Events, Active: active}, nil
+}
+
+// New subscribes a webhook. The response is the only place the secret is
+// shown.
+func New(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("creating a webhook")
+
+		var req request
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		hook, err := req.webhook()
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if hook.Secret == "" {
+			hook.Secret = webhooks.NewSecret()
+		}
+
+		id, err := storage.CreateWebhook(r.Context(), hook)
+		if err != nil {
+			log.Error("error creating webhook", slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		log.Info("webhook created", slog.Int64("id", id), slog.String("url", hook.URL))
+		created, err := storage.GetWebhookById(r.Context(), id)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		created.Secret = hook.Secret
+		response.WriteJson(w, http.StatusCreated, created)
+	}
+}
+
+func GetById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Getting a webhook", slog.String("id", id))
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		hook, err := storage.GetWebhookById(r.Context(), intId)
+		if err != nil {
+			log.Error("error getting webhook", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, hook)
+	}
+}
+
+func GetList(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("getting all webhooks")
+		hooks, err := storage.GetWebhooks(r.Context())
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, hooks)
+	}
+}
+
+// GetEvents lists the event types webhooks can subscribe to
+func GetEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteJson(w, http.StatusOK, webhooks.Events)
+	}
+}
+
+func UpdateById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Updating webhook", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		var req request
+		if !response.Decode(w, r, &req) {
+			return
+		}
+		hook, err := req.webhook()
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := storage.UpdateWebhook(r.Context(), intId, hook); err != nil {
+			log.Error("error updating webhook", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Webhook updated successfully"})
+	}
+}
+
+// DeleteById unsubscribes a webhook and drops its deliveries
+func DeleteById(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Deleting webhook", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := storage.DeleteWebhook(r.Context(), intId); err != nil {
+			log.Error("error deleting webhook", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, map[string]string{"message": "Webhook deleted successfully"})
+	}
+}
+
+// GetDeliveries lists deliveries, newest first, filtered by webhook_id and
+// status; status=dead is the dead-letter list. Under
+// /api/webhooks/{id}/deliveries the webhook comes from the path.
+func GetDeliveries(storage storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		log.Info("getting webhook deliveries")
+
+		q := r.URL.Query()
+		filter := types.WebhookDeliveryFilter{Status: q.Get("status")}
+		switch filter.Status {
+		case "", types.DeliveryPending, types.DeliveryDelivered, types.DeliveryDead:
+		default:
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid status %q, expected pending, delivered or dead", filter.Status)))
+			return
+		}
+		webhookID := r.PathValue("id")
+		if webhookID == "" {
+			webhookID = q.Get("webhook_id")
+		}
+		if webhookID != "" {
+			id, err := strconv.ParseInt(webhookID, 10, 64)
+			if err != nil {
+				response.WriteJson(w, http.StatusBadRequest, response.GeneralError(fmt.Errorf("invalid webhook id %q", webhookID)))
+				return
+			}
+			filter.WebhookID = id
+		}
+
+		deliveries, err := storage.GetWebhookDeliveries(r.Context(), filter)
+		if err != nil {
+			response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		response.WriteJson(w, http.StatusOK, deliveries)
+	}
+}
+
+// Redeliver queues a delivery again with a fresh set of attempts and has
+// the dispatcher send it straight away
+func Redeliver(storage storage.Storage, dispatcher interface{ Wake() }) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromRequest(r)
+		id := r.PathValue("id")
+		log.Info("Redelivering webhook delivery", slog.String("id", id))
+
+		intId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			response.WriteJson(w, http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := storage.RedeliverWebhook(r.Context(), intId); err != nil {
+			log.Error("error redelivering webhook", slog.String("id", id), slog.String("error", err.Error()))
+			response.WriteJson(w, http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		dispatcher.Wake()
+		response.WriteJson(w, http.StatusAccepted, map[string]string{"message": "Delivery queued"})
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+	userKey
+)
+
+// New builds the application logger. Level and format come from the log
+// section of the config; when unset they default per Env: text/debug for
+// dev and local, JSON/info everywhere else.
+func New(cfg *config.Config) *slog.Logger {
+	dev := cfg.Env == "dev" || cfg.Env == "local"
+
+	level := slog.LevelInfo
+	if dev {
+		level = slog.LevelDebug
+	}
+	if cfg.Log.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+			slog.Warn("invalid log level, using default", slog.String("level", cfg.Log.Level))
+		}
+	}
+
+	format := strings.ToLower(cfg.Log.Format)
+	if format == "" {
+		format = "json"
+		if dev {
+			format = "text"
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	return slog.New(handler).With(slog.String("env", cfg.Env))
+}
+
+// WithContext stores a request-scoped logger in the context
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the request-scoped logger, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithUser records the authenticated user for the request
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// User returns the authenticated user for the request, if any
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey).(string)
+	return user
+}
+
+// FromRequest returns the request logger annotated with the matched route
+// pattern and user. Call it inside the handler, after the mux has matched.
+func FromRequest(r *http.Request) *slog.Logger {
+	l := FromContext(r.Context())
+	if r.Pattern != "" {
+		l = l.With(slog.String("route", r.Pattern))
+	}
+	if user := User(r.Context()); user != "" {
+		l = l.With(slog.String("user", user))
+	}
+	return l
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tukesh1/student-api/internal/storage"
+)
+
+// Registry holds every metric exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route pattern and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "storage_query_duration_seconds",
+		Help:    "Latency of storage.Storage methods.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		storageDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP records one served request. Requests that matched no route are
+// grouped under "unmatched" to keep label cardinality bounded.
+func ObserveHTTP(method, route string, status int, d time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+// ObserveStorage records the latency of one storage method call
+func ObserveStorage(method string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	storageDuration.WithLabelValues(method, result).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDB exposes connection pool stats for db
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterStats exposes student, class and attendance gauges computed from
+// source at scrape time
+func RegisterStats(source storage.Storage) {
+	Registry.MustRegister(&statsCollector{source: source})
+}
+
+var (
+	studentsDesc = prometheus.NewDesc("students_total", "Number of students.", nil, nil)
+	classesDesc  = prometheus.NewDesc("classes_total", "Number of classes.", nil, nil)
+	markedDesc   = prometheus.NewDesc("attendance_marked_today_ratio", "Share of students with attendance marked today (0-1).", nil, nil)
+)
+
+type statsCollector struct {
+	source storage.Storage
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- studentsDesc
+	ch <- classesDesc
+	ch <- markedDesc
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.source.GetStats(context.Background(), time.Now())
+	if err != nil {
+		slog.Error("failed to collect stats metrics", slog.String("error", err.Error()))
+		return
+	}
+	ratio := 0.0
+	if stats.TotalStudents > 0 {
+		ratio = float64(stats.MarkedToday) / float64(stats.TotalStudents)
+	}
+	ch <- prometheus.MustNewConstMetric(studentsDesc, prometheus.GaugeValue, float64(stats.TotalStudents))
+	ch <- prometheus.MustNewConstMetric(classesDesc, prometheus.GaugeValue, float64(stats.TotalClasses))
+	ch <- prometheus.MustNewConstMetric(markedDesc, prometheus.GaugeValue, ratio)
+}
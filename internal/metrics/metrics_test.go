@@ -0,0 +1,103 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tukesh1/student-api/internal/metrics"
+	"github.com/tukesh1/student-api/internal/middleware"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage fails GetStudentById for id 0. Other methods fall through to
+// the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+}
+
+func (m *mockStorage) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	if id == 0 {
+		return types.Student{}, sql.ErrNoRows
+	}
+	return types.Student{Id: id}, nil
+}
+
+// find returns the metric of family name whose labels include want
+func find(t *testing.T, name string, want map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			for k, v := range want {
+				if labels[k] != v {
+					continue next
+				}
+			}
+			return m
+		}
+	}
+	return nil
+}
+
+func TestInstrumentStorage(t *testing.T) {
+	s := metrics.InstrumentStorage(&mockStorage{})
+	ctx := context.Background()
+
+	for _, id := range []int64{1, 2} {
+		if _, err := s.GetStudentById(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.GetStudentById(ctx, 0); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected ErrNotFound passed through, got %v", err)
+	}
+
+	ok := find(t, "storage_query_duration_seconds", map[string]string{"method": "GetStudentById", "result": "ok"})
+	if ok == nil || ok.GetHistogram().GetSampleCount() != 2 {
+		t.Errorf("Expected 2 ok observations, got %v", ok)
+	}
+	failed := find(t, "storage_query_duration_seconds", map[string]string{"method": "GetStudentById", "result": "error"})
+	if failed == nil || failed.GetHistogram().GetSampleCount() != 1 {
+		t.Errorf("Expected 1 error observation, got %v", failed)
+	}
+}
+
+func TestHTTPMetricsUseRoutePatterns(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := middleware.LoggingMiddleware(mux)
+
+	for _, path := range []string{"/api/metrics-test/1", "/api/metrics-test/2", "/nowhere/3"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	routed := find(t, "http_requests_total", map[string]string{"route": "GET /api/metrics-test/{id}", "status": "200"})
+	if routed == nil || routed.GetCounter().GetValue() != 2 {
+		t.Errorf("Expected 2 requests under the route pattern, got %v", routed)
+	}
+	if m := find(t, "http_requests_total", map[string]string{"route": "unmatched", "status": "404"}); m == nil {
+		t.Error("Expected unmatched requests grouped under one label")
+	}
+	for _, path := range []string{"/api/metrics-test/1", "/nowhere/3"} {
+		if m := find(t, "http_requests_total", map[string]string{"route": path}); m != nil {
+			t.Errorf("raw path %s should not be used as a label", path)
+		}
+	}
+}
@@ -0,0 +1,627 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// instrumentedStorage records the latency of every storage.Storage call
+type instrumentedStorage struct {
+	next storage.Storage
+}
+
+// InstrumentStorage wraps s so each method call is observed in
+// storage_query_duration_seconds
+func InstrumentStorage(s storage.Storage) storage.Storage {
+	return &instrumentedStorage{next: s}
+}
+
+func observe(method string, start time.Time, err *error) {
+	ObserveStorage(method, start, *err)
+}
+
+// Student methods
+func (s *instrumentedStorage) CreateStudent(ctx context.Context, name string, email string, age int) (result int64, err error) {
+	defer observe("CreateStudent", time.Now(), &err)
+	return s.next.CreateStudent(ctx, name, email, age)
+}
+
+func (s *instrumentedStorage) GetStudentById(ctx context.Context, id int64) (result types.Student, err error) {
+	defer observe("GetStudentById", time.Now(), &err)
+	return s.next.GetStudentById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetStudents(ctx context.Context, filter types.StudentFilter) (result []types.Student, err error) {
+	defer observe("GetStudents", time.Now(), &err)
+	return s.next.GetStudents(ctx, filter)
+}
+
+func (s *instrumentedStorage) StreamStudents(ctx context.Context, filter types.StudentFilter, fn func(types.Student) error) (err error) {
+	defer observe("StreamStudents", time.Now(), &err)
+	return s.next.StreamStudents(ctx, filter, fn)
+}
+
+func (s *instrumentedStorage) UpdateStudent(ctx context.Context, id int64, name string, email string, age int) (err error) {
+	defer observe("UpdateStudent", time.Now(), &err)
+	return s.next.UpdateStudent(ctx, id, name, email, age)
+}
+
+func (s *instrumentedStorage) DeleteStudent(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteStudent", time.Now(), &err)
+	return s.next.DeleteStudent(ctx, id)
+}
+
+func (s *instrumentedStorage) SetStudentPhoto(ctx context.Context, id int64, photoURL string) (err error) {
+	defer observe("SetStudentPhoto", time.Now(), &err)
+	return s.next.SetStudentPhoto(ctx, id, photoURL)
+}
+
+func (s *instrumentedStorage) ImportStudents(ctx context.Context, students []types.Student) (result []int64, err error) {
+	defer observe("ImportStudents", time.Now(), &err)
+	return s.next.ImportStudents(ctx, students)
+}
+
+// Class methods
+func (s *instrumentedStorage) CreateClass(ctx context.Context, name, grade, section string, teacherID int64, capacity int) (result int64, err error) {
+	defer observe("CreateClass", time.Now(), &err)
+	return s.next.CreateClass(ctx, name, grade, section, teacherID, capacity)
+}
+
+func (s *instrumentedStorage) GetClassById(ctx context.Context, id int64) (result types.Class, err error) {
+	defer observe("GetClassById", time.Now(), &err)
+	return s.next.GetClassById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetClasses(ctx context.Context, filter types.ClassFilter) (result []types.Class, err error) {
+	defer observe("GetClasses", time.Now(), &err)
+	return s.next.GetClasses(ctx, filter)
+}
+
+func (s *instrumentedStorage) StreamClasses(ctx context.Context, filter types.ClassFilter, fn func(types.Class) error) (err error) {
+	defer observe("StreamClasses", time.Now(), &err)
+	return s.next.StreamClasses(ctx, filter, fn)
+}
+
+func (s *instrumentedStorage) UpdateClass(ctx context.Context, id int64, name, grade, section string, teacherID int64, capacity int) (err error) {
+	defer observe("UpdateClass", time.Now(), &err)
+	return s.next.UpdateClass(ctx, id, name, grade, section, teacherID, capacity)
+}
+
+func (s *instrumentedStorage) DeleteClass(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteClass", time.Now(), &err)
+	return s.next.DeleteClass(ctx, id)
+}
+
+func (s *instrumentedStorage) SetClassGrace(ctx context.Context, id int64, lateMinutes, earlyLeaveMinutes *int) (err error) {
+	defer observe("SetClassGrace", time.Now(), &err)
+	return s.next.SetClassGrace(ctx, id, lateMinutes, earlyLeaveMinutes)
+}
+
+func (s *instrumentedStorage) ImportClasses(ctx context.Context, classes []types.Class) (result []int64, err error) {
+	defer observe("ImportClasses", time.Now(), &err)
+	return s.next.ImportClasses(ctx, classes)
+}
+
+// Teacher methods
+func (s *instrumentedStorage) CreateTeacher(ctx context.Context, name, email, phone, employeeID string) (result int64, err error) {
+	defer observe("CreateTeacher", time.Now(), &err)
+	return s.next.CreateTeacher(ctx, name, email, phone, employeeID)
+}
+
+func (s *instrumentedStorage) GetTeacherById(ctx context.Context, id int64) (result types.Teacher, err error) {
+	defer observe("GetTeacherById", time.Now(), &err)
+	return s.next.GetTeacherById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetTeachers(ctx context.Context) (result []types.Teacher, err error) {
+	defer observe("GetTeachers", time.Now(), &err)
+	return s.next.GetTeachers(ctx)
+}
+
+func (s *instrumentedStorage) UpdateTeacher(ctx context.Context, id int64, name, email, phone, employeeID string) (err error) {
+	defer observe("UpdateTeacher", time.Now(), &err)
+	return s.next.UpdateTeacher(ctx, id, name, email, phone, employeeID)
+}
+
+func (s *instrumentedStorage) DeleteTeacher(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteTeacher", time.Now(), &err)
+	return s.next.DeleteTeacher(ctx, id)
+}
+
+func (s *instrumentedStorage) ResolveTeacher(ctx context.Context, name string) (result int64, err error) {
+	defer observe("ResolveTeacher", time.Now(), &err)
+	return s.next.ResolveTeacher(ctx, name)
+}
+
+func (s *instrumentedStorage) TeachesStudent(ctx context.Context, teacherID, studentID int64) (result bool, err error) {
+	defer observe("TeachesStudent", time.Now(), &err)
+	return s.next.TeachesStudent(ctx, teacherID, studentID)
+}
+
+// Attendance methods
+func (s *instrumentedStorage) CreateAttendanceRecord(ctx context.Context, studentID, classID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (result int64, err error) {
+	defer observe("CreateAttendanceRecord", time.Now(), &err)
+	return s.next.CreateAttendanceRecord(ctx, studentID, classID, date, status, remarks, times)
+}
+
+func (s *instrumentedStorage) GetAttendanceByDate(ctx context.Context, classID int64, date time.Time) (result []types.AttendanceRecord, err error) {
+	defer observe("GetAttendanceByDate", time.Now(), &err)
+	return s.next.GetAttendanceByDate(ctx, classID, date)
+}
+
+func (s *instrumentedStorage) GetAttendanceByStudent(ctx context.Context, studentID int64, startDate, endDate time.Time) (result []types.AttendanceRecord, err error) {
+	defer observe("GetAttendanceByStudent", time.Now(), &err)
+	return s.next.GetAttendanceByStudent(ctx, studentID, startDate, endDate)
+}
+
+func (s *instrumentedStorage) GetAttendance(ctx context.Context, filter types.AttendanceFilter) (result []types.AttendanceRecord, err error) {
+	defer observe("GetAttendance", time.Now(), &err)
+	return s.next.GetAttendance(ctx, filter)
+}
+
+func (s *instrumentedStorage) StreamAttendance(ctx context.Context, filter types.AttendanceFilter, fn func(types.AttendanceRecord) error) (err error) {
+	defer observe("StreamAttendance", time.Now(), &err)
+	return s.next.StreamAttendance(ctx, filter, fn)
+}
+
+func (s *instrumentedStorage) CreatePeriodAttendance(ctx context.Context, studentID, classID, periodID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (result int64, err error) {
+	defer observe("CreatePeriodAttendance", time.Now(), &err)
+	return s.next.CreatePeriodAttendance(ctx, studentID, classID, periodID, date, status, remarks, times)
+}
+
+func (s *instrumentedStorage) GetAttendanceById(ctx context.Context, id int64) (result types.AttendanceRecord, err error) {
+	defer observe("GetAttendanceById", time.Now(), &err)
+	return s.next.GetAttendanceById(ctx, id)
+}
+
+func (s *instrumentedStorage) UpdateAttendanceRecord(ctx context.Context, id int64, status, remarks string, times types.AttendanceTimes) (err error) {
+	defer observe("UpdateAttendanceRecord", time.Now(), &err)
+	return s.next.UpdateAttendanceRecord(ctx, id, status, remarks, times)
+}
+
+func (s *instrumentedStorage) DeleteAttendanceRecord(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteAttendanceRecord", time.Now(), &err)
+	return s.next.DeleteAttendanceRecord(ctx, id)
+}
+
+func (s *instrumentedStorage) GetAttendanceReport(ctx context.Context, studentID int64, startDate, endDate time.Time) (result types.AttendanceReport, err error) {
+	defer observe("GetAttendanceReport", time.Now(), &err)
+	return s.next.GetAttendanceReport(ctx, studentID, startDate, endDate)
+}
+
+// Analytics methods
+func (s *instrumentedStorage) GetAttendanceAnalytics(ctx context.Context, query types.AnalyticsQuery) (result []types.AttendanceStats, err error) {
+	defer observe("GetAttendanceAnalytics", time.Now(), &err)
+	return s.next.GetAttendanceAnalytics(ctx, query)
+}
+
+// Timetable methods
+func (s *instrumentedStorage) CreatePeriod(ctx context.Context, name, startTime, endTime string) (result int64, err error) {
+	defer observe("CreatePeriod", time.Now(), &err)
+	return s.next.CreatePeriod(ctx, name, startTime, endTime)
+}
+
+func (s *instrumentedStorage) GetPeriodById(ctx context.Context, id int64) (result types.Period, err error) {
+	defer observe("GetPeriodById", time.Now(), &err)
+	return s.next.GetPeriodById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetPeriods(ctx context.Context) (result []types.Period, err error) {
+	defer observe("GetPeriods", time.Now(), &err)
+	return s.next.GetPeriods(ctx)
+}
+
+func (s *instrumentedStorage) UpdatePeriod(ctx context.Context, id int64, name, startTime, endTime string) (err error) {
+	defer observe("UpdatePeriod", time.Now(), &err)
+	return s.next.UpdatePeriod(ctx, id, name, startTime, endTime)
+}
+
+func (s *instrumentedStorage) DeletePeriod(ctx context.Context, id int64) (err error) {
+	defer observe("DeletePeriod", time.Now(), &err)
+	return s.next.DeletePeriod(ctx, id)
+}
+
+func (s *instrumentedStorage) CreateSubject(ctx context.Context, name, code string) (result int64, err error) {
+	defer observe("CreateSubject", time.Now(), &err)
+	return s.next.CreateSubject(ctx, name, code)
+}
+
+func (s *instrumentedStorage) GetSubjects(ctx context.Context) (result []types.Subject, err error) {
+	defer observe("GetSubjects", time.Now(), &err)
+	return s.next.GetSubjects(ctx)
+}
+
+func (s *instrumentedStorage) DeleteSubject(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteSubject", time.Now(), &err)
+	return s.next.DeleteSubject(ctx, id)
+}
+
+func (s *instrumentedStorage) GetTimetable(ctx context.Context, classID int64) (result []types.TimetableEntry, err error) {
+	defer observe("GetTimetable", time.Now(), &err)
+	return s.next.GetTimetable(ctx, classID)
+}
+
+func (s *instrumentedStorage) SetTimetable(ctx context.Context, classID int64, entries []types.TimetableEntry) (err error) {
+	defer observe("SetTimetable", time.Now(), &err)
+	return s.next.SetTimetable(ctx, classID, entries)
+}
+
+// Calendar methods
+func (s *instrumentedStorage) CreateAcademicYear(ctx context.Context, name string, startDate, endDate time.Time) (result int64, err error) {
+	defer observe("CreateAcademicYear", time.Now(), &err)
+	return s.next.CreateAcademicYear(ctx, name, startDate, endDate)
+}
+
+func (s *instrumentedStorage) GetAcademicYearById(ctx context.Context, id int64) (result types.AcademicYear, err error) {
+	defer observe("GetAcademicYearById", time.Now(), &err)
+	return s.next.GetAcademicYearById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetAcademicYears(ctx context.Context) (result []types.AcademicYear, err error) {
+	defer observe("GetAcademicYears", time.Now(), &err)
+	return s.next.GetAcademicYears(ctx)
+}
+
+func (s *instrumentedStorage) DeleteAcademicYear(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteAcademicYear", time.Now(), &err)
+	return s.next.DeleteAcademicYear(ctx, id)
+}
+
+func (s *instrumentedStorage) CreateTerm(ctx context.Context, academicYearID int64, name string, startDate, endDate time.Time) (result int64, err error) {
+	defer observe("CreateTerm", time.Now(), &err)
+	return s.next.CreateTerm(ctx, academicYearID, name, startDate, endDate)
+}
+
+func (s *instrumentedStorage) GetTerms(ctx context.Context, academicYearID int64) (result []types.Term, err error) {
+	defer observe("GetTerms", time.Now(), &err)
+	return s.next.GetTerms(ctx, academicYearID)
+}
+
+func (s *instrumentedStorage) DeleteTerm(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteTerm", time.Now(), &err)
+	return s.next.DeleteTerm(ctx, id)
+}
+
+func (s *instrumentedStorage) CreateClosure(ctx context.Context, name, kind string, startDate, endDate time.Time) (result int64, err error) {
+	defer observe("CreateClosure", time.Now(), &err)
+	return s.next.CreateClosure(ctx, name, kind, startDate, endDate)
+}
+
+func (s *instrumentedStorage) GetClosures(ctx context.Context, from, to time.Time) (result []types.Closure, err error) {
+	defer observe("GetClosures", time.Now(), &err)
+	return s.next.GetClosures(ctx, from, to)
+}
+
+func (s *instrumentedStorage) DeleteClosure(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteClosure", time.Now(), &err)
+	return s.next.DeleteClosure(ctx, id)
+}
+
+func (s *instrumentedStorage) ImportClosures(ctx context.Context, closures []types.Closure) (result []int64, err error) {
+	defer observe("ImportClosures", time.Now(), &err)
+	return s.next.ImportClosures(ctx, closures)
+}
+
+func (s *instrumentedStorage) GetCalendarDays(ctx context.Context, from, to time.Time) (result []types.CalendarDay, err error) {
+	defer observe("GetCalendarDays", time.Now(), &err)
+	return s.next.GetCalendarDays(ctx, from, to)
+}
+
+// Guardian methods
+func (s *instrumentedStorage) CreateGuardian(ctx context.Context, guardian types.Guardian) (result int64, err error) {
+	defer observe("CreateGuardian", time.Now(), &err)
+	return s.next.CreateGuardian(ctx, guardian)
+}
+
+func (s *instrumentedStorage) GetGuardianById(ctx context.Context, id int64) (result types.Guardian, err error) {
+	defer observe("GetGuardianById", time.Now(), &err)
+	return s.next.GetGuardianById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetGuardians(ctx context.Context) (result []types.Guardian, err error) {
+	defer observe("GetGuardians", time.Now(), &err)
+	return s.next.GetGuardians(ctx)
+}
+
+func (s *instrumentedStorage) UpdateGuardian(ctx context.Context, id int64, guardian types.Guardian) (err error) {
+	defer observe("UpdateGuardian", time.Now(), &err)
+	return s.next.UpdateGuardian(ctx, id, guardian)
+}
+
+func (s *instrumentedStorage) DeleteGuardian(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteGuardian", time.Now(), &err)
+	return s.next.DeleteGuardian(ctx, id)
+}
+
+func (s *instrumentedStorage) GetStudentGuardians(ctx context.Context, studentID int64) (result []types.StudentGuardian, err error) {
+	defer observe("GetStudentGuardians", time.Now(), &err)
+	return s.next.GetStudentGuardians(ctx, studentID)
+}
+
+func (s *instrumentedStorage) LinkGuardian(ctx context.Context, studentID, guardianID int64, relationship string, primary bool) (err error) {
+	defer observe("LinkGuardian", time.Now(), &err)
+	return s.next.LinkGuardian(ctx, studentID, guardianID, relationship, primary)
+}
+
+func (s *instrumentedStorage) UnlinkGuardian(ctx context.Context, studentID, guardianID int64) (err error) {
+	defer observe("UnlinkGuardian", time.Now(), &err)
+	return s.next.UnlinkGuardian(ctx, studentID, guardianID)
+}
+
+func (s *instrumentedStorage) GetGuardianStudents(ctx context.Context, guardianID int64) (result []types.Student, err error) {
+	defer observe("GetGuardianStudents", time.Now(), &err)
+	return s.next.GetGuardianStudents(ctx, guardianID)
+}
+
+func (s *instrumentedStorage) SetGuardianPassword(ctx context.Context, id int64, passwordHash string) (err error) {
+	defer observe("SetGuardianPassword", time.Now(), &err)
+	return s.next.SetGuardianPassword(ctx, id, passwordHash)
+}
+
+func (s *instrumentedStorage) GetGuardianLogin(ctx context.Context, email string) (result int64, result2 string, err error) {
+	defer observe("GetGuardianLogin", time.Now(), &err)
+	return s.next.GetGuardianLogin(ctx, email)
+}
+
+func (s *instrumentedStorage) CreateGuardianSession(ctx context.Context, guardianID int64, tokenHash string, expiresAt time.Time) (err error) {
+	defer observe("CreateGuardianSession", time.Now(), &err)
+	return s.next.CreateGuardianSession(ctx, guardianID, tokenHash, expiresAt)
+}
+
+func (s *instrumentedStorage) GetGuardianSession(ctx context.Context, tokenHash string) (result int64, err error) {
+	defer observe("GetGuardianSession", time.Now(), &err)
+	return s.next.GetGuardianSession(ctx, tokenHash)
+}
+
+func (s *instrumentedStorage) DeleteGuardianSession(ctx context.Context, tokenHash string) (err error) {
+	defer observe("DeleteGuardianSession", time.Now(), &err)
+	return s.next.DeleteGuardianSession(ctx, tokenHash)
+}
+
+// Notification methods
+func (s *instrumentedStorage) QueueNotification(ctx context.Context, studentID int64, date time.Time) (result bool, err error) {
+	defer observe("QueueNotification", time.Now(), &err)
+	return s.next.QueueNotification(ctx, studentID, date)
+}
+
+func (s *instrumentedStorage) CancelNotification(ctx context.Context, studentID int64, date time.Time) (err error) {
+	defer observe("CancelNotification", time.Now(), &err)
+	return s.next.CancelNotification(ctx, studentID, date)
+}
+
+func (s *instrumentedStorage) GetPendingNotifications(ctx context.Context, limit int) (result []types.Notification, err error) {
+	defer observe("GetPendingNotifications", time.Now(), &err)
+	return s.next.GetPendingNotifications(ctx, limit)
+}
+
+func (s *instrumentedStorage) UpdateNotification(ctx context.Context, notification types.Notification) (err error) {
+	defer observe("UpdateNotification", time.Now(), &err)
+	return s.next.UpdateNotification(ctx, notification)
+}
+
+func (s *instrumentedStorage) GetNotifications(ctx context.Context, filter types.NotificationFilter) (result []types.Notification, err error) {
+	defer observe("GetNotifications", time.Now(), &err)
+	return s.next.GetNotifications(ctx, filter)
+}
+
+func (s *instrumentedStorage) GetNotificationTemplates(ctx context.Context) (result []types.NotificationTemplate, err error) {
+	defer observe("GetNotificationTemplates", time.Now(), &err)
+	return s.next.GetNotificationTemplates(ctx)
+}
+
+func (s *instrumentedStorage) SaveNotificationTemplate(ctx context.Context, template types.NotificationTemplate) (err error) {
+	defer observe("SaveNotificationTemplate", time.Now(), &err)
+	return s.next.SaveNotificationTemplate(ctx, template)
+}
+
+// Outbox methods
+func (s *instrumentedStorage) GetUnpublishedEvents(ctx context.Context, limit int) (result []types.Event, err error) {
+	defer observe("GetUnpublishedEvents", time.Now(), &err)
+	return s.next.GetUnpublishedEvents(ctx, limit)
+}
+
+func (s *instrumentedStorage) MarkEventsPublished(ctx context.Context, throughSeq int64) (err error) {
+	defer observe("MarkEventsPublished", time.Now(), &err)
+	return s.next.MarkEventsPublished(ctx, throughSeq)
+}
+
+func (s *instrumentedStorage) GetEvents(ctx context.Context, since int64, limit int) (result []types.Event, err error) {
+	defer observe("GetEvents", time.Now(), &err)
+	return s.next.GetEvents(ctx, since, limit)
+}
+
+func (s *instrumentedStorage) GetEventSeqRange(ctx context.Context) (result int64, result2 int64, err error) {
+	defer observe("GetEventSeqRange", time.Now(), &err)
+	return s.next.GetEventSeqRange(ctx)
+}
+
+func (s *instrumentedStorage) PruneEvents(ctx context.Context, before time.Time) (result int64, err error) {
+	defer observe("PruneEvents", time.Now(), &err)
+	return s.next.PruneEvents(ctx, before)
+}
+
+// Sync methods
+func (s *instrumentedStorage) SyncAttendance(ctx context.Context, mutation types.SyncMutation) (result types.SyncResult, err error) {
+	defer observe("SyncAttendance", time.Now(), &err)
+	return s.next.SyncAttendance(ctx, mutation)
+}
+
+// Check-in methods
+func (s *instrumentedStorage) CheckIn(ctx context.Context, checkIn types.CheckIn) (result int64, err error) {
+	defer observe("CheckIn", time.Now(), &err)
+	return s.next.CheckIn(ctx, checkIn)
+}
+
+func (s *instrumentedStorage) GetCheckInById(ctx context.Context, id int64) (result types.CheckIn, err error) {
+	defer observe("GetCheckInById", time.Now(), &err)
+	return s.next.GetCheckInById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetCheckIns(ctx context.Context, filter types.CheckInFilter) (result []types.CheckIn, err error) {
+	defer observe("GetCheckIns", time.Now(), &err)
+	return s.next.GetCheckIns(ctx, filter)
+}
+
+func (s *instrumentedStorage) OverrideCheckIn(ctx context.Context, id int64, status, remarks string) (err error) {
+	defer observe("OverrideCheckIn", time.Now(), &err)
+	return s.next.OverrideCheckIn(ctx, id, status, remarks)
+}
+
+func (s *instrumentedStorage) GetBadgeVersion(ctx context.Context, studentID int64) (result int64, err error) {
+	defer observe("GetBadgeVersion", time.Now(), &err)
+	return s.next.GetBadgeVersion(ctx, studentID)
+}
+
+func (s *instrumentedStorage) RevokeBadge(ctx context.Context, studentID int64) (result int64, err error) {
+	defer observe("RevokeBadge", time.Now(), &err)
+	return s.next.RevokeBadge(ctx, studentID)
+}
+
+// Leave methods
+func (s *instrumentedStorage) CreateLeaveRequest(ctx context.Context, leave types.LeaveRequest) (result int64, err error) {
+	defer observe("CreateLeaveRequest", time.Now(), &err)
+	return s.next.CreateLeaveRequest(ctx, leave)
+}
+
+func (s *instrumentedStorage) GetLeaveRequestById(ctx context.Context, id int64) (result types.LeaveRequest, err error) {
+	defer observe("GetLeaveRequestById", time.Now(), &err)
+	return s.next.GetLeaveRequestById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetLeaveRequests(ctx context.Context, filter types.LeaveFilter) (result []types.LeaveRequest, err error) {
+	defer observe("GetLeaveRequests", time.Now(), &err)
+	return s.next.GetLeaveRequests(ctx, filter)
+}
+
+func (s *instrumentedStorage) ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (result int, err error) {
+	defer observe("ReviewLeaveRequest", time.Now(), &err)
+	return s.next.ReviewLeaveRequest(ctx, id, status, reviewedBy, note)
+}
+
+// Attachment methods
+func (s *instrumentedStorage) CreateAttachment(ctx context.Context, attachment types.Attachment) (result int64, err error) {
+	defer observe("CreateAttachment", time.Now(), &err)
+	return s.next.CreateAttachment(ctx, attachment)
+}
+
+func (s *instrumentedStorage) GetAttachmentById(ctx context.Context, id int64) (result types.Attachment, err error) {
+	defer observe("GetAttachmentById", time.Now(), &err)
+	return s.next.GetAttachmentById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetAttachments(ctx context.Context, ownerType string, ownerID int64) (result []types.Attachment, err error) {
+	defer observe("GetAttachments", time.Now(), &err)
+	return s.next.GetAttachments(ctx, ownerType, ownerID)
+}
+
+func (s *instrumentedStorage) DeleteAttachment(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteAttachment", time.Now(), &err)
+	return s.next.DeleteAttachment(ctx, id)
+}
+
+// Webhook methods
+func (s *instrumentedStorage) CreateWebhook(ctx context.Context, hook types.Webhook) (result int64, err error) {
+	defer observe("CreateWebhook", time.Now(), &err)
+	return s.next.CreateWebhook(ctx, hook)
+}
+
+func (s *instrumentedStorage) GetWebhookById(ctx context.Context, id int64) (result types.Webhook, err error) {
+	defer observe("GetWebhookById", time.Now(), &err)
+	return s.next.GetWebhookById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetWebhooks(ctx context.Context) (result []types.Webhook, err error) {
+	defer observe("GetWebhooks", time.Now(), &err)
+	return s.next.GetWebhooks(ctx)
+}
+
+func (s *instrumentedStorage) UpdateWebhook(ctx context.Context, id int64, hook types.Webhook) (err error) {
+	defer observe("UpdateWebhook", time.Now(), &err)
+	return s.next.UpdateWebhook(ctx, id, hook)
+}
+
+func (s *instrumentedStorage) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	defer observe("DeleteWebhook", time.Now(), &err)
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *instrumentedStorage) EnqueueWebhookEvent(ctx context.Context, eventID, eventType, payload string) (result int, err error) {
+	defer observe("EnqueueWebhookEvent", time.Now(), &err)
+	return s.next.EnqueueWebhookEvent(ctx, eventID, eventType, payload)
+}
+
+func (s *instrumentedStorage) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) (result []types.WebhookDelivery, err error) {
+	defer observe("GetDueWebhookDeliveries", time.Now(), &err)
+	return s.next.GetDueWebhookDeliveries(ctx, now, limit)
+}
+
+func (s *instrumentedStorage) UpdateWebhookDelivery(ctx context.Context, delivery types.WebhookDelivery) (err error) {
+	defer observe("UpdateWebhookDelivery", time.Now(), &err)
+	return s.next.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (s *instrumentedStorage) GetWebhookDeliveries(ctx context.Context, filter types.WebhookDeliveryFilter) (result []types.WebhookDelivery, err error) {
+	defer observe("GetWebhookDeliveries", time.Now(), &err)
+	return s.next.GetWebhookDeliveries(ctx, filter)
+}
+
+func (s *instrumentedStorage) RedeliverWebhook(ctx context.Context, deliveryID int64) (err error) {
+	defer observe("RedeliverWebhook", time.Now(), &err)
+	return s.next.RedeliverWebhook(ctx, deliveryID)
+}
+
+// Enrollment methods
+func (s *instrumentedStorage) CreateEnrollment(ctx context.Context, studentID, classID, subjectID int64, startDate, endDate time.Time) (result int64, err error) {
+	defer observe("CreateEnrollment", time.Now(), &err)
+	return s.next.CreateEnrollment(ctx, studentID, classID, subjectID, startDate, endDate)
+}
+
+func (s *instrumentedStorage) GetEnrollmentById(ctx context.Context, id int64) (result types.Enrollment, err error) {
+	defer observe("GetEnrollmentById", time.Now(), &err)
+	return s.next.GetEnrollmentById(ctx, id)
+}
+
+func (s *instrumentedStorage) GetEnrollments(ctx context.Context, filter types.EnrollmentFilter) (result []types.Enrollment, err error) {
+	defer observe("GetEnrollments", time.Now(), &err)
+	return s.next.GetEnrollments(ctx, filter)
+}
+
+func (s *instrumentedStorage) UpdateEnrollment(ctx context.Context, id int64, status string, endDate time.Time) (err error) {
+	defer observe("UpdateEnrollment", time.Now(), &err)
+	return s.next.UpdateEnrollment(ctx, id, status, endDate)
+}
+
+// Alert methods
+func (s *instrumentedStorage) GetAlerts(ctx context.Context, filter types.AlertFilter) (result []types.Alert, err error) {
+	defer observe("GetAlerts", time.Now(), &err)
+	return s.next.GetAlerts(ctx, filter)
+}
+
+func (s *instrumentedStorage) GetAlertById(ctx context.Context, id int64) (result types.Alert, err error) {
+	defer observe("GetAlertById", time.Now(), &err)
+	return s.next.GetAlertById(ctx, id)
+}
+
+func (s *instrumentedStorage) RaiseAlert(ctx context.Context, alert types.Alert) (result int64, err error) {
+	defer observe("RaiseAlert", time.Now(), &err)
+	return s.next.RaiseAlert(ctx, alert)
+}
+
+func (s *instrumentedStorage) ResolveAlerts(ctx context.Context, studentID int64, rule, note string) (err error) {
+	defer observe("ResolveAlerts", time.Now(), &err)
+	return s.next.ResolveAlerts(ctx, studentID, rule, note)
+}
+
+func (s *instrumentedStorage) UpdateAlertStatus(ctx context.Context, id int64, status, note string) (err error) {
+	defer observe("UpdateAlertStatus", time.Now(), &err)
+	return s.next.UpdateAlertStatus(ctx, id, status, note)
+}
+
+// Stats methods
+func (s *instrumentedStorage) GetStats(ctx context.Context, date time.Time) (result types.Stats, err error) {
+	defer observe("GetStats", time.Now(), &err)
+	return s.next.GetStats(ctx, date)
+}
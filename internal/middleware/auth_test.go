@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+func TestRequire(t *testing.T) {
+	keys := NewAPIKeys([]config.APIKey{{Name: "office", Key: "secret"}, {Name: "unset"}, {Name: "ms-lee", Key: "lee", TeacherID: 4}})
+	var staff string
+	var teacher int64
+	handler := keys.Require(func(w http.ResponseWriter, r *http.Request) {
+		staff, _ = StaffFromContext(r.Context())
+		teacher = TeacherFromContext(r.Context())
+	})
+
+	for key, want := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "secret": http.StatusOK} {
+		staff, teacher = "", -1
+		req := httptest.NewRequest("PUT", "/api/guardians/1/password", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != want {
+			t.Errorf("key %q: expected status code %d, got %d", key, want, rr.Code)
+		}
+		if want == http.StatusOK && (staff != "office" || teacher != 0) {
+			t.Errorf("key %q: expected staff office on the context, got %q teacher %d", key, staff, teacher)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/attachments/1", nil)
+	req.Header.Set("X-API-Key", "lee")
+	handler(httptest.NewRecorder(), req)
+	if staff != "ms-lee" || teacher != 4 {
+		t.Errorf("expected teacher 4 on the context, got %q teacher %d", staff, teacher)
+	}
+}
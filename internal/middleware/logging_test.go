@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/logger"
+)
+
+func TestRequestIDPropagatesAndTagsLogs(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/students/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("in handler")
+	})
+	handler := RequestID(LoggingMiddleware(mux))
+
+	req := httptest.NewRequest("GET", "/api/students/7", nil)
+	req.Header.Set(RequestIDHeader, "abc123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(RequestIDHeader); got != "abc123" {
+		t.Errorf("Expected request ID abc123, got %q", got)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "request_id=abc123") || !strings.Contains(line, `route="GET /api/students/{id}"`) {
+			t.Errorf("log line missing request context: %s", line)
+		}
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	if len(rr.Header().Get(RequestIDHeader)) != 32 {
+		t.Errorf("Expected generated request ID, got %q", rr.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestIdentifyTagsStaffLogs(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	keys := NewAPIKeys([]config.APIKey{{Name: "office", Key: "secret"}})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/students", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromRequest(r).Info("in handler")
+	})
+	handler := keys.Identify(LoggingMiddleware(mux))
+
+	for _, key := range []string{"secret", "wrong"} {
+		buf.Reset()
+		req := httptest.NewRequest("GET", "/api/students", nil)
+		req.Header.Set("X-API-Key", key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		tagged := strings.Count(buf.String(), "user=staff:office")
+		if key == "secret" && tagged != 2 {
+			t.Errorf("Expected both log lines tagged with the staff user: %s", buf.String())
+		}
+		if key == "wrong" && strings.Contains(buf.String(), "user=") {
+			t.Errorf("invalid key should not tag logs: %s", buf.String())
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/utils/response"
+)
+
+// LimitResult describes the outcome of a single rate limit check
+type LimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next request is allowed, zero when allowed
+}
+
+// Limiter decides whether a client identified by key may make another request.
+// The in-memory implementation is used by default; a shared store (e.g. Redis)
+// can be plugged in by implementing this interface.
+type Limiter interface {
+	Allow(key string) LimitResult
+}
+
+// KeyFunc extracts the rate limit key for a request
+type KeyFunc func(r *http.Request) string
+
+// MemoryLimiter is a token bucket limiter that keeps one bucket per key in memory
+type MemoryLimiter struct {
+	rate  float64 // tokens added per second
+	burst int     // bucket capacity
+
+	mu         sync.Mutex
+	buckets    map[string]*list.Element // of *bucket, in lru
+	lru        *list.List               // most recently used first
+	maxBuckets int
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+const (
+	// idle buckets are dropped after this long so the map does not grow forever
+	bucketIdleTTL = 10 * time.Minute
+	// maxBuckets caps the map between sweeps; at the cap the least recently
+	// used bucket makes room for a new one
+	maxBuckets = 100_000
+)
+
+func NewMemoryLimiter(ratePerSecond float64, burst int) *MemoryLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &MemoryLimiter{
+		rate:       ratePerSecond,
+		burst:      burst,
+		buckets:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxBuckets: maxBuckets,
+		now:        time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(key string) LimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var b *bucket
+	if e, ok := l.buckets[key]; !ok {
+		if len(l.buckets) >= l.maxBuckets {
+			l.evict()
+		}
+		b = &bucket{key: key, tokens: float64(l.burst), last: now}
+		l.buckets[key] = l.lru.PushFront(b)
+	} else {
+		l.lru.MoveToFront(e)
+		b = e.Value.(*bucket)
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(float64(l.burst), b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+
+	result := LimitResult{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else if l.rate > 0 {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	} else {
+		result.RetryAfter = time.Hour
+	}
+	result.Remaining = int(b.tokens)
+	if l.rate > 0 {
+		result.Reset = l.duration(float64(l.burst) - b.tokens)
+	}
+	return result
+}
+
+func (l *MemoryLimiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for e := l.lru.Back(); e != nil && now.Sub(e.Value.(*bucket).last) > bucketIdleTTL; e = l.lru.Back() {
+		l.evict()
+	}
+}
+
+// evict drops the least recently used bucket
+func (l *MemoryLimiter) evict() {
+	if e := l.lru.Back(); e != nil {
+		l.lru.Remove(e)
+		delete(l.buckets, e.Value.(*bucket).key)
+	}
+}
+
+// ClientKey identifies the caller by IP. APIKeys.ClientKey tells apart
+// callers with a valid API key.
+func ClientKey(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// ClientIP returns the remote IP of the request without the port
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimit rejects requests with 429 once the client's bucket is empty and
+// sets the RateLimit-* headers on every response.
+func RateLimit(limiter Limiter, keyFunc KeyFunc) func(http.Handler) http.Handler {
+	if keyFunc == nil {
+		keyFunc = ClientKey
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := limiter.Allow(keyFunc(r))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				response.WriteJson(w, http.StatusTooManyRequests, response.Response{
+					Status: response.StatusError,
+					Error:  "rate limit exceeded",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBodySize caps the request body; reads past the limit fail with *http.MaxBytesError
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+func TestMemoryLimiterRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewMemoryLimiter(1, 2)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if res := limiter.Allow("a"); !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	res := limiter.Allow("a")
+	if res.Allowed {
+		t.Fatal("third request should be limited")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("Expected retry after 1s, got %s", res.RetryAfter)
+	}
+	if other := limiter.Allow("b"); !other.Allowed {
+		t.Error("other keys should have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if res := limiter.Allow("a"); !res.Allowed {
+		t.Error("bucket should refill over time")
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	handler := RateLimit(NewMemoryLimiter(1, 1), ClientKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("POST", "/api/students", nil)
+	req.Header.Set("X-API-Key", "test")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("RateLimit-Limit") != "1" || rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected RateLimit headers %v", rr.Header())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", rr.Header().Get("Retry-After"))
+	}
+}
+
+func TestAPIKeysClientKey(t *testing.T) {
+	keys := NewAPIKeys([]config.APIKey{{Name: "office", Key: "secret"}})
+	handler := RateLimit(NewMemoryLimiter(1, 1), keys.ClientKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(key string) int {
+		req := httptest.NewRequest("GET", "/api/students", nil)
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := send("made-up-1"); code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+	if code := send("made-up-2"); code != http.StatusTooManyRequests {
+		t.Errorf("unknown keys should share the IP bucket, got %d", code)
+	}
+	if code := send("secret"); code != http.StatusOK {
+		t.Errorf("a valid key should have its own bucket, got %d", code)
+	}
+}
+
+func TestMemoryLimiterMaxBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewMemoryLimiter(1, 1)
+	limiter.now = func() time.Time { return now }
+	limiter.maxBuckets = 2
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		limiter.Allow(key)
+		now = now.Add(time.Millisecond)
+	}
+	if len(limiter.buckets) != 2 || limiter.lru.Len() != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(limiter.buckets))
+	}
+	if _, ok := limiter.buckets["b"]; ok {
+		t.Error("the least recently used bucket should be evicted")
+	}
+
+	// idle buckets go at the next sweep
+	now = now.Add(bucketIdleTTL + time.Second)
+	limiter.Allow("d")
+	if len(limiter.buckets) != 1 || limiter.lru.Len() != 1 {
+		t.Errorf("Expected only the new bucket after a sweep, got %d", len(limiter.buckets))
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	var readErr error
+	handler := MaxBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	req := httptest.NewRequest("POST", "/api/students", strings.NewReader("too large"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(readErr, &maxBytesErr) {
+		t.Errorf("Expected MaxBytesError, got %v", readErr)
+	}
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+// SMTP sends email through an SMTP server, authenticating when a username
+// is configured
+type SMTP struct {
+	cfg config.SMTP
+}
+
+func NewSMTP(cfg config.SMTP) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+// Send delivers msg the way smtp.SendMail does, but the connection is dialed
+// under ctx and the whole exchange must finish within cfg.Timeout, so a
+// stalled server cannot hold up the dispatcher
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := net.Dialer{Timeout: s.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var deadline time.Time
+	if s.cfg.Timeout > 0 {
+		deadline = time.Now().Add(s.cfg.Timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// closing the connection unblocks the exchange when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(email(s.cfg.From, msg, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// email formats msg as a plain-text RFC 5322 message
+func email(from string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// Webhook sends SMS by posting {"to", "message"} to an SMS gateway. Any
+// status outside 2xx is a failed delivery.
+type Webhook struct {
+	cfg    config.SMSWebhook
+	client *http.Client
+}
+
+func NewWebhook(cfg config.SMSWebhook) *Webhook {
+	return &Webhook{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+func (wh *Webhook) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{"to": msg.To, "message": msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if wh.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+wh.cfg.Token)
+	}
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms gateway answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Mailbox writes every message to a file in a directory instead of sending
+// it, so notifications can be tested offline. Emails are written as .eml
+// files and SMS as .txt.
+type Mailbox struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+}
+
+func NewMailbox(dir, from string) *Mailbox {
+	return &Mailbox{dir: dir, from: from}
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9@._+-]+`)
+
+func (m *Mailbox) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	ext, data := ".txt", []byte(fmt.Sprintf("To: %s\n\n%s\n", msg.To, msg.Body))
+	if msg.Channel == ChannelEmail {
+		ext, data = ".eml", email(m.from, msg, now)
+	}
+	name := fmt.Sprintf("%s-%04d-%s-%s%s", now.Format("20060102T150405"), m.seq.Add(1), msg.Channel, unsafeChars.ReplaceAllString(msg.To, "_"), ext)
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o644)
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+// fakeSMTP accepts one connection and answers like a minimal SMTP server,
+// returning the message data it received. With stall it never greets.
+func fakeSMTP(t *testing.T, stall bool) (config.SMTP, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if stall {
+			time.Sleep(time.Second)
+			return
+		}
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO":
+				reply("250-fake\r\n250 8BITMIME")
+			case "DATA":
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					body.WriteString(l)
+				}
+				data <- body.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return config.SMTP{Host: host, Port: p, From: "school@example.com", Timeout: 200 * time.Millisecond}, data
+}
+
+func TestSMTPSend(t *testing.T) {
+	cfg, data := fakeSMTP(t, false)
+	err := NewSMTP(cfg).Send(context.Background(), Message{Channel: ChannelEmail, To: "parent@example.com", Subject: "Absent", Body: "Ada was absent."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-data; !strings.Contains(got, "To: parent@example.com") || !strings.Contains(got, "Ada was absent.") {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestSMTPSendTimeout(t *testing.T) {
+	cfg, _ := fakeSMTP(t, true)
+	start := time.Now()
+	if err := NewSMTP(cfg).Send(context.Background(), Message{Channel: ChannelEmail, To: "parent@example.com"}); err == nil {
+		t.Fatal("Expected an error from a server that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Errorf("Send should give up after the timeout, took %s", elapsed)
+	}
+
+	cfg, _ = fakeSMTP(t, true)
+	cfg.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := NewSMTP(cfg).Send(ctx, Message{Channel: ChannelEmail, To: "parent@example.com"}); err == nil {
+		t.Fatal("Expected an error once the context is done")
+	}
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Errorf("Send should give up with the context, took %s", elapsed)
+	}
+}
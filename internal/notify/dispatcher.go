@@ -0,0 +1,347 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Dispatcher queues absence notices as attendance is written and sends
+// them in batches
+type Dispatcher struct {
+	storage   storage.Storage
+	cfg       config.Notifications
+	notifiers map[string]Notifier
+	from      time.Duration // send window, as offsets from midnight
+	until     time.Duration
+	now       func() time.Time
+	wake      chan struct{}
+}
+
+// NewDispatcher builds the notifiers for cfg.Mode. In live mode a channel
+// without configuration has no notifier, and notices that can only go out
+// on it are skipped.
+func NewDispatcher(storage storage.Storage, cfg config.Notifications) (*Dispatcher, error) {
+	from, err := clock(cfg.SendFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notifications.send_from %q: %w", cfg.SendFrom, err)
+	}
+	until, err := clock(cfg.SendUntil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notifications.send_until %q: %w", cfg.SendUntil, err)
+	}
+
+	notifiers := map[string]Notifier{}
+	switch cfg.Mode {
+	case "off":
+	case "mailbox":
+		mailbox := NewMailbox(cfg.MailboxDir, cfg.SMTP.From)
+		notifiers[ChannelEmail] = mailbox
+		notifiers[ChannelSMS] = mailbox
+	case "live":
+		if cfg.SMTP.Host != "" {
+			notifiers[ChannelEmail] = NewSMTP(cfg.SMTP)
+		}
+		if cfg.SMS.URL != "" {
+			notifiers[ChannelSMS] = NewWebhook(cfg.SMS)
+		}
+	default:
+		return nil, fmt.Errorf("invalid notifications.mode %q, expected off, mailbox or live", cfg.Mode)
+	}
+
+	return &Dispatcher{
+		storage:   storage,
+		cfg:       cfg,
+		notifiers: notifiers,
+		from:      from,
+		until:     until,
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+	}, nil
+}
+
+func clock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (d *Dispatcher) enabled() bool {
+	return d.cfg.Mode != "off"
+}
+
+// inWindow reports whether t falls inside the daily send window
+func (d *Dispatcher) inWindow(t time.Time) bool {
+	y, m, day := t.Date()
+	offset := t.Sub(time.Date(y, m, day, 0, 0, 0, 0, t.Location()))
+	return offset >= d.from && offset < d.until
+}
+
+// Run sends queued notices every interval, and as soon as one is queued,
+// until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	if !d.enabled() {
+		return
+	}
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		if err := d.Flush(ctx); err != nil {
+			slog.Error("error sending notifications", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Flush sends one batch of queued notices if the send window is open.
+// Notices left over from an earlier day are skipped: the family has to
+// hear on the day or not at all.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	now := d.now()
+	if !d.inWindow(now) {
+		return nil
+	}
+	pending, err := d.storage.GetPendingNotifications(ctx, d.cfg.BatchSize)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+	list, err := d.storage.GetNotificationTemplates(ctx)
+	if err != nil {
+		return err
+	}
+	templates := map[string]types.NotificationTemplate{}
+	for _, t := range list {
+		templates[t.Channel] = t
+	}
+
+	today := now.Format("2006-01-02")
+	for _, n := range pending {
+		if n.Date.Format("2006-01-02") != today {
+			n.Status, n.Error = types.NotificationSkipped, "send window missed"
+		} else {
+			d.deliver(ctx, &n, templates)
+		}
+		if err := d.storage.UpdateNotification(ctx, n); err != nil {
+			return fmt.Errorf("notification %d: %w", n.Id, err)
+		}
+	}
+	return nil
+}
+
+// deliver sends n to the student's primary guardian, or their first one,
+// on the guardian's preferred channel, and records the outcome on n
+func (d *Dispatcher) deliver(ctx context.Context, n *types.Notification, templates map[string]types.NotificationTemplate) {
+	fail := func(err error) {
+		n.Attempts++
+		n.Error = err.Error()
+		if n.Attempts >= d.cfg.MaxAttempts {
+			n.Status = types.NotificationFailed
+		}
+	}
+
+	guardians, err := d.storage.GetStudentGuardians(ctx, n.StudentID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(guardians) == 0 {
+		n.Status, n.Error = types.NotificationSkipped, "student has no guardian"
+		return
+	}
+	guardian := guardians[0]
+	channel, to := d.route(guardian.Guardian)
+	if channel == "" {
+		n.Status, n.Error = types.NotificationSkipped, "no configured channel reaches the guardian"
+		return
+	}
+	n.GuardianID, n.Channel, n.Recipient = guardian.Id, channel, to
+
+	student, err := d.storage.GetStudentById(ctx, n.StudentID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	data := templateData{
+		StudentName:  student.Name,
+		GuardianName: guardian.Name,
+		Date:         n.Date.Format("2006-01-02"),
+		SchoolName:   d.cfg.SchoolName,
+	}
+	if student.ClassID != 0 {
+		if class, err := d.storage.GetClassById(ctx, student.ClassID); err == nil {
+			data.ClassName = class.Name
+		}
+	}
+	template, ok := templates[channel]
+	if !ok {
+		n.Status, n.Error = types.NotificationFailed, "no template for channel "+channel
+		return
+	}
+	if n.Subject, n.Body, err = Render(template, data); err != nil {
+		n.Status, n.Error = types.NotificationFailed, err.Error()
+		return
+	}
+
+	msg := Message{Channel: channel, To: to, Subject: n.Subject, Body: n.Body}
+	if err := d.notifiers[channel].Send(ctx, msg); err != nil {
+		fail(err)
+		return
+	}
+	sentAt := d.now()
+	n.Status, n.Error, n.SentAt = types.NotificationSent, "", &sentAt
+	n.Attempts++
+}
+
+// route picks the channel and address for a guardian: their preferred
+// channel when it is configured and they have an address for it, otherwise
+// whichever other channel can reach them. Phone preference is served by SMS.
+func (d *Dispatcher) route(g types.Guardian) (channel, to string) {
+	addresses := map[string]string{ChannelEmail: g.Email, ChannelSMS: g.Phone}
+	order := []string{ChannelSMS, ChannelEmail}
+	if g.PreferredChannel == ChannelEmail {
+		order = []string{ChannelEmail, ChannelSMS}
+	}
+	for _, c := range order {
+		if d.notifiers[c] != nil && addresses[c] != "" {
+			return c, addresses[c]
+		}
+	}
+	return "", ""
+}
+
+// Watch wraps storage so that an Absent daily record for today queues a
+// notice for the student, and correcting it withdraws the notice while it
+// is still queued. Errors are logged and never fail the write.
+func (d *Dispatcher) Watch(s storage.Storage) storage.Storage {
+	if !d.enabled() {
+		return s
+	}
+	return &watched{Storage: s, dispatcher: d}
+}
+
+type watched struct {
+	storage.Storage
+	dispatcher *Dispatcher
+}
+
+func (w *watched) CreateAttendanceRecord(ctx context.Context, studentID, classID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (int64, error) {
+	id, err := w.Storage.CreateAttendanceRecord(ctx, studentID, classID, date, status, remarks, times)
+	if err == nil {
+		w.sync(ctx, studentID, date)
+	}
+	return id, err
+}
+
+func (w *watched) CreatePeriodAttendance(ctx context.Context, studentID, classID, periodID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (int64, error) {
+	id, err := w.Storage.CreatePeriodAttendance(ctx, studentID, classID, periodID, date, status, remarks, times)
+	if err == nil {
+		w.sync(ctx, studentID, date)
+	}
+	return id, err
+}
+
+func (w *watched) UpdateAttendanceRecord(ctx context.Context, id int64, status, remarks string, times types.AttendanceTimes) error {
+	err := w.Storage.UpdateAttendanceRecord(ctx, id, status, remarks, times)
+	if err == nil {
+		if record, err := w.Storage.GetAttendanceById(ctx, id); err == nil {
+			w.sync(ctx, record.StudentID, record.Date)
+		}
+	}
+	return err
+}
+
+func (w *watched) DeleteAttendanceRecord(ctx context.Context, id int64) error {
+	record, lookupErr := w.Storage.GetAttendanceById(ctx, id)
+	err := w.Storage.DeleteAttendanceRecord(ctx, id)
+	if err == nil && lookupErr == nil {
+		w.sync(ctx, record.StudentID, record.Date)
+	}
+	return err
+}
+
+func (w *watched) SyncAttendance(ctx context.Context, mutation types.SyncMutation) (types.SyncResult, error) {
+	result, err := w.Storage.SyncAttendance(ctx, mutation)
+	if err == nil && result.Outcome == types.SyncApplied && !result.Replayed {
+		w.sync(ctx, mutation.StudentID, mutation.Date)
+	}
+	return result, err
+}
+
+func (w *watched) CheckIn(ctx context.Context, checkIn types.CheckIn) (int64, error) {
+	id, err := w.Storage.CheckIn(ctx, checkIn)
+	if err == nil {
+		w.sync(ctx, checkIn.StudentID, checkIn.Date)
+	}
+	return id, err
+}
+
+func (w *watched) OverrideCheckIn(ctx context.Context, id int64, status, remarks string) error {
+	err := w.Storage.OverrideCheckIn(ctx, id, status, remarks)
+	if err == nil {
+		if checkIn, err := w.Storage.GetCheckInById(ctx, id); err == nil {
+			w.sync(ctx, checkIn.StudentID, checkIn.Date)
+		}
+	}
+	return err
+}
+
+func (w *watched) ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (int, error) {
+	excused, err := w.Storage.ReviewLeaveRequest(ctx, id, status, reviewedBy, note)
+	if err == nil && excused > 0 {
+		if leave, err := w.Storage.GetLeaveRequestById(ctx, id); err == nil {
+			w.sync(ctx, leave.StudentID, w.dispatcher.now())
+		}
+	}
+	return excused, err
+}
+
+// sync queues or withdraws the student's notice from their daily record
+func (w *watched) sync(ctx context.Context, studentID int64, date time.Time) {
+	d := w.dispatcher
+	if date.Format("2006-01-02") != d.now().Format("2006-01-02") {
+		return
+	}
+	log := logger.FromContext(ctx).With(slog.Int64("studentId", studentID))
+
+	records, err := w.Storage.GetAttendance(ctx, types.AttendanceFilter{StudentID: studentID, From: date, To: date})
+	if err != nil {
+		log.Error("error checking attendance for notification", slog.String("error", err.Error()))
+		return
+	}
+	absent := false
+	for _, r := range records {
+		absent = absent || r.Status == "Absent"
+	}
+
+	if !absent {
+		if err := w.Storage.CancelNotification(ctx, studentID, date); err != nil {
+			log.Error("error cancelling notification", slog.String("error", err.Error()))
+		}
+		return
+	}
+	queued, err := w.Storage.QueueNotification(ctx, studentID, date)
+	if err != nil {
+		log.Error("error queueing notification", slog.String("error", err.Error()))
+		return
+	}
+	if queued {
+		log.Info("absence notification queued")
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
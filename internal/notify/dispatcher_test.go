@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage serves one student with one guardian and records the
+// notifications written back. Other methods fall through to the nil
+// embedded interface.
+type mockStorage struct {
+	storage.Storage
+	pending  []types.Notification
+	guardian types.Guardian
+	updated  map[int64]types.Notification
+}
+
+func (m *mockStorage) GetPendingNotifications(ctx context.Context, limit int) ([]types.Notification, error) {
+	return m.pending, nil
+}
+
+func (m *mockStorage) GetNotificationTemplates(ctx context.Context) ([]types.NotificationTemplate, error) {
+	return []types.NotificationTemplate{
+		{Channel: ChannelEmail, Subject: "{{.StudentName}} absent", Body: "Dear {{.GuardianName}}, {{.StudentName}} of {{.ClassName}} was absent on {{.Date}}."},
+		{Channel: ChannelSMS, Body: "{{.SchoolName}}: {{.StudentName}} absent {{.Date}}"},
+	}, nil
+}
+
+func (m *mockStorage) GetStudentGuardians(ctx context.Context, studentID int64) ([]types.StudentGuardian, error) {
+	return []types.StudentGuardian{{Guardian: m.guardian, StudentID: studentID, IsPrimary: true}}, nil
+}
+
+func (m *mockStorage) GetStudentById(ctx context.Context, id int64) (types.Student, error) {
+	return types.Student{Id: id, Name: "Ada", ClassID: 1}, nil
+}
+
+func (m *mockStorage) GetClassById(ctx context.Context, id int64) (types.Class, error) {
+	return types.Class{Id: id, Name: "5A"}, nil
+}
+
+func (m *mockStorage) UpdateNotification(ctx context.Context, n types.Notification) error {
+	m.updated[n.Id] = n
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, m *mockStorage, now time.Time) (*Dispatcher, string) {
+	t.Helper()
+	dir := t.TempDir()
+	d, err := NewDispatcher(m, config.Notifications{
+		Mode:        "mailbox",
+		MailboxDir:  dir,
+		SchoolName:  "Springfield",
+		SendFrom:    "07:00",
+		SendUntil:   "19:00",
+		BatchSize:   10,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.now = func() time.Time { return now }
+	return d, dir
+}
+
+func TestFlush(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.Local)
+	today := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	m := &mockStorage{
+		pending: []types.Notification{
+			{Id: 1, StudentID: 7, Date: today, Status: types.NotificationQueued},
+			{Id: 2, StudentID: 8, Date: today.AddDate(0, 0, -1), Status: types.NotificationQueued},
+		},
+		guardian: types.Guardian{Id: 3, Name: "Mary", Email: "mary@example.com", Phone: "555-0100", PreferredChannel: ChannelEmail},
+		updated:  map[int64]types.Notification{},
+	}
+	d, dir := newTestDispatcher(t, m, now)
+
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := m.updated[1]
+	if sent.Status != types.NotificationSent || sent.Channel != ChannelEmail || sent.Recipient != "mary@example.com" || sent.SentAt == nil {
+		t.Errorf("today's notice: got %+v", sent)
+	}
+	if want := "Dear Mary, Ada of 5A was absent on 2026-03-02."; sent.Body != want {
+		t.Errorf("body = %q, want %q", sent.Body, want)
+	}
+	if stale := m.updated[2]; stale.Status != types.NotificationSkipped {
+		t.Errorf("yesterday's notice: got status %q, want skipped", stale.Status)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !strings.HasSuffix(files[0].Name(), ".eml") {
+		t.Fatalf("mailbox holds %v, want one .eml file", files)
+	}
+	data, _ := os.ReadFile(dir + "/" + files[0].Name())
+	if !strings.Contains(string(data), "To: mary@example.com") || !strings.Contains(string(data), "Subject: Ada absent") {
+		t.Errorf("unexpected message:\n%s", data)
+	}
+}
+
+func TestFlushOutsideWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 19, 0, 0, 0, time.Local)
+	m := &mockStorage{
+		pending: []types.Notification{{Id: 1, StudentID: 7, Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}},
+		updated: map[int64]types.Notification{},
+	}
+	d, _ := newTestDispatcher(t, m, now)
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.updated) != 0 {
+		t.Errorf("sent outside the window: %+v", m.updated)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	d, _ := newTestDispatcher(t, &mockStorage{}, time.Now())
+	live := &Dispatcher{notifiers: map[string]Notifier{ChannelEmail: d.notifiers[ChannelEmail]}}
+
+	tests := []struct {
+		name     string
+		d        *Dispatcher
+		guardian types.Guardian
+		channel  string
+	}{
+		{"prefers email", d, types.Guardian{Email: "a@x", Phone: "1", PreferredChannel: ChannelEmail}, ChannelEmail},
+		{"phone goes by sms", d, types.Guardian{Email: "a@x", Phone: "1", PreferredChannel: "phone"}, ChannelSMS},
+		{"falls back to email", d, types.Guardian{Email: "a@x", PreferredChannel: ChannelSMS}, ChannelEmail},
+		{"sms not configured", live, types.Guardian{Email: "a@x", Phone: "1", PreferredChannel: ChannelSMS}, ChannelEmail},
+		{"unreachable", live, types.Guardian{Phone: "1"}, ""},
+	}
+	for _, tt := range tests {
+		if channel, _ := tt.d.route(tt.guardian); channel != tt.channel {
+			t.Errorf("%s: got channel %q, want %q", tt.name, channel, tt.channel)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(types.NotificationTemplate{Body: "{{.StudentName}} absent"}); err != nil {
+		t.Errorf("valid template rejected: %v", err)
+	}
+	for _, body := range []string{"{{.StudentName", "{{.Unknown}}"} {
+		if err := Check(types.NotificationTemplate{Body: body}); err == nil {
+			t.Errorf("template %q accepted", body)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package notify tells guardians when their student is marked absent. Absent
+// marks queue a notice, at most one per student per day, and a Dispatcher
+// sends the queue in batches inside the school's send window through a
+// Notifier per channel.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Channels a notice can be sent on
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Message is one rendered notice. Subject is empty for SMS.
+type Message struct {
+	Channel string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages on one channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// templateData is what notification templates can refer to
+type templateData struct {
+	StudentName  string
+	GuardianName string
+	ClassName    string
+	Date         string
+	SchoolName   string
+}
+
+// Render executes a notification template
+func Render(t types.NotificationTemplate, data templateData) (subject, body string, err error) {
+	if subject, err = execute("subject", t.Subject, data); err != nil {
+		return "", "", err
+	}
+	if body, err = execute("body", t.Body, data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject), strings.TrimSpace(body), nil
+}
+
+// Check reports whether a template parses and renders against sample data
+func Check(t types.NotificationTemplate) error {
+	_, _, err := Render(t, templateData{
+		StudentName:  "Student",
+		GuardianName: "Guardian",
+		ClassName:    "Class",
+		Date:         "2006-01-02",
+		SchoolName:   "School",
+	})
+	return err
+}
+
+func execute(name, text string, data templateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return sb.String(), nil
+}
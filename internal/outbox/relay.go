@@ -0,0 +1,111 @@
+// Package outbox publishes the domain events the storage writes to its
+// outbox table. Events are written in the same transaction as the change
+// they describe, so none is lost or made up when a write fails, and the
+// Relay hands them on in order, at least once, to every Publisher.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Publisher receives every event in order. A publisher may see an event
+// again after a failure or restart, so it must tolerate duplicates; the
+// event ID is stable for that.
+type Publisher interface {
+	Publish(ctx context.Context, event types.Event) error
+}
+
+// Relay moves events from the outbox to the publishers
+type Relay struct {
+	storage    storage.Storage
+	cfg        config.Outbox
+	publishers []Publisher
+	now        func() time.Time
+}
+
+func NewRelay(storage storage.Storage, cfg config.Outbox, publishers ...Publisher) *Relay {
+	return &Relay{storage: storage, cfg: cfg, publishers: publishers, now: time.Now}
+}
+
+// Run publishes new events every interval and prunes old ones hourly,
+// until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	var pruned time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := r.Flush(ctx); err != nil {
+			slog.Error("error relaying events", slog.String("error", err.Error()))
+		}
+		if r.now().Sub(pruned) >= time.Hour {
+			pruned = r.now()
+			r.prune(ctx)
+		}
+	}
+}
+
+// Flush publishes unpublished events in batches until none are left. It
+// stops at the first event a publisher rejects and leaves it, and the ones
+// after it, for the next round, so order is kept.
+func (r *Relay) Flush(ctx context.Context) error {
+	for {
+		events, err := r.storage.GetUnpublishedEvents(ctx, r.cfg.BatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		var through int64
+		var failed error
+		for _, event := range events {
+			if failed = r.publish(ctx, event); failed != nil {
+				break
+			}
+			through = event.Seq
+		}
+		if through > 0 {
+			if err := r.storage.MarkEventsPublished(ctx, through); err != nil {
+				return err
+			}
+		}
+		if failed != nil {
+			return failed
+		}
+		if len(events) < r.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, event types.Event) error {
+	for _, p := range r.publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			return fmt.Errorf("event %d %s: %w", event.Seq, event.Type, err)
+		}
+	}
+	return nil
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	if r.cfg.Retention <= 0 {
+		return
+	}
+	n, err := r.storage.PruneEvents(ctx, r.now().Add(-r.cfg.Retention))
+	if err != nil {
+		slog.Error("error pruning events", slog.String("error", err.Error()))
+		return
+	}
+	if n > 0 {
+		slog.Info("pruned published events", slog.Int64("count", n))
+	}
+}
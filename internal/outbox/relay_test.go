@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage keeps the outbox in memory. Other methods fall through to the
+// nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	events    []types.Event
+	published int64
+}
+
+func (m *mockStorage) GetUnpublishedEvents(ctx context.Context, limit int) ([]types.Event, error) {
+	var events []types.Event
+	for _, e := range m.events {
+		if e.Seq > m.published && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockStorage) MarkEventsPublished(ctx context.Context, throughSeq int64) error {
+	m.published = throughSeq
+	return nil
+}
+
+// publisher records what it is given and rejects the event failSeq
+type publisher struct {
+	got     []int64
+	failSeq int64
+}
+
+func (p *publisher) Publish(ctx context.Context, event types.Event) error {
+	if event.Seq == p.failSeq {
+		return errors.New("unavailable")
+	}
+	p.got = append(p.got, event.Seq)
+	return nil
+}
+
+func newStorage(n int) *mockStorage {
+	m := &mockStorage{}
+	for seq := int64(1); seq <= int64(n); seq++ {
+		m.events = append(m.events, types.Event{Seq: seq, Type: "student.created"})
+	}
+	return m
+}
+
+func TestFlushInBatches(t *testing.T) {
+	m := newStorage(5)
+	p := &publisher{}
+	if err := NewRelay(m, config.Outbox{BatchSize: 2}, p).Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.got) != 5 || m.published != 5 {
+		t.Errorf("published %v through seq %d, want 1-5", p.got, m.published)
+	}
+	for i, seq := range p.got {
+		if seq != int64(i+1) {
+			t.Fatalf("published out of order: %v", p.got)
+		}
+	}
+}
+
+func TestFlushStopsAtFailure(t *testing.T) {
+	m := newStorage(5)
+	p := &publisher{failSeq: 3}
+	relay := NewRelay(m, config.Outbox{BatchSize: 10}, p)
+	if err := relay.Flush(context.Background()); err == nil {
+		t.Fatal("expected the failure to be reported")
+	}
+	if m.published != 2 {
+		t.Errorf("marked through seq %d, want 2", m.published)
+	}
+
+	p.failSeq = 0
+	if err := relay.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 2, 3, 4, 5}; len(p.got) != len(want) || p.got[2] != 3 || m.published != 5 {
+		t.Errorf("after recovery published %v through seq %d, want %v", p.got, m.published, want)
+	}
+}
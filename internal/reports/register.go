@@ -0,0 +1,146 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// RegisterData is everything printed on a monthly class register. Days is
+// the school calendar of the month; days it marks as not instructional
+// are shaded.
+type RegisterData struct {
+	Class    types.Class
+	Month    time.Time // any day in the month
+	Students []types.Student
+	Records  []types.AttendanceRecord
+	Days     []types.CalendarDay
+}
+
+// marks printed in the register grid, keyed by attendance status
+var marks = map[string]string{
+	"Present": "P",
+	"Absent":  "A",
+	"Late":    "L",
+	"Excused": "E",
+}
+
+// ClassRegister writes a landscape A4 register: one row per student, one
+// column per day of the month with P/A/L/E marks, and per-student totals
+func ClassRegister(w io.Writer, data RegisterData) error {
+	first := time.Date(data.Month.Year(), data.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	days := first.AddDate(0, 1, -1).Day()
+
+	// day of month -> no school that day
+	closed := map[int]bool{}
+	for _, d := range data.Days {
+		if !d.Instructional && d.Date.Year() == first.Year() && d.Date.Month() == first.Month() {
+			closed[d.Date.Day()] = true
+		}
+	}
+
+	// student id -> day of month -> mark
+	grid := map[int64]map[int]string{}
+	for _, rec := range data.Records {
+		if rec.Date.Year() != first.Year() || rec.Date.Month() != first.Month() {
+			continue
+		}
+		if grid[rec.StudentID] == nil {
+			grid[rec.StudentID] = map[int]string{}
+		}
+		grid[rec.StudentID][rec.Date.Day()] = marks[rec.Status]
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 25)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-20)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(90, 6, "Class teacher signature: ____________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(90, 6, "Principal signature: ____________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+
+	const (
+		rollW  = 14.0
+		nameW  = 46.0
+		totalW = 9.0
+		rowH   = 6.0
+	)
+	dayW := (297.0 - 20 - rollW - nameW - 4*totalW) / float64(days)
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 8, tr(fmt.Sprintf("Attendance Register - %s (Grade %s, Section %s)", data.Class.Name, data.Class.Grade, data.Class.Section)), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s    Teacher: %s", first.Format("January 2006"), data.Class.TeacherName)), "", 1, "L", false, 0, "")
+		pdf.Ln(2)
+
+		pdf.SetFont("Helvetica", "B", 7)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(rollW, rowH, "Roll", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(nameW, rowH, "Student", "1", 0, "L", true, 0, "")
+		for d := 1; d <= days; d++ {
+			pdf.CellFormat(dayW, rowH, strconv.Itoa(d), "1", 0, "C", closed[d], 0, "")
+		}
+		pdf.CellFormat(totalW, rowH, "P", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(totalW, rowH, "A", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(totalW, rowH, "L", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(totalW, rowH, "E", "1", 1, "C", true, 0, "")
+	}
+	pdf.SetHeaderFunc(header)
+	pdf.AddPage()
+
+	dailyPresent := make([]int, days+1)
+	pdf.SetFont("Helvetica", "", 7)
+	for _, student := range data.Students {
+		counts := map[string]int{}
+		pdf.CellFormat(rollW, rowH, tr(student.RollNo), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(nameW, rowH, tr(truncate(student.Name, 30)), "1", 0, "L", false, 0, "")
+		for d := 1; d <= days; d++ {
+			mark := grid[student.Id][d]
+			counts[mark]++
+			if mark == "P" || mark == "L" {
+				dailyPresent[d]++
+			}
+			pdf.CellFormat(dayW, rowH, mark, "1", 0, "C", closed[d], 0, "")
+		}
+		pdf.CellFormat(totalW, rowH, strconv.Itoa(counts["P"]), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(totalW, rowH, strconv.Itoa(counts["A"]), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(totalW, rowH, strconv.Itoa(counts["L"]), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(totalW, rowH, strconv.Itoa(counts["E"]), "1", 1, "C", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 7)
+	pdf.CellFormat(rollW+nameW, rowH, "Present (incl. late)", "1", 0, "R", true, 0, "")
+	for d := 1; d <= days; d++ {
+		value := ""
+		if dailyPresent[d] > 0 {
+			value = strconv.Itoa(dailyPresent[d])
+		}
+		pdf.CellFormat(dayW, rowH, value, "1", 0, "C", true, 0, "")
+	}
+	pdf.CellFormat(4*totalW, rowH, "", "1", 1, "C", true, 0, "")
+
+	pdf.Ln(3)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(0, 5, fmt.Sprintf("P = Present, A = Absent, L = Late, E = Excused. Shaded columns are days without school. Generated %s.", time.Now().Format("2006-01-02 15:04")), "", 1, "L", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "."
+}
@@ -0,0 +1,115 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// ReportCardData is everything printed on a student's attendance report card
+type ReportCardData struct {
+	Student types.Student
+	Report  types.AttendanceReport
+	From    time.Time
+	To      time.Time
+	// Records lists the student's attendance in the period; absences and
+	// late arrivals are itemised on the card
+	Records []types.AttendanceRecord
+}
+
+// ReportCard writes a portrait A4 attendance report card
+func ReportCard(w io.Writer, data ReportCardData) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Attendance Report Card", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	field := func(label, value string) {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(45, 7, label, "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 7, tr(value), "", 1, "L", false, 0, "")
+	}
+	field("Student", data.Report.StudentName)
+	field("Roll number", data.Student.RollNo)
+	field("Class", data.Report.ClassName)
+	field("Period", periodLabel(data.From, data.To))
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFillColor(230, 230, 230)
+	for _, h := range []string{"Days", "Present", "Late", "Excused", "Unexcused", "Rate"} {
+		pdf.CellFormat(28, 8, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 11)
+	for _, v := range []string{
+		fmt.Sprint(data.Report.TotalDays),
+		fmt.Sprint(data.Report.PresentDays),
+		fmt.Sprint(data.Report.LateDays),
+		fmt.Sprint(data.Report.ExcusedDays),
+		fmt.Sprint(data.Report.UnexcusedDays),
+		fmt.Sprintf("%.1f%%", data.Report.AttendanceRate),
+	} {
+		pdf.CellFormat(28, 8, v, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Minutes late: %d    Early departures: %d (%d minutes)",
+		data.Report.MinutesLate, data.Report.EarlyDepartures, data.Report.MinutesLeftEarly), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	var notable []types.AttendanceRecord
+	for _, rec := range data.Records {
+		if rec.Status != "Present" {
+			notable = append(notable, rec)
+		}
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, "Absences, leave and late arrivals", "", 1, "L", false, 0, "")
+	if len(notable) == 0 {
+		pdf.SetFont("Helvetica", "I", 10)
+		pdf.CellFormat(0, 7, "None in this period.", "", 1, "L", false, 0, "")
+	} else {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(35, 7, "Date", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(25, 7, "Status", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(0, 7, "Remarks", "1", 1, "L", true, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		for _, rec := range notable {
+			pdf.CellFormat(35, 7, rec.Date.Format("Mon 02 Jan 2006"), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(25, 7, rec.Status, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(0, 7, tr(truncate(rec.Remarks, 70)), "1", 1, "L", false, 0, "")
+		}
+	}
+
+	pdf.Ln(20)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(85, 6, "Class teacher: ____________________", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Parent/guardian: ____________________", "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.CellFormat(0, 5, "Generated "+time.Now().Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+func periodLabel(from, to time.Time) string {
+	switch {
+	case from.IsZero() && to.IsZero():
+		return "All records"
+	case from.IsZero():
+		return "Until " + to.Format("02 Jan 2006")
+	case to.IsZero():
+		return "From " + from.Format("02 Jan 2006")
+	}
+	return from.Format("02 Jan 2006") + " - " + to.Format("02 Jan 2006")
+}
@@ -0,0 +1,44 @@
+package reports
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestClassRegisterAndReportCard(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 2, d, 0, 0, 0, 0, time.UTC) }
+	students := []types.Student{{Id: 1, Name: "Ada Lovelace", RollNo: "R1"}, {Id: 2, Name: "José Núñez", RollNo: "R2"}}
+	records := []types.AttendanceRecord{
+		{StudentID: 1, Date: day(2), Status: "Present"},
+		{StudentID: 2, Date: day(2), Status: "Absent", Remarks: "flu"},
+		{StudentID: 2, Date: day(3), Status: "Late"},
+	}
+
+	var buf bytes.Buffer
+	days := []types.CalendarDay{{Date: day(2), Instructional: true}, {Date: day(16), Reason: "holiday"}}
+	err := ClassRegister(&buf, RegisterData{Class: types.Class{Name: "5A"}, Month: day(14), Students: students, Records: records, Days: days})
+	if err != nil {
+		t.Fatalf("ClassRegister failed: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("register is not a PDF")
+	}
+
+	buf.Reset()
+	err = ReportCard(&buf, ReportCardData{
+		Student: students[1],
+		Report:  types.AttendanceReport{StudentName: "José Núñez", TotalDays: 2, AbsentDays: 1, LateDays: 1, AttendanceRate: 50},
+		From:    day(1),
+		To:      day(28),
+		Records: records[1:],
+	})
+	if err != nil {
+		t.Fatalf("ReportCard failed: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("report card is not a PDF")
+	}
+}
@@ -0,0 +1,64 @@
+package localfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := New(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put(ctx, "ab/abcdef", strings.NewReader("certificate")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "ab/abcdef", strings.NewReader("replaced")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := s.Open(ctx, "ab/abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "replaced" {
+		t.Errorf("content = %q, want %q", data, "replaced")
+	}
+	// no temporary files are left behind
+	if entries, _ := os.ReadDir(filepath.Join(dir, "blobs", "ab")); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+
+	if err := s.Delete(ctx, "ab/abcdef"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "ab/abcdef"); err != nil {
+		t.Errorf("deleting a missing blob: %v", err)
+	}
+	if _, err := s.Open(ctx, "ab/abcdef"); !errors.Is(err, storage.ErrBlobNotFound) {
+		t.Errorf("open after delete err = %v, want ErrBlobNotFound", err)
+	}
+}
+
+func TestStoreKeys(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", ".", "../escape", "/etc/passwd", "a/../../b", "a//b"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q) succeeded, want an error", key)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const alertColumns = "id, student_id, rule, status, COALESCE(message, ''), COALESCE(value, 0), COALESCE(threshold, 0), COALESCE(note, ''), created_at, updated_at"
+
+// Alert methods
+func (s *Sqlite) GetAlerts(ctx context.Context, filter types.AlertFilter) (alerts []types.Alert, err error) {
+	var where []string
+	var args []any
+	if filter.StudentID != 0 {
+		where = append(where, "student_id = ?")
+		args = append(args, filter.StudentID)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Rule != "" {
+		where = append(where, "rule = ?")
+		args = append(args, filter.Rule)
+	}
+	query := "select " + alertColumns + " from alerts"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY updated_at DESC, id DESC"
+	ctx, span := startSpan(ctx, "GetAlerts", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts = []types.Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+func (s *Sqlite) GetAlertById(ctx context.Context, id int64) (alert types.Alert, err error) {
+	const query = "select " + alertColumns + " from alerts where id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetAlertById", query)
+	defer endSpan(span, &err)
+
+	alert, err = scanAlert(s.Db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Alert{}, fmt.Errorf("no alert found with id %d", id)
+		}
+		return types.Alert{}, fmt.Errorf("query error %w", err)
+	}
+	return alert, nil
+}
+
+// RaiseAlert opens an alert, or refreshes the message and value of the
+// student's active alert for the same rule, keeping its status
+func (s *Sqlite) RaiseAlert(ctx context.Context, alert types.Alert) (id int64, err error) {
+	const query = `INSERT INTO alerts (student_id, rule, status, message, value, threshold) VALUES (?,?,'open',?,?,?)
+ON CONFLICT(student_id, rule) WHERE status != 'resolved' DO UPDATE SET
+    message = excluded.message, value = excluded.value, threshold = excluded.threshold, updated_at = CURRENT_TIMESTAMP
+RETURNING id`
+	ctx, span := startSpan(ctx, "RaiseAlert", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, alert.StudentID, alert.Rule, alert.Message, alert.Value, alert.Threshold).Scan(&id)
+	return id, err
+}
+
+// ResolveAlerts resolves the student's active alerts for a rule, if any
+func (s *Sqlite) ResolveAlerts(ctx context.Context, studentID int64, rule, note string) (err error) {
+	const query = "UPDATE alerts SET status = 'resolved', note = ?, updated_at = CURRENT_TIMESTAMP WHERE student_id = ? AND rule = ? AND status != 'resolved'"
+	ctx, span := startSpan(ctx, "ResolveAlerts", query)
+	defer endSpan(span, &err)
+
+	_, err = s.Db.ExecContext(ctx, query, note, studentID, rule)
+	return err
+}
+
+func (s *Sqlite) UpdateAlertStatus(ctx context.Context, id int64, status, note string) (err error) {
+	const query = "UPDATE alerts SET status = ?, note = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateAlertStatus", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, status, note, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no alert found with id %d", id)
+	}
+	return nil
+}
+
+func scanAlert(row interface{ Scan(...any) error }) (alert types.Alert, err error) {
+	err = row.Scan(&alert.Id, &alert.StudentID, &alert.Rule, &alert.Status, &alert.Message,
+		&alert.Value, &alert.Threshold, &alert.Note, &alert.CreatedAt, &alert.UpdatedAt)
+	return alert, err
+}
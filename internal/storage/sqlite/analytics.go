@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// analyticsGroups maps each group_by value to its key and label expressions
+// over attendance records, and to the key of a calendar day d so that
+// instructional days can be counted per group. Groups that are not periods
+// share every day of the range.
+var analyticsGroups = map[string]struct{ key, label, day string }{
+	"class":   {"CAST(a.class_id AS TEXT)", "COALESCE(c.name, '')", "''"},
+	"grade":   {"COALESCE(c.grade, '')", "COALESCE(c.grade, '')", "''"},
+	"section": {"COALESCE(c.grade, '') || '-' || COALESCE(c.section, '')", "COALESCE(c.grade, '') || '-' || COALESCE(c.section, '')", "''"},
+	"day":     {"date(a.date)", "date(a.date)", "date(d)"},
+	"week":    {"strftime('%Y-W%W', a.date)", "MIN(a.date)", "strftime('%Y-W%W', d)"},
+	"month":   {"strftime('%Y-%m', a.date)", "strftime('%Y-%m', a.date)", "strftime('%Y-%m', d)"},
+	"weekday": {"strftime('%w', a.date)", "strftime('%w', a.date)", "strftime('%w', d)"},
+}
+
+var weekdays = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// GetAttendanceAnalytics aggregates attendance records by class, grade,
+// section or period with SQL aggregates. Records on non-instructional days
+// are left out, and the rate is measured against the students of the group
+// times the instructional days of its period, so unmarked days count as
+// missed; excused absences are taken off.
+func (s *Sqlite) GetAttendanceAnalytics(ctx context.Context, q types.AnalyticsQuery) (stats []types.AttendanceStats, err error) {
+	group, ok := analyticsGroups[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", storage.ErrInvalidGroupBy, q.GroupBy)
+	}
+
+	where := []string{"a.period_id IS NULL", s.instructional("a.date")}
+	var args []any
+	if !q.From.IsZero() {
+		where = append(where, "a.date >= ?")
+		args = append(args, q.From.Format("2006-01-02"))
+	}
+	if !q.To.IsZero() {
+		where = append(where, "a.date <= ?")
+		args = append(args, q.To.Format("2006-01-02"))
+	}
+	if q.ClassID != 0 {
+		where = append(where, "a.class_id = ?")
+		args = append(args, q.ClassID)
+	}
+	if q.Grade != "" {
+		where = append(where, "c.grade = ?")
+		args = append(args, q.Grade)
+	}
+
+	query := fmt.Sprintf(`SELECT %s AS k, %s,
+    COUNT(*),
+    SUM(a.status = 'Present'),
+    SUM(a.status IN ('Absent', 'Excused')),
+    SUM(a.status = 'Excused'),
+    SUM(a.status = 'Late'),
+    SUM(a.minutes_late),
+    SUM(a.minutes_early > 0),
+    SUM(a.minutes_early),
+    COUNT(DISTINCT a.student_id),
+    MIN(date(a.date)),
+    MAX(date(a.date))
+FROM attendance_records a
+LEFT JOIN classes c ON c.id = a.class_id`, group.key, group.label)
+	query += "\nWHERE " + strings.Join(where, " AND ")
+	query += "\nGROUP BY k ORDER BY k"
+
+	ctx, span := startSpan(ctx, "GetAttendanceAnalytics", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats = []types.AttendanceStats{}
+	var first, last string
+	for rows.Next() {
+		var st types.AttendanceStats
+		var minDate, maxDate string
+		err := rows.Scan(&st.Key, &st.Label, &st.Records, &st.Present, &st.Absent, &st.Excused, &st.Late,
+			&st.MinutesLate, &st.EarlyDepartures, &st.MinutesLeftEarly, &st.Students, &minDate, &maxDate)
+		if err != nil {
+			return nil, err
+		}
+		if q.GroupBy == "weekday" {
+			var wd int
+			fmt.Sscan(st.Key, &wd)
+			st.Label = weekdays[wd%7]
+		}
+		if first == "" || minDate < first {
+			first = minDate
+		}
+		last = max(last, maxDate)
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return stats, nil
+	}
+
+	// instructional days per group over the queried range, or the span of
+	// the records when the range is open
+	from, to := q.From.Format("2006-01-02"), q.To.Format("2006-01-02")
+	if q.From.IsZero() {
+		from = first
+	}
+	if q.To.IsZero() {
+		to = last
+	}
+	days, err := s.instructionalDaysBy(ctx, group.day, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		st := &stats[i]
+		key := st.Key
+		if group.day == "''" {
+			key = ""
+		}
+		st.Days = days[key]
+		if expected := st.Students*st.Days - st.Excused; expected > 0 {
+			rate := float64(st.Present+st.Late) / float64(expected) * 100
+			st.AttendanceRate = math.Round(min(rate, 100)*100) / 100
+		}
+	}
+	return stats, nil
+}
+
+// instructionalDaysBy counts the instructional days between two inclusive
+// YYYY-MM-DD dates, grouped by a key expression over the day d
+func (s *Sqlite) instructionalDaysBy(ctx context.Context, key, from, to string) (map[string]int, error) {
+	query := daysCTE + "\nSELECT " + key + ", COUNT(*) FROM days WHERE " + s.instructional("d") + " GROUP BY 1"
+	rows, err := s.Db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := map[string]int{}
+	for rows.Next() {
+		var k string
+		var n int
+		if err := rows.Scan(&k, &n); err != nil {
+			return nil, err
+		}
+		days[k] = n
+	}
+	return days, rows.Err()
+}
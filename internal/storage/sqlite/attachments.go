@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const attachmentColumns = `id, owner_type, owner_id, student_id, filename, content_type, size, sha256, blob_key,
+    COALESCE(uploaded_by, ''), created_at FROM attachments`
+
+// ownerStudent finds the student of each kind of attachment owner
+var ownerStudent = map[string]string{
+	types.OwnerStudent:      "SELECT id FROM students WHERE id = ?",
+	types.OwnerLeaveRequest: "SELECT student_id FROM leave_requests WHERE id = ?",
+	types.OwnerAttendance:   "SELECT student_id FROM attendance_records WHERE id = ?",
+}
+
+// Attachment methods
+
+// CreateAttachment records an attachment whose content is already in the
+// blob store. The student is taken from the owner, which must exist.
+func (s *Sqlite) CreateAttachment(ctx context.Context, a types.Attachment) (id int64, err error) {
+	const query = `INSERT INTO attachments (owner_type, owner_id, student_id, filename, content_type, size, sha256, blob_key, uploaded_by, created_at)
+VALUES (?,?,?,?,?,?,?,?,?,?)`
+	ctx, span := startSpan(ctx, "CreateAttachment", query)
+	defer endSpan(span, &err)
+
+	ownerQuery, ok := ownerStudent[a.OwnerType]
+	if !ok {
+		return 0, fmt.Errorf("unknown attachment owner %q", a.OwnerType)
+	}
+	err = s.Db.QueryRowContext(ctx, ownerQuery, a.OwnerID).Scan(&a.StudentID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no %s found with id %d", a.OwnerType, a.OwnerID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := s.Db.ExecContext(ctx, query, a.OwnerType, a.OwnerID, a.StudentID, a.Filename, a.ContentType, a.Size, a.SHA256,
+		a.BlobKey, nullableString(a.UploadedBy), time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetAttachmentById(ctx context.Context, id int64) (a types.Attachment, err error) {
+	const query = "SELECT " + attachmentColumns + " WHERE id = ?"
+	ctx, span := startSpan(ctx, "GetAttachmentById", query)
+	defer endSpan(span, &err)
+
+	a, err = scanAttachment(s.Db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return a, fmt.Errorf("no attachment found with id %d", id)
+	}
+	return a, err
+}
+
+// GetAttachments lists the attachments of one owner, oldest first
+func (s *Sqlite) GetAttachments(ctx context.Context, ownerType string, ownerID int64) (attachments []types.Attachment, err error) {
+	const query = "SELECT " + attachmentColumns + " WHERE owner_type = ? AND owner_id = ? ORDER BY id"
+	ctx, span := startSpan(ctx, "GetAttachments", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments = []types.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// DeleteAttachment deletes the record of an attachment; its blob is left
+// to the caller
+func (s *Sqlite) DeleteAttachment(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM attachments WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteAttachment", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("no attachment found with id %d", id)
+	}
+	return nil
+}
+
+func scanAttachment(row interface{ Scan(...any) error }) (types.Attachment, error) {
+	var a types.Attachment
+	err := row.Scan(&a.Id, &a.OwnerType, &a.OwnerID, &a.StudentID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.BlobKey,
+		&a.UploadedBy, &a.CreatedAt)
+	return a, err
+}
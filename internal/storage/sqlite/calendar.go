@@ -0,0 +1,351 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// weekendDays turns weekday names into the strftime('%w') list used in the
+// calendar queries
+func weekendDays(names []string) (string, error) {
+	var days []string
+	for _, name := range names {
+		found := false
+		for i, wd := range weekdays {
+			if strings.EqualFold(strings.TrimSpace(name), wd) {
+				days = append(days, strconv.Itoa(i))
+				found = true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("invalid weekend day %q", name)
+		}
+	}
+	if len(days) == 0 {
+		return "-1", nil
+	}
+	return strings.Join(days, ","), nil
+}
+
+// daysCTE generates one row per date between two bound parameters
+const daysCTE = `WITH RECURSIVE days(d) AS (
+    SELECT date(?) UNION ALL SELECT date(d, '+1 day') FROM days WHERE d < date(?)
+)`
+
+// instructional returns a predicate that holds when the date expression
+// falls on an instructional day
+func (s *Sqlite) instructional(expr string) string {
+	return fmt.Sprintf(`(CAST(strftime('%%w', %[1]s) AS INTEGER) NOT IN (%[2]s)
+    AND NOT EXISTS (SELECT 1 FROM closures h WHERE date(%[1]s) BETWEEN h.start_date AND h.end_date)
+    AND (NOT EXISTS (SELECT 1 FROM terms) OR EXISTS (SELECT 1 FROM terms t WHERE date(%[1]s) BETWEEN t.start_date AND t.end_date)))`,
+		expr, s.weekend)
+}
+
+// countInstructionalDays counts the instructional days between two inclusive dates
+func (s *Sqlite) countInstructionalDays(ctx context.Context, from, to time.Time) (int, error) {
+	if to.Before(from) {
+		return 0, nil
+	}
+	query := daysCTE + "\nSELECT COUNT(*) FROM days WHERE " + s.instructional("d")
+	var n int
+	err := s.Db.QueryRowContext(ctx, query, from.Format("2006-01-02"), to.Format("2006-01-02")).Scan(&n)
+	return n, err
+}
+
+// checkInstructional fails with storage.ErrNonInstructionalDay unless the
+// date is an instructional day
+func (s *Sqlite) checkInstructional(ctx context.Context, date time.Time) error {
+	days, err := s.GetCalendarDays(ctx, date, date)
+	if err != nil {
+		return err
+	}
+	if len(days) == 1 && !days[0].Instructional {
+		return fmt.Errorf("%s is %s: %w", date.Format("2006-01-02"), days[0].Reason, storage.ErrNonInstructionalDay)
+	}
+	return nil
+}
+
+// Calendar methods
+func (s *Sqlite) CreateAcademicYear(ctx context.Context, name string, startDate, endDate time.Time) (id int64, err error) {
+	const query = "INSERT INTO academic_years (name, start_date, end_date) VALUES (?,?,?)"
+	ctx, span := startSpan(ctx, "CreateAcademicYear", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetAcademicYearById(ctx context.Context, id int64) (year types.AcademicYear, err error) {
+	const query = "select id, name, start_date, end_date from academic_years where id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetAcademicYearById", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, id).Scan(&year.Id, &year.Name, &year.StartDate, &year.EndDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.AcademicYear{}, fmt.Errorf("no academic year found with id %d", id)
+		}
+		return types.AcademicYear{}, fmt.Errorf("query error %w", err)
+	}
+	return year, nil
+}
+
+func (s *Sqlite) GetAcademicYears(ctx context.Context) (years []types.AcademicYear, err error) {
+	const query = "select id, name, start_date, end_date from academic_years ORDER BY start_date"
+	ctx, span := startSpan(ctx, "GetAcademicYears", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	years = []types.AcademicYear{}
+	for rows.Next() {
+		var year types.AcademicYear
+		if err := rows.Scan(&year.Id, &year.Name, &year.StartDate, &year.EndDate); err != nil {
+			return nil, err
+		}
+		years = append(years, year)
+	}
+	return years, rows.Err()
+}
+
+// DeleteAcademicYear deletes the year together with its terms
+func (s *Sqlite) DeleteAcademicYear(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM academic_years WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteAcademicYear", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM terms WHERE academic_year_id = ?", id); err != nil {
+		return err
+	}
+	if err := execOne(ctx, tx, query, id, "academic year"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Sqlite) CreateTerm(ctx context.Context, academicYearID int64, name string, startDate, endDate time.Time) (id int64, err error) {
+	const query = "INSERT INTO terms (academic_year_id, name, start_date, end_date) VALUES (?,?,?,?)"
+	ctx, span := startSpan(ctx, "CreateTerm", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, academicYearID, name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetTerms lists the terms of one academic year, or of all years when the id is zero
+func (s *Sqlite) GetTerms(ctx context.Context, academicYearID int64) (terms []types.Term, err error) {
+	query := "select id, academic_year_id, name, start_date, end_date from terms"
+	var args []any
+	if academicYearID != 0 {
+		query += " WHERE academic_year_id = ?"
+		args = append(args, academicYearID)
+	}
+	query += " ORDER BY start_date"
+	ctx, span := startSpan(ctx, "GetTerms", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms = []types.Term{}
+	for rows.Next() {
+		var term types.Term
+		if err := rows.Scan(&term.Id, &term.AcademicYearID, &term.Name, &term.StartDate, &term.EndDate); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+func (s *Sqlite) DeleteTerm(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM terms WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteTerm", query)
+	defer endSpan(span, &err)
+
+	return execOne(ctx, s.Db, query, id, "term")
+}
+
+func (s *Sqlite) CreateClosure(ctx context.Context, name, kind string, startDate, endDate time.Time) (id int64, err error) {
+	const query = "INSERT INTO closures (name, kind, start_date, end_date) VALUES (?,?,?,?)"
+	ctx, span := startSpan(ctx, "CreateClosure", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, name, kind, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetClosures lists the closures overlapping the inclusive range; zero
+// dates leave that end of the range open
+func (s *Sqlite) GetClosures(ctx context.Context, from, to time.Time) (closures []types.Closure, err error) {
+	query := "select id, name, kind, start_date, end_date from closures"
+	var where []string
+	var args []any
+	if !to.IsZero() {
+		where = append(where, "start_date <= ?")
+		args = append(args, to.Format("2006-01-02"))
+	}
+	if !from.IsZero() {
+		where = append(where, "end_date >= ?")
+		args = append(args, from.Format("2006-01-02"))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY start_date"
+	ctx, span := startSpan(ctx, "GetClosures", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	closures = []types.Closure{}
+	for rows.Next() {
+		var c types.Closure
+		if err := rows.Scan(&c.Id, &c.Name, &c.Kind, &c.StartDate, &c.EndDate); err != nil {
+			return nil, err
+		}
+		closures = append(closures, c)
+	}
+	return closures, rows.Err()
+}
+
+func (s *Sqlite) DeleteClosure(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM closures WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteClosure", query)
+	defer endSpan(span, &err)
+
+	return execOne(ctx, s.Db, query, id, "closure")
+}
+
+// ImportClosures inserts all closures in one transaction
+func (s *Sqlite) ImportClosures(ctx context.Context, closures []types.Closure) (ids []int64, err error) {
+	const query = "INSERT INTO closures (name, kind, start_date, end_date) VALUES (?,?,?,?)"
+	ctx, span := startSpan(ctx, "ImportClosures", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, c := range closures {
+		result, err := stmt.ExecContext(ctx, c.Name, c.Kind, c.StartDate.Format("2006-01-02"), c.EndDate.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+// GetCalendarDays lists every date of the inclusive range with whether it is
+// an instructional day and, if not, why
+func (s *Sqlite) GetCalendarDays(ctx context.Context, from, to time.Time) (days []types.CalendarDay, err error) {
+	query := daysCTE + fmt.Sprintf(`
+SELECT d,
+    CAST(strftime('%%w', d) AS INTEGER) IN (%s),
+    (SELECT h.kind || ': ' || h.name FROM closures h WHERE d BETWEEN h.start_date AND h.end_date ORDER BY h.start_date LIMIT 1),
+    NOT EXISTS (SELECT 1 FROM terms) OR EXISTS (SELECT 1 FROM terms t WHERE d BETWEEN t.start_date AND t.end_date)
+FROM days`, s.weekend)
+	ctx, span := startSpan(ctx, "GetCalendarDays", query)
+	defer endSpan(span, &err)
+
+	days = []types.CalendarDay{}
+	if to.Before(from) {
+		return days, nil
+	}
+	rows, err := s.Db.QueryContext(ctx, query, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			date    string
+			weekend bool
+			closure sql.NullString
+			inTerm  bool
+		)
+		if err := rows.Scan(&date, &weekend, &closure, &inTerm); err != nil {
+			return nil, err
+		}
+		day := types.CalendarDay{Instructional: true}
+		day.Date, err = time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case weekend:
+			day.Instructional, day.Reason = false, "weekend"
+		case closure.Valid:
+			day.Instructional, day.Reason = false, closure.String
+		case !inTerm:
+			day.Instructional, day.Reason = false, "outside term"
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// execOne runs a delete or update that must affect exactly one row
+func execOne(ctx context.Context, db interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, query string, id int64, what string) error {
+	result, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no %s found with id %d", what, id)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestCalendarDays(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// 2025-03-10 is a Monday
+	if _, err := s.CreateClosure(ctx, "Holi", types.ClosureHoliday, date("2025-03-13"), date("2025-03-14")); err != nil {
+		t.Fatal(err)
+	}
+	days, err := s.GetCalendarDays(ctx, date("2025-03-10"), date("2025-03-16"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "", "", "holiday: Holi", "holiday: Holi", "weekend", "weekend"}
+	if len(days) != len(want) {
+		t.Fatalf("Expected %d days, got %d", len(want), len(days))
+	}
+	for i, day := range days {
+		if day.Reason != want[i] || day.Instructional != (want[i] == "") {
+			t.Errorf("%s: expected reason %q, got %+v", day.Date.Format("2006-01-02"), want[i], day)
+		}
+	}
+	if n, err := s.countInstructionalDays(ctx, date("2025-03-10"), date("2025-03-16")); err != nil || n != 3 {
+		t.Errorf("Expected 3 instructional days, got %d (%v)", n, err)
+	}
+
+	// once a term exists, days outside every term are not instructional
+	year, err := s.CreateAcademicYear(ctx, "2024-25", date("2024-06-01"), date("2025-05-31"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateTerm(ctx, year, "Term 2", date("2025-01-06"), date("2025-03-11")); err != nil {
+		t.Fatal(err)
+	}
+	days, err = s.GetCalendarDays(ctx, date("2025-03-11"), date("2025-03-12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !days[0].Instructional || days[1].Instructional || days[1].Reason != "outside term" {
+		t.Errorf("unexpected days around the end of term %+v", days)
+	}
+	if err := s.checkInstructional(ctx, date("2025-03-12")); !errors.Is(err, storage.ErrNonInstructionalDay) {
+		t.Errorf("Expected ErrNonInstructionalDay, got %v", err)
+	}
+
+	// deleting the year removes its terms
+	if err := s.DeleteAcademicYear(ctx, year); err != nil {
+		t.Fatal(err)
+	}
+	if terms, err := s.GetTerms(ctx, 0); err != nil || len(terms) != 0 {
+		t.Errorf("Expected terms deleted with the year, got %+v (%v)", terms, err)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	ids, err := s.ImportClosures(ctx, []types.Closure{
+		{Name: "Winter break", Kind: types.ClosureBreak, StartDate: date("2024-12-23"), EndDate: date("2025-01-01")},
+		{Name: "Founders day", Kind: types.ClosureHoliday, StartDate: date("2025-04-01"), EndDate: date("2025-04-01")},
+	})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("Expected 2 closures imported, got %v (%v)", ids, err)
+	}
+
+	closures, err := s.GetClosures(ctx, date("2025-01-01"), date("2025-03-31"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(closures) != 1 || closures[0].Name != "Winter break" {
+		t.Errorf("Expected only the overlapping closure, got %+v", closures)
+	}
+
+	if err := s.DeleteClosure(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteClosure(ctx, ids[0]); err == nil {
+		t.Error("Expected an error deleting a missing closure")
+	}
+	if closures, _ := s.GetClosures(ctx, date("2024-01-01"), date("2025-12-31")); len(closures) != 1 {
+		t.Errorf("Expected 1 closure left, got %+v", closures)
+	}
+}
@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const checkInColumns = `id, student_id, class_id, period_id, date, session_id, checked_in_at, status,
+    COALESCE(attendance_id, 0), COALESCE(override_status, ''), overridden_at FROM checkins`
+
+// Check-in methods
+
+// CheckIn records a kiosk check-in and marks the student's attendance, both
+// in one transaction. The status is derived from CheckedInAt like any
+// check-in time, against the period or the class's day, and the status set
+// on c is ignored. A student checks in once per day
+// and period: a second check-in fails with storage.ErrConflict, and so does
+// a check-in for a record a teacher already marked, which is left as it is.
+func (s *Sqlite) CheckIn(ctx context.Context, c types.CheckIn) (id int64, err error) {
+	const query = `INSERT INTO checkins (student_id, class_id, period_id, date, session_id, checked_in_at, status, attendance_id)
+VALUES (?,?,?,?,?,?,?,?)`
+	ctx, span := startSpan(ctx, "CheckIn", query)
+	defer endSpan(span, &err)
+
+	if err := s.checkInstructional(ctx, c.Date); err != nil {
+		return 0, err
+	}
+	day := c.Date.Format("2006-01-02")
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if c.PeriodID != 0 {
+		if err := checkPeriod(ctx, tx, c.ClassID, c.PeriodID, c.Date); err != nil {
+			return 0, err
+		}
+	}
+	var checkedIn, marked bool
+	err = tx.QueryRowContext(ctx, `SELECT
+    EXISTS (SELECT 1 FROM checkins WHERE student_id = ? AND date = ? AND period_id = ?),
+    EXISTS (SELECT 1 FROM attendance_records WHERE student_id = ? AND date = ? AND period_id IS ?)`,
+		c.StudentID, day, c.PeriodID, c.StudentID, day, nullableID(c.PeriodID)).Scan(&checkedIn, &marked)
+	if err != nil {
+		return 0, err
+	}
+	if checkedIn {
+		return 0, fmt.Errorf("student %d already checked in on %s: %w", c.StudentID, day, storage.ErrConflict)
+	}
+	if marked {
+		return 0, fmt.Errorf("attendance of student %d on %s is already marked: %w", c.StudentID, day, storage.ErrConflict)
+	}
+
+	p, err := s.punctual(ctx, tx, c.ClassID, c.PeriodID, c.Date, types.AttendanceTimes{CheckInAt: &c.CheckedInAt})
+	if err != nil {
+		return 0, err
+	}
+	if c.Status, err = onLeave(ctx, tx, c.StudentID, c.Date, p.Status); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO attendance_records (student_id, class_id, period_id, date, status, remarks, check_in_at, minutes_late)
+VALUES (?,?,?,?,?,?,?,?)`,
+		c.StudentID, c.ClassID, nullableID(c.PeriodID), day, c.Status, "checked in at "+c.CheckedInAt.Local().Format("15:04"), c.CheckedInAt.UTC(), p.MinutesLate)
+	if err != nil {
+		return 0, err
+	}
+	attendanceID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := recordEvent(ctx, tx, resourceAttendance, actionCreated, attendanceID); err != nil {
+		return 0, err
+	}
+	if c.PeriodID != 0 {
+		if err := s.rollUpDay(ctx, tx, c.StudentID, c.ClassID, c.Date); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err = tx.ExecContext(ctx, query, c.StudentID, c.ClassID, c.PeriodID, day, c.SessionID, c.CheckedInAt.UTC(), c.Status, attendanceID)
+	if err != nil {
+		return 0, conflict(err, fmt.Sprintf("check-in of student %d on %s", c.StudentID, day))
+	}
+	if id, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (s *Sqlite) GetCheckInById(ctx context.Context, id int64) (c types.CheckIn, err error) {
+	const query = "SELECT " + checkInColumns + " WHERE id = ?"
+	ctx, span := startSpan(ctx, "GetCheckInById", query)
+	defer endSpan(span, &err)
+
+	c, err = scanCheckIn(s.Db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return c, fmt.Errorf("no check-in found with id %d", id)
+	}
+	return c, err
+}
+
+func (s *Sqlite) GetCheckIns(ctx context.Context, filter types.CheckInFilter) (checkIns []types.CheckIn, err error) {
+	var where []string
+	var args []any
+	if filter.StudentID != 0 {
+		where = append(where, "student_id = ?")
+		args = append(args, filter.StudentID)
+	}
+	if filter.ClassID != 0 {
+		where = append(where, "class_id = ?")
+		args = append(args, filter.ClassID)
+	}
+	if filter.SessionID != "" {
+		where = append(where, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if !filter.Date.IsZero() {
+		where = append(where, "date = ?")
+		args = append(args, filter.Date.Format("2006-01-02"))
+	}
+	query := "SELECT " + checkInColumns
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY checked_in_at, id"
+	ctx, span := startSpan(ctx, "GetCheckIns", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkIns = []types.CheckIn{}
+	for rows.Next() {
+		c, err := scanCheckIn(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkIns = append(checkIns, c)
+	}
+	return checkIns, rows.Err()
+}
+
+// OverrideCheckIn lets a teacher change the attendance a check-in marked,
+// for a late bus or a student who checked in and left. The check-in keeps
+// the status it recorded and notes the override. Empty remarks keep the
+// check-in time on the record.
+func (s *Sqlite) OverrideCheckIn(ctx context.Context, id int64, status, remarks string) (err error) {
+	const query = "UPDATE checkins SET override_status = ?, overridden_at = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "OverrideCheckIn", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := scanCheckIn(tx.QueryRowContext(ctx, "SELECT "+checkInColumns+" WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no check-in found with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE attendance_records SET status = ?, remarks = COALESCE(NULLIF(?, ''), remarks) WHERE id = ?",
+		status, remarks, c.AttendanceID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("attendance record of check-in %d no longer exists", id)
+	}
+	if err := recordEvent(ctx, tx, resourceAttendance, actionUpdated, c.AttendanceID); err != nil {
+		return err
+	}
+	if c.PeriodID != 0 {
+		if err := s.rollUpDay(ctx, tx, c.StudentID, c.ClassID, c.Date); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, status, time.Now().UTC(), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetBadgeVersion returns the version of a student's current badge
+func (s *Sqlite) GetBadgeVersion(ctx context.Context, studentID int64) (version int64, err error) {
+	const query = "SELECT badge_version FROM students WHERE id = ?"
+	ctx, span := startSpan(ctx, "GetBadgeVersion", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, studentID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no student found with id %d", studentID)
+	}
+	return version, err
+}
+
+// RevokeBadge bumps a student's badge version, so the badges printed
+// before stop checking in, and returns the new version
+func (s *Sqlite) RevokeBadge(ctx context.Context, studentID int64) (version int64, err error) {
+	const query = "UPDATE students SET badge_version = badge_version + 1 WHERE id = ? RETURNING badge_version"
+	ctx, span := startSpan(ctx, "RevokeBadge", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, studentID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no student found with id %d", studentID)
+	}
+	return version, err
+}
+
+func scanCheckIn(row interface{ Scan(...any) error }) (types.CheckIn, error) {
+	var c types.CheckIn
+	var overriddenAt sql.NullTime
+	err := row.Scan(&c.Id, &c.StudentID, &c.ClassID, &c.PeriodID, &c.Date, &c.SessionID, &c.CheckedInAt, &c.Status,
+		&c.AttendanceID, &c.OverrideStatus, &overriddenAt)
+	if overriddenAt.Valid {
+		c.OverriddenAt = &overriddenAt.Time
+	}
+	return c, err
+}
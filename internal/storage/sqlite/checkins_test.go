@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRevokeBadge(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	id, err := s.CreateStudent(ctx, "Asha Rao", "asha@example.com", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := s.GetBadgeVersion(ctx, id); err != nil || version != 1 {
+		t.Fatalf("expected badge version 1, got %d (%v)", version, err)
+	}
+	if version, err := s.RevokeBadge(ctx, id); err != nil || version != 2 {
+		t.Fatalf("expected badge version 2 after a revoke, got %d (%v)", version, err)
+	}
+	if version, err := s.GetBadgeVersion(ctx, id); err != nil || version != 2 {
+		t.Errorf("expected badge version 2, got %d (%v)", version, err)
+	}
+
+	if _, err := s.GetBadgeVersion(ctx, id+1); err == nil {
+		t.Error("expected an error for an unknown student")
+	}
+	if _, err := s.RevokeBadge(ctx, id+1); err == nil {
+		t.Error("expected an error revoking the badge of an unknown student")
+	}
+}
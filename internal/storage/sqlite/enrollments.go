@@ -0,0 +1,188 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const enrollmentColumns = `e.id, e.student_id, e.class_id, e.subject_id, e.start_date, e.end_date, e.status,
+    COALESCE(c.name, ''), COALESCE(sj.name, '')
+FROM enrollments e
+LEFT JOIN classes c ON c.id = e.class_id
+LEFT JOIN subjects sj ON sj.id = e.subject_id`
+
+// Enrollment methods
+
+// CreateEnrollment enrolls a student in a subject section. The class must
+// have room under its capacity and the student must not already be actively
+// enrolled in the same class and subject. A zero endDate leaves it open-ended.
+func (s *Sqlite) CreateEnrollment(ctx context.Context, studentID, classID, subjectID int64, startDate, endDate time.Time) (id int64, err error) {
+	const query = "INSERT INTO enrollments (student_id, class_id, subject_id, start_date, end_date, status) VALUES (?,?,?,?,?,'active')"
+	ctx, span := startSpan(ctx, "CreateEnrollment", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, ref := range []struct {
+		table string
+		id    int64
+	}{{"students", studentID}, {"subjects", subjectID}} {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+ref.table+" WHERE id = ?)", ref.id).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if !exists {
+			return 0, fmt.Errorf("no %s found with id %d", strings.TrimSuffix(ref.table, "s"), ref.id)
+		}
+	}
+	if err := checkCapacity(ctx, tx, classID); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, studentID, classID, subjectID, startDate.Format("2006-01-02"), nullableDate(endDate))
+	if err != nil {
+		return 0, conflict(err, fmt.Sprintf("active enrollment of student %d in class %d", studentID, classID))
+	}
+	if id, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// checkCapacity fails with storage.ErrClassFull when the class has no room
+// for another active enrollment
+func checkCapacity(ctx context.Context, tx *sql.Tx, classID int64) error {
+	var capacity, active int
+	err := tx.QueryRowContext(ctx, `SELECT capacity, (SELECT COUNT(*) FROM enrollments WHERE class_id = classes.id AND status = 'active')
+FROM classes WHERE id = ?`, classID).Scan(&capacity, &active)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no class found with id %d", classID)
+	}
+	if err != nil {
+		return err
+	}
+	if capacity > 0 && active >= capacity {
+		return fmt.Errorf("class %d has %d of %d places taken: %w", classID, active, capacity, storage.ErrClassFull)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetEnrollmentById(ctx context.Context, id int64) (enrollment types.Enrollment, err error) {
+	const query = "select " + enrollmentColumns + " where e.id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetEnrollmentById", query)
+	defer endSpan(span, &err)
+
+	enrollment, err = scanEnrollment(s.Db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Enrollment{}, fmt.Errorf("no enrollment found with id %d", id)
+		}
+		return types.Enrollment{}, fmt.Errorf("query error %w", err)
+	}
+	return enrollment, nil
+}
+
+func (s *Sqlite) GetEnrollments(ctx context.Context, filter types.EnrollmentFilter) (enrollments []types.Enrollment, err error) {
+	var where []string
+	var args []any
+	for _, f := range []struct {
+		column string
+		value  int64
+	}{{"e.student_id", filter.StudentID}, {"e.class_id", filter.ClassID}, {"e.subject_id", filter.SubjectID}} {
+		if f.value != 0 {
+			where = append(where, f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+	if filter.Status != "" {
+		where = append(where, "e.status = ?")
+		args = append(args, filter.Status)
+	}
+	query := "select " + enrollmentColumns
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY e.start_date, e.id"
+	ctx, span := startSpan(ctx, "GetEnrollments", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments = []types.Enrollment{}
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	return enrollments, rows.Err()
+}
+
+// UpdateEnrollment sets an enrollment's status and end date; dropping a
+// student is an update to dropped with the last day. Reactivating an
+// enrollment needs a free place in the class again.
+func (s *Sqlite) UpdateEnrollment(ctx context.Context, id int64, status string, endDate time.Time) (err error) {
+	const query = "UPDATE enrollments SET status = ?, end_date = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateEnrollment", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var classID int64
+	var current string
+	err = tx.QueryRowContext(ctx, "SELECT class_id, status FROM enrollments WHERE id = ?", id).Scan(&classID, &current)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no enrollment found with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+	if status == types.EnrollmentActive && current != types.EnrollmentActive {
+		if err := checkCapacity(ctx, tx, classID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, query, status, nullableDate(endDate), id); err != nil {
+		return conflict(err, fmt.Sprintf("active enrollment in class %d", classID))
+	}
+	return tx.Commit()
+}
+
+func scanEnrollment(row interface{ Scan(...any) error }) (types.Enrollment, error) {
+	var enrollment types.Enrollment
+	var endDate sql.NullTime
+	err := row.Scan(&enrollment.Id, &enrollment.StudentID, &enrollment.ClassID, &enrollment.SubjectID,
+		&enrollment.StartDate, &endDate, &enrollment.Status, &enrollment.ClassName, &enrollment.SubjectName)
+	if endDate.Valid {
+		enrollment.EndDate = &endDate.Time
+	}
+	return enrollment, err
+}
+
+// nullableDate stores the zero time as NULL
+func nullableDate(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
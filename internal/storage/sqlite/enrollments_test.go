@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// enrollmentFixture creates a class with room for one, a subject and two
+// students
+func enrollmentFixture(t *testing.T, s *Sqlite) (classID, subjectID, asha, ben int64) {
+	t.Helper()
+	ctx := context.Background()
+	var err error
+	if classID, err = s.CreateClass(ctx, "5A", "5", "A", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if subjectID, err = s.CreateSubject(ctx, "Maths", "MATH"); err != nil {
+		t.Fatal(err)
+	}
+	if asha, err = s.CreateStudent(ctx, "Asha Rao", "asha@example.com", 10); err != nil {
+		t.Fatal(err)
+	}
+	if ben, err = s.CreateStudent(ctx, "Ben Okafor", "ben@example.com", 10); err != nil {
+		t.Fatal(err)
+	}
+	return classID, subjectID, asha, ben
+}
+
+func TestCreateEnrollmentCapacity(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	classID, subjectID, asha, ben := enrollmentFixture(t, s)
+
+	first, err := s.CreateEnrollment(ctx, asha, classID, subjectID, date("2025-03-10"), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateEnrollment(ctx, ben, classID, subjectID, date("2025-03-10"), time.Time{}); !errors.Is(err, storage.ErrClassFull) {
+		t.Errorf("Expected ErrClassFull, got %v", err)
+	}
+
+	// a dropped enrollment frees its place, and taking it back needs one
+	if err := s.UpdateEnrollment(ctx, first, types.EnrollmentDropped, date("2025-03-14")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateEnrollment(ctx, ben, classID, subjectID, date("2025-03-17"), time.Time{}); err != nil {
+		t.Fatalf("expected the freed place to be taken, got %v", err)
+	}
+	if err := s.UpdateEnrollment(ctx, first, types.EnrollmentActive, time.Time{}); !errors.Is(err, storage.ErrClassFull) {
+		t.Errorf("Expected ErrClassFull reactivating into a full class, got %v", err)
+	}
+	if e, err := s.GetEnrollmentById(ctx, first); err != nil || e.Status != types.EnrollmentDropped {
+		t.Errorf("expected the enrollment to stay dropped, got %+v (%v)", e, err)
+	}
+}
+
+func TestCreateEnrollmentDuplicate(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	classID, subjectID, asha, _ := enrollmentFixture(t, s)
+	if err := s.UpdateClass(ctx, classID, "5A", "5", "A", 0, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.CreateEnrollment(ctx, asha, classID, subjectID, date("2025-03-10"), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateEnrollment(ctx, asha, classID, subjectID, date("2025-03-17"), time.Time{}); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+}
+
+func TestDropEnrollmentSetsEndDate(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	classID, subjectID, asha, _ := enrollmentFixture(t, s)
+
+	id, err := s.CreateEnrollment(ctx, asha, classID, subjectID, date("2025-03-10"), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := s.GetEnrollmentById(ctx, id); err != nil || e.EndDate != nil {
+		t.Fatalf("expected an open-ended enrollment, got %+v (%v)", e, err)
+	}
+	if err := s.UpdateEnrollment(ctx, id, types.EnrollmentDropped, date("2025-03-14")); err != nil {
+		t.Fatal(err)
+	}
+	e, err := s.GetEnrollmentById(ctx, id)
+	if err != nil || e.Status != types.EnrollmentDropped || e.EndDate == nil || !e.EndDate.Equal(date("2025-03-14")) {
+		t.Errorf("expected a dropped enrollment ending 2025-03-14, got %+v (%v)", e, err)
+	}
+}
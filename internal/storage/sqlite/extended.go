@@ -1,35 +1,60 @@
 package sqlite
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/tukesh1/student-api/internal/types"
 )
 
+// classColumns reads the teacher name from the teacher, falling back to the
+// free-text column of classes created before teachers existed
+const classColumns = `c.id, c.name, c.grade, c.section, COALESCE(c.teacher_id, 0), COALESCE(t.name, c.teacher_name, ''), c.capacity,
+    c.late_grace_minutes, c.early_leave_grace_minutes
+FROM classes c LEFT JOIN teachers t ON t.id = c.teacher_id`
+
 // Class methods
-func (s *Sqlite) CreateClass(name, grade, section, teacherName string) (int64, error) {
-	stmt, err := s.Db.Prepare("INSERT INTO classes (name, grade, section, teacher_name) VALUES (?,?,?,?)")
+func (s *Sqlite) CreateClass(ctx context.Context, name, grade, section string, teacherID int64, capacity int) (id int64, err error) {
+	const query = "INSERT INTO classes (name, grade, section, teacher_id, capacity) VALUES (?,?,?,?,?)"
+	ctx, span := startSpan(ctx, "CreateClass", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
-	result, err := stmt.Exec(name, grade, section, teacherName)
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, name, grade, section, nullableID(teacherID), capacity)
 	if err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+	if id, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+	if err := recordEvent(ctx, tx, resourceClass, actionCreated, id); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
 }
 
-func (s *Sqlite) GetClassById(id int64) (types.Class, error) {
-	stmt, err := s.Db.Prepare("select id, name, grade, section, teacher_name from classes where id = ? LIMIT 1")
+func (s *Sqlite) GetClassById(ctx context.Context, id int64) (class types.Class, err error) {
+	const query = "select " + classColumns + " where c.id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetClassById", query)
+	defer endSpan(span, &err)
+
+	stmt, err := s.Db.PrepareContext(ctx, query)
 	if err != nil {
 		return types.Class{}, err
 	}
 	defer stmt.Close()
-	var class types.Class
-	err = stmt.QueryRow(id).Scan(&class.Id, &class.Name, &class.Grade, &class.Section, &class.TeacherName)
+	class, err = scanClass(stmt.QueryRowContext(ctx, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return types.Class{}, fmt.Errorf("no class found with id %d", id)
@@ -39,27 +64,92 @@ func (s *Sqlite) GetClassById(id int64) (types.Class, error) {
 	return class, nil
 }
 
-func (s *Sqlite) GetClasses() ([]types.Class, error) {
-	rows, err := s.Db.Query("select id, name, grade, section, teacher_name from classes")
+func (s *Sqlite) GetClasses(ctx context.Context, filter types.ClassFilter) (classes []types.Class, err error) {
+	query, args := classesQuery(filter)
+	ctx, span := startSpan(ctx, "GetClasses", query)
+	defer endSpan(span, &err)
+
+	err = s.eachClass(ctx, query, args, func(class types.Class) error {
+		classes = append(classes, class)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return classes, nil
+}
+
+// StreamClasses calls fn for each matching class; an error from fn stops the iteration
+func (s *Sqlite) StreamClasses(ctx context.Context, filter types.ClassFilter, fn func(types.Class) error) (err error) {
+	query, args := classesQuery(filter)
+	ctx, span := startSpan(ctx, "StreamClasses", query)
+	defer endSpan(span, &err)
+
+	return s.eachClass(ctx, query, args, fn)
+}
+
+func classesQuery(filter types.ClassFilter) (string, []any) {
+	var where []string
+	var args []any
+	if filter.Grade != "" {
+		where = append(where, "c.grade = ?")
+		args = append(args, filter.Grade)
+	}
+	if filter.Section != "" {
+		where = append(where, "c.section = ?")
+		args = append(args, filter.Section)
+	}
+	if filter.TeacherID != 0 {
+		where = append(where, "c.teacher_id = ?")
+		args = append(args, filter.TeacherID)
+	}
+	query := "select " + classColumns
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	return query + " ORDER BY c.id", args
+}
+
+func (s *Sqlite) eachClass(ctx context.Context, query string, args []any, fn func(types.Class) error) error {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
-	var classes []types.Class
 
 	for rows.Next() {
-		var class types.Class
-		err := rows.Scan(&class.Id, &class.Name, &class.Grade, &class.Section, &class.TeacherName)
+		class, err := scanClass(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(class); err != nil {
+			return err
 		}
-		classes = append(classes, class)
 	}
-	return classes, nil
+	return rows.Err()
 }
 
-func (s *Sqlite) UpdateClass(id int64, name, grade, section, teacherName string) error {
-	result, err := s.Db.Exec("UPDATE classes SET name = ?, grade = ?, section = ?, teacher_name = ? WHERE id = ?", name, grade, section, teacherName, id)
+func scanClass(row interface{ Scan(...any) error }) (types.Class, error) {
+	var class types.Class
+	err := row.Scan(&class.Id, &class.Name, &class.Grade, &class.Section, &class.TeacherID, &class.TeacherName, &class.Capacity,
+		&class.LateGraceMinutes, &class.EarlyLeaveGraceMinutes)
+	return class, err
+}
+
+// UpdateClass updates a class. Lowering the capacity below the active
+// enrollments keeps them and only stops new ones.
+func (s *Sqlite) UpdateClass(ctx context.Context, id int64, name, grade, section string, teacherID int64, capacity int) (err error) {
+	const query = "UPDATE classes SET name = ?, grade = ?, section = ?, teacher_id = ?, teacher_name = NULL, capacity = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateClass", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, name, grade, section, nullableID(teacherID), capacity, id)
 	if err != nil {
 		return err
 	}
@@ -70,49 +160,393 @@ func (s *Sqlite) UpdateClass(id int64, name, grade, section, teacherName string)
 	if rowsAffected == 0 {
 		return fmt.Errorf("no class found with id %d", id)
 	}
-	return nil
+	if err := recordEvent(ctx, tx, resourceClass, actionUpdated, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *Sqlite) DeleteClass(id int64) error {
-	result, err := s.Db.Exec("DELETE FROM classes WHERE id = ?", id)
+func (s *Sqlite) DeleteClass(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM classes WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteClass", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	if err := recordEvent(ctx, tx, resourceClass, actionDeleted, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no class found with id %d", id)
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetClassGrace sets the grace the class allows for checking in late and
+// leaving early, in minutes; nil falls back to the school's. Records
+// already marked keep the minutes they were given.
+func (s *Sqlite) SetClassGrace(ctx context.Context, id int64, lateMinutes, earlyLeaveMinutes *int) (err error) {
+	const query = "UPDATE classes SET late_grace_minutes = ?, early_leave_grace_minutes = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "SetClassGrace", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, lateMinutes, earlyLeaveMinutes, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
 		return fmt.Errorf("no class found with id %d", id)
 	}
-	return nil
+	if err := recordEvent(ctx, tx, resourceClass, actionUpdated, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// Basic attendance methods (for demo purposes)
-func (s *Sqlite) CreateAttendanceRecord(studentID, classID int64, date time.Time, status, remarks string) (int64, error) {
-	result, err := s.Db.Exec("INSERT INTO attendance_records (student_id, class_id, date, status, remarks) VALUES (?,?,?,?,?)", studentID, classID, date.Format("2006-01-02"), status, remarks)
+const attendanceColumns = `id, student_id, class_id, COALESCE(period_id, 0), date, status, COALESCE(remarks, ''),
+    check_in_at, check_out_at, minutes_late, minutes_early`
+
+// Attendance methods
+
+// CreateAttendanceRecord marks a student for the day. Check-in and
+// check-out times are classified against the class's day; an empty status
+// takes the one derived from the check-in, and a given one is kept.
+func (s *Sqlite) CreateAttendanceRecord(ctx context.Context, studentID, classID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (id int64, err error) {
+	const query = `INSERT INTO attendance_records (student_id, class_id, date, status, remarks, check_in_at, check_out_at, minutes_late, minutes_early)
+VALUES (?,?,?,?,?,?,?,?,?)`
+	ctx, span := startSpan(ctx, "CreateAttendanceRecord", query)
+	defer endSpan(span, &err)
+
+	if err := s.checkInstructional(ctx, date); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	p, err := s.punctual(ctx, tx, classID, 0, date, times)
 	if err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+	if status, err = onLeave(ctx, tx, studentID, date, cmp.Or(status, p.Status)); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, query, studentID, classID, date.Format("2006-01-02"), status, remarks,
+		nullableTime(times.CheckInAt), nullableTime(times.CheckOutAt), p.MinutesLate, p.MinutesEarly)
+	if err != nil {
+		return 0, err
+	}
+	if id, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+	if err := recordEvent(ctx, tx, resourceAttendance, actionCreated, id); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (s *Sqlite) GetAttendanceByDate(ctx context.Context, classID int64, date time.Time) ([]types.AttendanceRecord, error) {
+	return s.GetAttendance(ctx, types.AttendanceFilter{ClassID: classID, From: date, To: date})
+}
+
+func (s *Sqlite) GetAttendanceByStudent(ctx context.Context, studentID int64, startDate, endDate time.Time) ([]types.AttendanceRecord, error) {
+	return s.GetAttendance(ctx, types.AttendanceFilter{StudentID: studentID, From: startDate, To: endDate})
+}
+
+func (s *Sqlite) GetAttendance(ctx context.Context, filter types.AttendanceFilter) (records []types.AttendanceRecord, err error) {
+	query, args := attendanceQuery(filter)
+	ctx, span := startSpan(ctx, "GetAttendance", query)
+	defer endSpan(span, &err)
+
+	records = []types.AttendanceRecord{}
+	err = s.eachAttendance(ctx, query, args, func(record types.AttendanceRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// StreamAttendance calls fn for each matching record; an error from fn stops the iteration
+func (s *Sqlite) StreamAttendance(ctx context.Context, filter types.AttendanceFilter, fn func(types.AttendanceRecord) error) (err error) {
+	query, args := attendanceQuery(filter)
+	ctx, span := startSpan(ctx, "StreamAttendance", query)
+	defer endSpan(span, &err)
+
+	return s.eachAttendance(ctx, query, args, fn)
+}
+
+func attendanceQuery(filter types.AttendanceFilter) (string, []any) {
+	var where []string
+	var args []any
+	if filter.StudentID != 0 {
+		where = append(where, "student_id = ?")
+		args = append(args, filter.StudentID)
+	}
+	if filter.ClassID != 0 {
+		where = append(where, "class_id = ?")
+		args = append(args, filter.ClassID)
+	}
+	switch {
+	case filter.PeriodID != 0:
+		where = append(where, "period_id = ?")
+		args = append(args, filter.PeriodID)
+	case filter.Scope == types.ScopePeriod:
+		where = append(where, "period_id IS NOT NULL")
+	case filter.Scope != types.ScopeAll:
+		where = append(where, "period_id IS NULL")
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "date >= ?")
+		args = append(args, filter.From.Format("2006-01-02"))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "date <= ?")
+		args = append(args, filter.To.Format("2006-01-02"))
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	query := "select " + attendanceColumns + " from attendance_records"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	return query + " ORDER BY date, class_id, student_id, period_id", args
 }
 
-func (s *Sqlite) GetAttendanceByDate(classID int64, date time.Time) ([]types.AttendanceRecord, error) {
-	return []types.AttendanceRecord{}, nil
+func (s *Sqlite) eachAttendance(ctx context.Context, query string, args []any, fn func(types.AttendanceRecord) error) error {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanAttendance(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
-func (s *Sqlite) GetAttendanceByStudent(studentID int64, startDate, endDate time.Time) ([]types.AttendanceRecord, error) {
-	return []types.AttendanceRecord{}, nil
+func scanAttendance(row interface{ Scan(...any) error }) (types.AttendanceRecord, error) {
+	var record types.AttendanceRecord
+	err := row.Scan(&record.Id, &record.StudentID, &record.ClassID, &record.PeriodID, &record.Date, &record.Status, &record.Remarks,
+		&record.CheckInAt, &record.CheckOutAt, &record.MinutesLate, &record.MinutesEarly)
+	return record, err
 }
 
-func (s *Sqlite) UpdateAttendanceRecord(id int64, status, remarks string) error {
-	return nil
+func (s *Sqlite) GetAttendanceById(ctx context.Context, id int64) (record types.AttendanceRecord, err error) {
+	const query = "select " + attendanceColumns + " from attendance_records where id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetAttendanceById", query)
+	defer endSpan(span, &err)
+
+	record, err = scanAttendance(s.Db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.AttendanceRecord{}, fmt.Errorf("no attendance record found with id %d", id)
+		}
+		return types.AttendanceRecord{}, fmt.Errorf("query error %w", err)
+	}
+	return record, nil
 }
 
-func (s *Sqlite) DeleteAttendanceRecord(id int64) error {
-	return nil
+// UpdateAttendanceRecord changes a record; changing a period record rolls
+// the daily record of its student up again. Times left nil keep the
+// recorded ones, and the minutes late and early are worked out again. An
+// empty status is derived from a new check-in time, and kept otherwise, so
+// a status set by hand survives recording a check-out.
+func (s *Sqlite) UpdateAttendanceRecord(ctx context.Context, id int64, status, remarks string, times types.AttendanceTimes) (err error) {
+	const query = `UPDATE attendance_records SET status = ?, remarks = ?, check_in_at = ?, check_out_at = ?, minutes_late = ?, minutes_early = ?
+WHERE id = ?`
+	ctx, span := startSpan(ctx, "UpdateAttendanceRecord", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	record, err := scanAttendance(tx.QueryRowContext(ctx, "select "+attendanceColumns+" from attendance_records where id = ?", id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no attendance record found with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+	checkedIn := times.CheckInAt != nil
+	times.CheckInAt = cmp.Or(times.CheckInAt, record.CheckInAt)
+	times.CheckOutAt = cmp.Or(times.CheckOutAt, record.CheckOutAt)
+
+	p, err := s.punctual(ctx, tx, record.ClassID, record.PeriodID, record.Date, times)
+	if err != nil {
+		return err
+	}
+	if status == "" && checkedIn {
+		status = p.Status
+	}
+	if status, err = onLeave(ctx, tx, record.StudentID, record.Date, cmp.Or(status, record.Status)); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, status, remarks,
+		nullableTime(times.CheckInAt), nullableTime(times.CheckOutAt), p.MinutesLate, p.MinutesEarly, id)
+	if err != nil {
+		return err
+	}
+	if err := recordEvent(ctx, tx, resourceAttendance, actionUpdated, id); err != nil {
+		return err
+	}
+	if record.PeriodID != 0 {
+		if err := s.rollUpDay(ctx, tx, record.StudentID, record.ClassID, record.Date); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteAttendanceRecord deletes a record; deleting a period record rolls
+// the daily record of its student up again
+func (s *Sqlite) DeleteAttendanceRecord(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM attendance_records WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteAttendanceRecord", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var record types.AttendanceRecord
+	err = tx.QueryRowContext(ctx, "SELECT student_id, class_id, COALESCE(period_id, 0), date FROM attendance_records WHERE id = ?", id).
+		Scan(&record.StudentID, &record.ClassID, &record.PeriodID, &record.Date)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no attendance record found with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := recordEvent(ctx, tx, resourceAttendance, actionDeleted, id); err != nil {
+		return err
+	}
+	if err := tombstone(ctx, tx, id, time.Now().UTC().Format(syncTimeFormat)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+	if record.PeriodID != 0 {
+		if err := s.rollUpDay(ctx, tx, record.StudentID, record.ClassID, record.Date); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAttendanceReport summarises a student's attendance between two
+// inclusive dates. A zero start date falls back to the student's first
+// record and a zero end date to today. Only instructional days count, and
+// they are the denominator of the rate less the excused days; late counts
+// as attended. Minutes late and left early are totals over the same days.
+func (s *Sqlite) GetAttendanceReport(ctx context.Context, studentID int64, startDate, endDate time.Time) (report types.AttendanceReport, err error) {
+	query := `SELECT s.id, s.name, COALESCE(c.name, ''),
+    COALESCE(MIN(date(a.date)), ''),
+    COALESCE(SUM(a.status = 'Present'), 0),
+    COALESCE(SUM(a.status = 'Absent'), 0),
+    COALESCE(SUM(a.status = 'Excused'), 0),
+    COALESCE(SUM(a.status = 'Late'), 0),
+    COALESCE(SUM(a.minutes_late), 0),
+    COALESCE(SUM(a.minutes_early > 0), 0),
+    COALESCE(SUM(a.minutes_early), 0)
+FROM students s
+LEFT JOIN classes c ON c.id = s.class_id
+LEFT JOIN attendance_records a ON a.student_id = s.id AND a.period_id IS NULL AND a.date BETWEEN ? AND ? AND ` + s.instructional("a.date") + `
+WHERE s.id = ?
+GROUP BY s.id`
+	ctx, span := startSpan(ctx, "GetAttendanceReport", query)
+	defer endSpan(span, &err)
+
+	from, to := "0001-01-01", "9999-12-31"
+	if !startDate.IsZero() {
+		from = startDate.Format("2006-01-02")
+	}
+	if !endDate.IsZero() {
+		to = endDate.Format("2006-01-02")
+	}
+
+	var firstRecord string
+	err = s.Db.QueryRowContext(ctx, query, from, to, studentID).Scan(
+		&report.StudentID, &report.StudentName, &report.ClassName,
+		&firstRecord, &report.PresentDays, &report.UnexcusedDays, &report.ExcusedDays, &report.LateDays,
+		&report.MinutesLate, &report.EarlyDepartures, &report.MinutesLeftEarly,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.AttendanceReport{}, fmt.Errorf("no student found with id %d", studentID)
+		}
+		return types.AttendanceReport{}, fmt.Errorf("query error %w", err)
+	}
+
+	if startDate.IsZero() && firstRecord != "" {
+		startDate, _ = time.Parse("2006-01-02", firstRecord)
+	}
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+	if !startDate.IsZero() {
+		report.TotalDays, err = s.countInstructionalDays(ctx, startDate, endDate)
+		if err != nil {
+			return types.AttendanceReport{}, err
+		}
+	}
+	report.AbsentDays = report.UnexcusedDays + report.ExcusedDays
+	if days := report.TotalDays - report.ExcusedDays; days > 0 {
+		rate := float64(report.PresentDays+report.LateDays) / float64(days) * 100
+		report.AttendanceRate = math.Round(min(rate, 100)*100) / 100
+	}
+	return report, nil
 }
 
-func (s *Sqlite) GetAttendanceReport(studentID int64, startDate, endDate time.Time) (types.AttendanceReport, error) {
-	return types.AttendanceReport{}, nil
+// Stats methods
+func (s *Sqlite) GetStats(ctx context.Context, date time.Time) (stats types.Stats, err error) {
+	const query = `SELECT
+    (SELECT COUNT(*) FROM students),
+    (SELECT COUNT(*) FROM classes),
+    (SELECT COUNT(DISTINCT student_id) FROM attendance_records WHERE date = ? AND period_id IS NULL)`
+	ctx, span := startSpan(ctx, "GetStats", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, date.Format("2006-01-02")).
+		Scan(&stats.TotalStudents, &stats.TotalClasses, &stats.MarkedToday)
+	if err != nil {
+		return types.Stats{}, fmt.Errorf("query error %w", err)
+	}
+	return stats, nil
 }
@@ -0,0 +1,298 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const guardianColumns = "g.id, g.name, COALESCE(g.email, ''), COALESCE(g.phone, ''), COALESCE(g.alt_phone, ''), g.preferred_channel"
+
+// Guardian methods
+func (s *Sqlite) CreateGuardian(ctx context.Context, guardian types.Guardian) (id int64, err error) {
+	const query = "INSERT INTO guardians (name, email, phone, alt_phone, preferred_channel) VALUES (?,?,?,?,?)"
+	ctx, span := startSpan(ctx, "CreateGuardian", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, guardian.Name, nullableString(guardian.Email), guardian.Phone, guardian.AltPhone, channel(guardian))
+	if err != nil {
+		return 0, conflict(err, "guardian email "+guardian.Email)
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetGuardianById(ctx context.Context, id int64) (guardian types.Guardian, err error) {
+	const query = "select " + guardianColumns + " from guardians g where g.id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetGuardianById", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, id).Scan(&guardian.Id, &guardian.Name, &guardian.Email, &guardian.Phone, &guardian.AltPhone, &guardian.PreferredChannel)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Guardian{}, fmt.Errorf("no guardian found with id %d", id)
+		}
+		return types.Guardian{}, fmt.Errorf("query error %w", err)
+	}
+	return guardian, nil
+}
+
+func (s *Sqlite) GetGuardians(ctx context.Context) (guardians []types.Guardian, err error) {
+	const query = "select " + guardianColumns + " from guardians g ORDER BY g.name, g.id"
+	ctx, span := startSpan(ctx, "GetGuardians", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians = []types.Guardian{}
+	for rows.Next() {
+		var guardian types.Guardian
+		if err := rows.Scan(&guardian.Id, &guardian.Name, &guardian.Email, &guardian.Phone, &guardian.AltPhone, &guardian.PreferredChannel); err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, guardian)
+	}
+	return guardians, rows.Err()
+}
+
+// UpdateGuardian replaces a guardian's contact details; the password is
+// left alone
+func (s *Sqlite) UpdateGuardian(ctx context.Context, id int64, guardian types.Guardian) (err error) {
+	const query = "UPDATE guardians SET name = ?, email = ?, phone = ?, alt_phone = ?, preferred_channel = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateGuardian", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, guardian.Name, nullableString(guardian.Email), guardian.Phone, guardian.AltPhone, channel(guardian), id)
+	if err != nil {
+		return conflict(err, "guardian email "+guardian.Email)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no guardian found with id %d", id)
+	}
+	return nil
+}
+
+// DeleteGuardian deletes a guardian with their student links and sessions
+func (s *Sqlite) DeleteGuardian(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM guardians WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteGuardian", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"student_guardians", "guardian_sessions"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE guardian_id = ?", id); err != nil {
+			return err
+		}
+	}
+	if err := execOne(ctx, tx, query, id, "guardian"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetStudentGuardians lists a student's guardians, primary contact first
+func (s *Sqlite) GetStudentGuardians(ctx context.Context, studentID int64) (guardians []types.StudentGuardian, err error) {
+	const query = "select " + guardianColumns + `, sg.student_id, sg.relationship, sg.is_primary
+FROM student_guardians sg JOIN guardians g ON g.id = sg.guardian_id
+WHERE sg.student_id = ?
+ORDER BY sg.is_primary DESC, g.name`
+	ctx, span := startSpan(ctx, "GetStudentGuardians", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians = []types.StudentGuardian{}
+	for rows.Next() {
+		var g types.StudentGuardian
+		err := rows.Scan(&g.Id, &g.Name, &g.Email, &g.Phone, &g.AltPhone, &g.PreferredChannel, &g.StudentID, &g.Relationship, &g.IsPrimary)
+		if err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, g)
+	}
+	return guardians, rows.Err()
+}
+
+// LinkGuardian links a guardian to a student, or updates the relationship of
+// an existing link. Making a guardian primary demotes the student's previous
+// primary contact.
+func (s *Sqlite) LinkGuardian(ctx context.Context, studentID, guardianID int64, relationship string, primary bool) (err error) {
+	const query = `INSERT INTO student_guardians (student_id, guardian_id, relationship, is_primary) VALUES (?,?,?,?)
+ON CONFLICT(student_id, guardian_id) DO UPDATE SET relationship = excluded.relationship, is_primary = excluded.is_primary`
+	ctx, span := startSpan(ctx, "LinkGuardian", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, ref := range []struct {
+		table, what string
+		id          int64
+	}{{"students", "student", studentID}, {"guardians", "guardian", guardianID}} {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+ref.table+" WHERE id = ?)", ref.id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("no %s found with id %d", ref.what, ref.id)
+		}
+	}
+	if primary {
+		if _, err := tx.ExecContext(ctx, "UPDATE student_guardians SET is_primary = 0 WHERE student_id = ? AND guardian_id != ?", studentID, guardianID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, studentID, guardianID, relationship, primary); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Sqlite) UnlinkGuardian(ctx context.Context, studentID, guardianID int64) (err error) {
+	const query = "DELETE FROM student_guardians WHERE student_id = ? AND guardian_id = ?"
+	ctx, span := startSpan(ctx, "UnlinkGuardian", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, studentID, guardianID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("guardian %d is not linked to student %d", guardianID, studentID)
+	}
+	return nil
+}
+
+// GetGuardianStudents lists the students a guardian is linked to
+func (s *Sqlite) GetGuardianStudents(ctx context.Context, guardianID int64) (students []types.Student, err error) {
+	const query = `select s.id, s.name, s.email, s.age, COALESCE(s.class_id, 0), COALESCE(s.roll_no, ''), COALESCE(s.photo_url, '')
+FROM student_guardians sg JOIN students s ON s.id = sg.student_id
+WHERE sg.guardian_id = ?
+ORDER BY s.name, s.id`
+	ctx, span := startSpan(ctx, "GetGuardianStudents", query)
+	defer endSpan(span, &err)
+
+	students = []types.Student{}
+	err = s.eachStudent(ctx, query, []any{guardianID}, func(student types.Student) error {
+		students = append(students, student)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
+// SetGuardianPassword stores the password hash and ends the guardian's
+// existing sessions
+func (s *Sqlite) SetGuardianPassword(ctx context.Context, id int64, passwordHash string) (err error) {
+	const query = "UPDATE guardians SET password_hash = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "SetGuardianPassword", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, nullableString(passwordHash), id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no guardian found with id %d", id)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM guardian_sessions WHERE guardian_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetGuardianLogin returns the id and password hash of the guardian with
+// the email, matched case-insensitively. The hash is empty when no password
+// has been set.
+func (s *Sqlite) GetGuardianLogin(ctx context.Context, email string) (id int64, passwordHash string, err error) {
+	const query = "SELECT id, COALESCE(password_hash, '') FROM guardians WHERE lower(email) = lower(?)"
+	ctx, span := startSpan(ctx, "GetGuardianLogin", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, email).Scan(&id, &passwordHash)
+	if err == sql.ErrNoRows {
+		return 0, "", fmt.Errorf("no guardian found with email %s", email)
+	}
+	return id, passwordHash, err
+}
+
+// CreateGuardianSession stores a login session and clears out expired ones
+func (s *Sqlite) CreateGuardianSession(ctx context.Context, guardianID int64, tokenHash string, expiresAt time.Time) (err error) {
+	const query = "INSERT INTO guardian_sessions (token_hash, guardian_id, expires_at) VALUES (?,?,?)"
+	ctx, span := startSpan(ctx, "CreateGuardianSession", query)
+	defer endSpan(span, &err)
+
+	if _, err := s.Db.ExecContext(ctx, "DELETE FROM guardian_sessions WHERE expires_at <= ?", time.Now().UTC()); err != nil {
+		return err
+	}
+	_, err = s.Db.ExecContext(ctx, query, tokenHash, guardianID, expiresAt.UTC())
+	return err
+}
+
+// GetGuardianSession returns the guardian of an unexpired session
+func (s *Sqlite) GetGuardianSession(ctx context.Context, tokenHash string) (guardianID int64, err error) {
+	const query = "SELECT guardian_id FROM guardian_sessions WHERE token_hash = ? AND expires_at > ?"
+	ctx, span := startSpan(ctx, "GetGuardianSession", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, tokenHash, time.Now().UTC()).Scan(&guardianID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("session not found or expired")
+	}
+	return guardianID, err
+}
+
+func (s *Sqlite) DeleteGuardianSession(ctx context.Context, tokenHash string) (err error) {
+	const query = "DELETE FROM guardian_sessions WHERE token_hash = ?"
+	ctx, span := startSpan(ctx, "DeleteGuardianSession", query)
+	defer endSpan(span, &err)
+
+	_, err = s.Db.ExecContext(ctx, query, tokenHash)
+	return err
+}
+
+// channel is the guardian's preferred contact channel, phone by default
+func channel(guardian types.Guardian) string {
+	if guardian.PreferredChannel == "" {
+		return "phone"
+	}
+	return guardian.PreferredChannel
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// ImportStudents inserts all students in one transaction; if any insert
+// fails nothing is written
+func (s *Sqlite) ImportStudents(ctx context.Context, students []types.Student) (ids []int64, err error) {
+	const query = "INSERT INTO students (name, email, age, class_id, roll_no) VALUES (?,?,?,?,?)"
+	ctx, span := startSpan(ctx, "ImportStudents", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for i, student := range students {
+		result, err := stmt.ExecContext(ctx, student.Name, student.Email, student.Age, nullableID(student.ClassID), student.RollNo)
+		if err != nil {
+			return nil, fmt.Errorf("student %d: %w", i+1, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		if err := recordEvent(ctx, tx, resourceStudent, actionCreated, id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ImportClasses inserts all classes in one transaction; if any insert
+// fails nothing is written. Teacher ids must exist, and teacher names are
+// resolved to existing teachers when no teacher id is given.
+func (s *Sqlite) ImportClasses(ctx context.Context, classes []types.Class) (ids []int64, err error) {
+	const query = "INSERT INTO classes (name, grade, section, teacher_id, capacity) VALUES (?,?,?,?,?)"
+	ctx, span := startSpan(ctx, "ImportClasses", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for i, class := range classes {
+		teacherID := class.TeacherID
+		if teacherID == 0 {
+			teacherID, err = resolveTeacher(ctx, tx, class.TeacherName)
+		} else {
+			err = checkTeacher(ctx, tx, teacherID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("class %d: %w", i+1, err)
+		}
+		result, err := stmt.ExecContext(ctx, class.Name, class.Grade, class.Section, nullableID(teacherID), class.Capacity)
+		if err != nil {
+			return nil, fmt.Errorf("class %d: %w", i+1, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		if err := recordEvent(ctx, tx, resourceClass, actionCreated, id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// nullableID stores a zero foreign key as NULL
+func nullableID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const leaveColumns = `id, student_id, start_date, end_date, reason, COALESCE(attachment_url, ''), submitted_by,
+    COALESCE(guardian_id, 0), status, COALESCE(reviewed_by, ''), COALESCE(review_note, ''), reviewed_at, created_at
+FROM leave_requests`
+
+// Leave methods
+
+// CreateLeaveRequest records a pending leave request for a student
+func (s *Sqlite) CreateLeaveRequest(ctx context.Context, leave types.LeaveRequest) (id int64, err error) {
+	const query = `INSERT INTO leave_requests (student_id, start_date, end_date, reason, attachment_url, submitted_by, guardian_id, status, created_at)
+VALUES (?,?,?,?,?,?,?,?,?)`
+	ctx, span := startSpan(ctx, "CreateLeaveRequest", query)
+	defer endSpan(span, &err)
+
+	var exists bool
+	if err := s.Db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM students WHERE id = ?)", leave.StudentID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("no student found with id %d", leave.StudentID)
+	}
+
+	result, err := s.Db.ExecContext(ctx, query, leave.StudentID, leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02"),
+		leave.Reason, nullableString(leave.AttachmentURL), leave.SubmittedBy, nullableID(leave.GuardianID), types.LeavePending, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetLeaveRequestById(ctx context.Context, id int64) (leave types.LeaveRequest, err error) {
+	const query = "SELECT " + leaveColumns + " WHERE id = ?"
+	ctx, span := startSpan(ctx, "GetLeaveRequestById", query)
+	defer endSpan(span, &err)
+
+	leave, err = scanLeave(s.Db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return leave, fmt.Errorf("no leave request found with id %d", id)
+	}
+	return leave, err
+}
+
+func (s *Sqlite) GetLeaveRequests(ctx context.Context, filter types.LeaveFilter) (leaves []types.LeaveRequest, err error) {
+	var where []string
+	var args []any
+	if filter.StudentID != 0 {
+		where = append(where, "student_id = ?")
+		args = append(args, filter.StudentID)
+	}
+	if filter.GuardianID != 0 {
+		where = append(where, "guardian_id = ?")
+		args = append(args, filter.GuardianID)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "end_date >= ?")
+		args = append(args, filter.From.Format("2006-01-02"))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "start_date <= ?")
+		args = append(args, filter.To.Format("2006-01-02"))
+	}
+	query := "SELECT " + leaveColumns
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY start_date DESC, id DESC"
+	ctx, span := startSpan(ctx, "GetLeaveRequests", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaves = []types.LeaveRequest{}
+	for rows.Next() {
+		leave, err := scanLeave(rows)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leave)
+	}
+	return leaves, rows.Err()
+}
+
+// ReviewLeaveRequest approves or rejects a pending leave request; a request
+// is reviewed once, after that it fails with storage.ErrAlreadyReviewed.
+// Approving sets the student's absences in the range to Excused, daily and
+// period records alike, and returns how many were changed. Absences written
+// after the approval are excused as they are written; see onLeave.
+func (s *Sqlite) ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (excused int, err error) {
+	const query = "UPDATE leave_requests SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "ReviewLeaveRequest", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	leave, err := scanLeave(tx.QueryRowContext(ctx, "SELECT "+leaveColumns+" WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no leave request found with id %d", id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if leave.Status != types.LeavePending {
+		return 0, fmt.Errorf("leave request %d is %s: %w", id, leave.Status, storage.ErrAlreadyReviewed)
+	}
+	if _, err := tx.ExecContext(ctx, query, status, reviewedBy, nullableString(note), time.Now().UTC(), id); err != nil {
+		return 0, err
+	}
+	if status == types.LeaveApproved {
+		if excused, err = s.excuse(ctx, tx, leave); err != nil {
+			return 0, err
+		}
+	}
+	return excused, tx.Commit()
+}
+
+// excuse sets the absences covered by an approved leave to Excused, then
+// rolls up the days that had period records among them
+func (s *Sqlite) excuse(ctx context.Context, tx *sql.Tx, leave types.LeaveRequest) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, class_id, COALESCE(period_id, 0), date FROM attendance_records
+WHERE student_id = ? AND date(date) BETWEEN ? AND ? AND status = 'Absent'`,
+		leave.StudentID, leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	var records []types.AttendanceRecord
+	for rows.Next() {
+		var r types.AttendanceRecord
+		if err := rows.Scan(&r.Id, &r.ClassID, &r.PeriodID, &r.Date); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// days are rolled up once every record is excused
+	type day struct {
+		classID int64
+		date    string
+	}
+	days := map[day]time.Time{}
+	for _, r := range records {
+		if _, err := tx.ExecContext(ctx, "UPDATE attendance_records SET status = 'Excused' WHERE id = ?", r.Id); err != nil {
+			return 0, err
+		}
+		if err := recordEvent(ctx, tx, resourceAttendance, actionUpdated, r.Id); err != nil {
+			return 0, err
+		}
+		if r.PeriodID != 0 {
+			days[day{r.ClassID, r.Date.Format("2006-01-02")}] = r.Date
+		}
+	}
+	for d, date := range days {
+		if err := s.rollUpDay(ctx, tx, leave.StudentID, d.classID, date); err != nil {
+			return 0, err
+		}
+	}
+	return len(records), nil
+}
+
+// onLeave turns an Absent status into Excused when the student has an
+// approved leave covering date, so absences marked after the approval are
+// excused too. Every attendance write passes its status through it before
+// writing, so the outbox event carries the excused status.
+func onLeave(ctx context.Context, tx *sql.Tx, studentID int64, date time.Time, status string) (string, error) {
+	if status != "Absent" {
+		return status, nil
+	}
+	var approved bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM leave_requests
+WHERE student_id = ? AND status = ? AND ? BETWEEN start_date AND end_date)`,
+		studentID, types.LeaveApproved, date.Format("2006-01-02")).Scan(&approved)
+	if err != nil || !approved {
+		return status, err
+	}
+	return "Excused", nil
+}
+
+func scanLeave(row interface{ Scan(...any) error }) (types.LeaveRequest, error) {
+	var leave types.LeaveRequest
+	var reviewedAt sql.NullTime
+	err := row.Scan(&leave.Id, &leave.StudentID, &leave.StartDate, &leave.EndDate, &leave.Reason, &leave.AttachmentURL, &leave.SubmittedBy,
+		&leave.GuardianID, &leave.Status, &leave.ReviewedBy, &leave.ReviewNote, &reviewedAt, &leave.CreatedAt)
+	if reviewedAt.Valid {
+		leave.ReviewedAt = &reviewedAt.Time
+	}
+	return leave, err
+}
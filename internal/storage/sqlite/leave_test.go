@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestReviewLeaveRequestExcuses(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	classID, err := s.CreateClass(ctx, "5A", "5", "A", 0, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	studentID, err := s.CreateStudent(ctx, "Asha Rao", "asha@example.com", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mark := func(day, status string) int64 {
+		t.Helper()
+		id, err := s.CreateAttendanceRecord(ctx, studentID, classID, date(day), status, "", types.AttendanceTimes{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	absent, present, outside := mark("2025-03-10", "Absent"), mark("2025-03-11", "Present"), mark("2025-03-14", "Absent")
+
+	leave := types.LeaveRequest{StudentID: studentID, StartDate: date("2025-03-10"), EndDate: date("2025-03-13"), Reason: "flu", SubmittedBy: "staff"}
+	id, err := s.CreateLeaveRequest(ctx, leave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, last, err := s.GetEventSeqRange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	excused, err := s.ReviewLeaveRequest(ctx, id, types.LeaveApproved, "Ms. Lee", "")
+	if err != nil || excused != 1 {
+		t.Fatalf("expected one absence excused, got %d (%v)", excused, err)
+	}
+	for id, want := range map[int64]string{absent: "Excused", present: "Present", outside: "Absent"} {
+		if record, err := s.GetAttendanceById(ctx, id); err != nil || record.Status != want {
+			t.Errorf("record %d: expected %s, got %+v (%v)", id, want, record, err)
+		}
+	}
+	events, err := s.GetEvents(ctx, last, 10)
+	if err != nil || len(events) != 1 || events[0].Type != "attendance.updated" || events[0].ResourceID != absent {
+		t.Errorf("expected one update event for record %d, got %+v (%v)", absent, events, err)
+	}
+
+	// absences written after the approval are excused as well
+	_, last, err = s.GetEventSeqRange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := mark("2025-03-12", "Absent")
+	if err := s.UpdateAttendanceRecord(ctx, absent, "Absent", "marked again", types.AttendanceTimes{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{later, absent} {
+		if record, err := s.GetAttendanceById(ctx, id); err != nil || record.Status != "Excused" {
+			t.Errorf("record %d: expected the absence to be excused, got %+v (%v)", id, record, err)
+		}
+	}
+	events, err = s.GetEvents(ctx, last, 10)
+	if err != nil || len(events) != 2 || events[0].Type != "attendance.created" || events[0].ResourceID != later {
+		t.Errorf("expected a create event for record %d and an update, got %+v (%v)", later, events, err)
+	}
+
+	if _, err := s.ReviewLeaveRequest(ctx, id, types.LeaveRejected, "Ms. Lee", ""); !errors.Is(err, storage.ErrAlreadyReviewed) {
+		t.Errorf("Expected ErrAlreadyReviewed, got %v", err)
+	}
+}
+
+func TestReviewLeaveRequestRejected(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	studentID, err := s.CreateStudent(ctx, "Asha Rao", "asha@example.com", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := s.CreateAttendanceRecord(ctx, studentID, 0, date("2025-03-10"), "Absent", "", types.AttendanceTimes{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.CreateLeaveRequest(ctx, types.LeaveRequest{StudentID: studentID, StartDate: date("2025-03-10"), EndDate: date("2025-03-10"),
+		Reason: "trip", SubmittedBy: "student"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if excused, err := s.ReviewLeaveRequest(ctx, id, types.LeaveRejected, "Ms. Lee", "no note"); err != nil || excused != 0 {
+		t.Fatalf("expected nothing excused, got %d (%v)", excused, err)
+	}
+	if r, err := s.GetAttendanceById(ctx, record); err != nil || r.Status != "Absent" {
+		t.Errorf("expected the absence to stay, got %+v (%v)", r, err)
+	}
+	if leave, err := s.GetLeaveRequestById(ctx, id); err != nil || leave.Status != types.LeaveRejected || leave.ReviewedAt == nil {
+		t.Errorf("expected a rejected request, got %+v (%v)", leave, err)
+	}
+}
+
+func TestApprovedLeaveExcusesLaterMarks(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	classID, err := s.CreateClass(ctx, "5A", "5", "A", 0, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	studentID, err := s.CreateStudent(ctx, "Asha Rao", "asha@example.com", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.CreateLeaveRequest(ctx, types.LeaveRequest{StudentID: studentID, StartDate: date("2025-03-10"), EndDate: date("2025-03-11"),
+		Reason: "flu", SubmittedBy: "staff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if excused, err := s.ReviewLeaveRequest(ctx, id, types.LeaveApproved, "Ms. Lee", ""); err != nil || excused != 0 {
+		t.Fatalf("expected nothing to excuse yet, got %d (%v)", excused, err)
+	}
+
+	cases := []struct {
+		day, status, want string
+	}{
+		{"2025-03-10", "Absent", "Excused"},
+		{"2025-03-11", "Present", "Present"},
+		{"2025-03-12", "Absent", "Absent"},
+	}
+	for _, c := range cases {
+		record, err := s.CreateAttendanceRecord(ctx, studentID, classID, date(c.day), c.status, "", types.AttendanceTimes{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, err := s.GetAttendanceById(ctx, record); err != nil || r.Status != c.want {
+			t.Errorf("%s marked %s: expected %s, got %+v (%v)", c.day, c.status, c.want, r, err)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migration is one versioned schema change. Append new migrations to the
+// end of the list; never edit or reorder ones that have shipped.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+var migrations = []migration{
+	{1, "create_students", `CREATE TABLE IF NOT EXISTS students(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    email TEXT,
+    age INTEGER,
+    class_id INTEGER,
+    roll_no TEXT
+)`},
+	{2, "create_classes", `CREATE TABLE IF NOT EXISTS classes(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    grade TEXT,
+    section TEXT,
+    teacher_name TEXT
+)`},
+	{3, "create_attendance_records", `CREATE TABLE IF NOT EXISTS attendance_records(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id INTEGER,
+    class_id INTEGER,
+    date DATE,
+    status TEXT,
+    remarks TEXT,
+    FOREIGN KEY(student_id) REFERENCES students(id),
+    FOREIGN KEY(class_id) REFERENCES classes(id)
+)`},
+	{4, "create_alerts", `CREATE TABLE IF NOT EXISTS alerts(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id INTEGER NOT NULL,
+    rule TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    message TEXT,
+    value REAL,
+    threshold REAL,
+    note TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(student_id) REFERENCES students(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS alerts_active ON alerts(student_id, rule) WHERE status != 'resolved'`},
+	{5, "create_calendar", `CREATE TABLE IF NOT EXISTS academic_years(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL
+);
+CREATE TABLE IF NOT EXISTS terms(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    academic_year_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    FOREIGN KEY(academic_year_id) REFERENCES academic_years(id)
+);
+CREATE TABLE IF NOT EXISTS closures(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS closures_dates ON closures(start_date, end_date)`},
+	{6, "create_timetable", `CREATE TABLE IF NOT EXISTS periods(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    start_time TEXT NOT NULL,
+    end_time TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS subjects(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    code TEXT
+);
+CREATE TABLE IF NOT EXISTS timetable_entries(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    class_id INTEGER NOT NULL,
+    weekday INTEGER NOT NULL,
+    period_id INTEGER NOT NULL,
+    subject_id INTEGER NOT NULL,
+    UNIQUE(class_id, weekday, period_id),
+    FOREIGN KEY(class_id) REFERENCES classes(id),
+    FOREIGN KEY(period_id) REFERENCES periods(id),
+    FOREIGN KEY(subject_id) REFERENCES subjects(id)
+);
+ALTER TABLE attendance_records ADD COLUMN period_id INTEGER REFERENCES periods(id);
+CREATE INDEX IF NOT EXISTS attendance_student_date ON attendance_records(student_id, date, period_id)`},
+	{7, "create_teachers", `CREATE TABLE IF NOT EXISTS teachers(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    employee_id TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS teachers_employee_id ON teachers(employee_id) WHERE employee_id IS NOT NULL;
+ALTER TABLE classes ADD COLUMN teacher_id INTEGER REFERENCES teachers(id);
+CREATE INDEX IF NOT EXISTS classes_teacher ON classes(teacher_id)`},
+	{8, "create_enrollments", `ALTER TABLE classes ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS enrollments(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id INTEGER NOT NULL,
+    class_id INTEGER NOT NULL,
+    subject_id INTEGER NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    status TEXT NOT NULL DEFAULT 'active',
+    FOREIGN KEY(student_id) REFERENCES students(id),
+    FOREIGN KEY(class_id) REFERENCES classes(id),
+    FOREIGN KEY(subject_id) REFERENCES subjects(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS enrollments_active ON enrollments(student_id, class_id, subject_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS enrollments_class ON enrollments(class_id, status)`},
+	{9, "create_guardians", `CREATE TABLE IF NOT EXISTS guardians(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    alt_phone TEXT,
+    preferred_channel TEXT NOT NULL DEFAULT 'phone',
+    password_hash TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS guardians_email ON guardians(lower(email)) WHERE email IS NOT NULL;
+CREATE TABLE IF NOT EXISTS student_guardians(
+    student_id INTEGER NOT NULL,
+    guardian_id INTEGER NOT NULL,
+    relationship TEXT NOT NULL,
+    is_primary INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY(student_id, guardian_id),
+    FOREIGN KEY(student_id) REFERENCES students(id),
+    FOREIGN KEY(guardian_id) REFERENCES guardians(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS student_guardians_primary ON student_guardians(student_id) WHERE is_primary = 1;
+CREATE INDEX IF NOT EXISTS student_guardians_guardian ON student_guardians(guardian_id);
+CREATE TABLE IF NOT EXISTS guardian_sessions(
+    token_hash TEXT PRIMARY KEY,
+    guardian_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY(guardian_id) REFERENCES guardians(id)
+)`},
+	{10, "create_notifications", `CREATE TABLE IF NOT EXISTS notifications(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id INTEGER NOT NULL,
+    date DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued',
+    guardian_id INTEGER,
+    channel TEXT,
+    recipient TEXT,
+    subject TEXT,
+    body TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    sent_at DATETIME,
+    UNIQUE(student_id, date),
+    FOREIGN KEY(student_id) REFERENCES students(id)
+);
+CREATE INDEX IF NOT EXISTS notifications_status ON notifications(status, id);
+CREATE TABLE IF NOT EXISTS notification_templates(
+    channel TEXT PRIMARY KEY,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL
+);
+INSERT INTO notification_templates (channel, subject, body) VALUES
+    ('email', '{{.StudentName}} was absent on {{.Date}}', 'Dear {{.GuardianName}},
+
+{{.StudentName}} ({{.ClassName}}) was marked absent today, {{.Date}}. If you were not expecting this, please contact the school.
+
+{{.SchoolName}}'),
+    ('sms', '', '{{.SchoolName}}: {{.StudentName}} was marked absent today ({{.Date}}). Please contact the school if this is unexpected.')`},
+	{11, "create_webhooks", `CREATE TABLE IF NOT EXISTS webhooks(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL,
+    active INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT,
+    response_status INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    delivered_at DATETIME,
+    FOREIGN KEY(webhook_id) REFERENCES webhooks(id)
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id)`},
+	{12, "create_events_outbox", `CREATE TABLE IF NOT EXISTS events(
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    resource TEXT NOT NULL,
+    resource_id INTEGER NOT NULL,
+    data TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    published_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS events_unpublished ON events(seq) WHERE published_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id)`},
+	{13, "create_sync_mutations", `ALTER TABLE attendance_records ADD COLUMN updated_at DATETIME;
+UPDATE attendance_records SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', date);
+CREATE TRIGGER IF NOT EXISTS attendance_records_inserted AFTER INSERT ON attendance_records
+WHEN NEW.updated_at IS NULL BEGIN
+    UPDATE attendance_records SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TRIGGER IF NOT EXISTS attendance_records_updated AFTER UPDATE OF status, remarks ON attendance_records
+WHEN NEW.updated_at IS OLD.updated_at BEGIN
+    UPDATE attendance_records SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END;
+CREATE TABLE IF NOT EXISTS sync_mutations(
+    client_id TEXT PRIMARY KEY,
+    outcome TEXT NOT NULL,
+    record_id INTEGER,
+    created_at DATETIME NOT NULL
+)`},
+	{14, "create_checkins", `CREATE TABLE IF NOT EXISTS checkins(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id INTEGER NOT NULL REFERENCES students(id),
+    class_id INTEGER NOT NULL REFERENCES classes(id),
+    period_id INTEGER NOT NULL DEFAULT 0,
+    date DATE NOT NULL,
+    session_id TEXT NOT NULL,
+    checked_in_at DATETIME NOT NULL,
+    status TEXT NOT NULL,
+    attendance_id INTEGER,
+    override_status TEXT,
+    overridden_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS checkins_student_day ON checkins(student_id, date, period_id);
+CREATE INDEX IF NOT EXISTS checkins_session ON checkins(session_id)`},
+	{15, "add_attendance_times", `ALTER TABLE attendance_records ADD COLUMN check_in_at DATETIME;
+ALTER TABLE attendance_records ADD COLUMN check_out_at DATETIME;
+ALTER TABLE attendance_records ADD COLUMN minutes_late INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE attendance_records ADD COLUMN minutes_early INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE classes ADD COLUMN late_grace_minutes INTEGER;
+ALTER TABLE classes ADD COLUMN early_leave_grace_minutes INTEGER;
+UPDATE attendance_records SET check_in_at = (SELECT checked_in_at FROM checkins WHERE attendance_id = attendance_records.id)
+WHERE id IN (SELECT attendance_id FROM checkins);
+DROP TRIGGER IF EXISTS attendance_records_updated;
+CREATE TRIGGER attendance_records_updated AFTER UPDATE OF status, remarks, check_in_at, check_out_at ON attendance_records
+WHEN NEW.updated_at IS OLD.updated_at BEGIN
+    UPDATE attendance_records SET updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now') WHERE id = NEW.id;
+END`},
+	{16, "create_leave_requests", `CREATE TABLE IF NOT EXISTS leave_requests(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id INTEGER NOT NULL REFERENCES students(id),
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT NOT NULL,
+    attachment_url TEXT,
+    submitted_by TEXT NOT NULL,
+    guardian_id INTEGER REFERENCES guardians(id),
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by TEXT,
+    review_note TEXT,
+    reviewed_at DATETIME,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS leave_requests_student ON leave_requests(student_id, start_date);
+CREATE TRIGGER IF NOT EXISTS attendance_records_excused_inserted AFTER INSERT ON attendance_records
+WHEN NEW.status = 'Absent' AND EXISTS (SELECT 1 FROM leave_requests l
+    WHERE l.student_id = NEW.student_id AND l.status = 'approved' AND date(NEW.date) BETWEEN l.start_date AND l.end_date) BEGIN
+    UPDATE attendance_records SET status = 'Excused' WHERE id = NEW.id;
+END;
+CREATE TRIGGER IF NOT EXISTS attendance_records_excused_updated AFTER UPDATE OF status ON attendance_records
+WHEN NEW.status = 'Absent' AND EXISTS (SELECT 1 FROM leave_requests l
+    WHERE l.student_id = NEW.student_id AND l.status = 'approved' AND date(NEW.date) BETWEEN l.start_date AND l.end_date) BEGIN
+    UPDATE attendance_records SET status = 'Excused' WHERE id = NEW.id;
+END`},
+	{17, "create_attachments", `CREATE TABLE IF NOT EXISTS attachments(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_type TEXT NOT NULL,
+    owner_id INTEGER NOT NULL,
+    student_id INTEGER NOT NULL REFERENCES students(id),
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    blob_key TEXT NOT NULL UNIQUE,
+    uploaded_by TEXT,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS attachments_owner ON attachments(owner_type, owner_id)`},
+	{18, "add_student_photo", `ALTER TABLE students ADD COLUMN photo_url TEXT`},
+	{19, "create_attendance_tombstones", `CREATE TABLE IF NOT EXISTS attendance_tombstones(
+    student_id INTEGER NOT NULL,
+    date DATE NOT NULL,
+    period_id INTEGER NOT NULL DEFAULT 0,
+    record_id INTEGER NOT NULL,
+    deleted_at DATETIME NOT NULL,
+    PRIMARY KEY (student_id, date, period_id)
+)`},
+	{20, "add_student_badge_version", `ALTER TABLE students ADD COLUMN badge_version INTEGER NOT NULL DEFAULT 1`},
+	{21, "drop_attendance_excused_triggers", `DROP TRIGGER IF EXISTS attendance_records_excused_inserted;
+DROP TRIGGER IF EXISTS attendance_records_excused_updated`},
+}
+
+// dataMigrations run right after the schema change of their version, in
+// the same transaction, for steps that cannot be written in SQL
+var dataMigrations = map[int]func(*sql.Tx) error{
+	7: migrateTeacherNames,
+}
+
+// migrate applies every migration newer than the recorded schema version,
+// each in its own transaction
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations(
+    version INTEGER PRIMARY KEY,
+    name TEXT,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return err
+	}
+
+	current, err := schemaVersion(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+		}
+		if data, ok := dataMigrations[m.version]; ok {
+			if err := data(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+			}
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// PendingMigrations returns how many known migrations have not been applied
+func (s *Sqlite) PendingMigrations(ctx context.Context) (int, error) {
+	current, err := schemaVersion(ctx, s.Db)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, m := range migrations {
+		if m.version > current {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// CheckMigrations is a readiness check that fails while migrations are pending
+func (s *Sqlite) CheckMigrations(ctx context.Context) error {
+	pending, err := s.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migrations", pending)
+	}
+	return nil
+}
+
+// Ping checks that the database answers queries
+func (s *Sqlite) Ping(ctx context.Context) error {
+	return s.Db.PingContext(ctx)
+}
@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const notificationColumns = `id, student_id, date, status, COALESCE(guardian_id, 0), COALESCE(channel, ''), COALESCE(recipient, ''),
+    COALESCE(subject, ''), COALESCE(body, ''), attempts, COALESCE(error, ''), created_at, sent_at`
+
+// Notification methods
+
+// QueueNotification queues the student's absence notice for the day. It
+// reports false when the day already has one, unless that one had been
+// cancelled, in which case it is queued again.
+func (s *Sqlite) QueueNotification(ctx context.Context, studentID int64, date time.Time) (queued bool, err error) {
+	const query = `INSERT INTO notifications (student_id, date, status) VALUES (?, ?, 'queued')
+ON CONFLICT(student_id, date) DO UPDATE SET status = 'queued', attempts = 0, error = NULL WHERE status = 'cancelled'`
+	ctx, span := startSpan(ctx, "QueueNotification", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, studentID, date.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// CancelNotification withdraws the student's notice for the day if it has
+// not gone out yet
+func (s *Sqlite) CancelNotification(ctx context.Context, studentID int64, date time.Time) (err error) {
+	const query = "UPDATE notifications SET status = 'cancelled' WHERE student_id = ? AND date = ? AND status = 'queued'"
+	ctx, span := startSpan(ctx, "CancelNotification", query)
+	defer endSpan(span, &err)
+
+	_, err = s.Db.ExecContext(ctx, query, studentID, date.Format("2006-01-02"))
+	return err
+}
+
+// GetPendingNotifications returns up to limit queued notifications, oldest first
+func (s *Sqlite) GetPendingNotifications(ctx context.Context, limit int) (notifications []types.Notification, err error) {
+	const query = "select " + notificationColumns + " from notifications WHERE status = 'queued' ORDER BY id LIMIT ?"
+	ctx, span := startSpan(ctx, "GetPendingNotifications", query)
+	defer endSpan(span, &err)
+
+	return s.queryNotifications(ctx, query, limit)
+}
+
+// UpdateNotification records a delivery attempt: the status, where the
+// message went and what it said
+func (s *Sqlite) UpdateNotification(ctx context.Context, n types.Notification) (err error) {
+	const query = `UPDATE notifications SET status = ?, guardian_id = ?, channel = ?, recipient = ?, subject = ?, body = ?,
+    attempts = ?, error = ?, sent_at = ? WHERE id = ?`
+	ctx, span := startSpan(ctx, "UpdateNotification", query)
+	defer endSpan(span, &err)
+
+	var sentAt any
+	if n.SentAt != nil {
+		sentAt = n.SentAt.UTC()
+	}
+	result, err := s.Db.ExecContext(ctx, query, n.Status, nullableID(n.GuardianID), nullableString(n.Channel), nullableString(n.Recipient),
+		nullableString(n.Subject), nullableString(n.Body), n.Attempts, nullableString(n.Error), sentAt, n.Id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no notification found with id %d", n.Id)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetNotifications(ctx context.Context, filter types.NotificationFilter) (notifications []types.Notification, err error) {
+	var where []string
+	var args []any
+	if filter.StudentID != 0 {
+		where = append(where, "student_id = ?")
+		args = append(args, filter.StudentID)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "date >= ?")
+		args = append(args, filter.From.Format("2006-01-02"))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "date <= ?")
+		args = append(args, filter.To.Format("2006-01-02"))
+	}
+	query := "select " + notificationColumns + " from notifications"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY date DESC, id DESC"
+	ctx, span := startSpan(ctx, "GetNotifications", query)
+	defer endSpan(span, &err)
+
+	return s.queryNotifications(ctx, query, args...)
+}
+
+func (s *Sqlite) queryNotifications(ctx context.Context, query string, args ...any) ([]types.Notification, error) {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []types.Notification{}
+	for rows.Next() {
+		var n types.Notification
+		var sentAt sql.NullTime
+		err := rows.Scan(&n.Id, &n.StudentID, &n.Date, &n.Status, &n.GuardianID, &n.Channel, &n.Recipient,
+			&n.Subject, &n.Body, &n.Attempts, &n.Error, &n.CreatedAt, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+		if sentAt.Valid {
+			n.SentAt = &sentAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (s *Sqlite) GetNotificationTemplates(ctx context.Context) (templates []types.NotificationTemplate, err error) {
+	const query = "SELECT channel, subject, body FROM notification_templates ORDER BY channel"
+	ctx, span := startSpan(ctx, "GetNotificationTemplates", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates = []types.NotificationTemplate{}
+	for rows.Next() {
+		var t types.NotificationTemplate
+		if err := rows.Scan(&t.Channel, &t.Subject, &t.Body); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+func (s *Sqlite) SaveNotificationTemplate(ctx context.Context, t types.NotificationTemplate) (err error) {
+	const query = `INSERT INTO notification_templates (channel, subject, body) VALUES (?,?,?)
+ON CONFLICT(channel) DO UPDATE SET subject = excluded.subject, body = excluded.body`
+	ctx, span := startSpan(ctx, "SaveNotificationTemplate", query)
+	defer endSpan(span, &err)
+
+	_, err = s.Db.ExecContext(ctx, query, t.Channel, t.Subject, t.Body)
+	return err
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Resources recorded in the outbox
+const (
+	resourceStudent    = "student"
+	resourceClass      = "class"
+	resourceAttendance = "attendance"
+)
+
+// Outbox actions, appended to the resource to make the event type
+const (
+	actionCreated = "created"
+	actionUpdated = "updated"
+	actionDeleted = "deleted"
+)
+
+type rowQuerier interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+// recordEvent writes an event for the row of resource id to the outbox. It
+// runs inside the transaction of the change so the two commit together; the
+// row is read back in the same transaction, so call it after a create or
+// update and before a delete.
+func recordEvent(ctx context.Context, tx rowQuerier, resource, action string, id int64) error {
+	row, err := readRow(ctx, tx, resource, id)
+	if err != nil {
+		return fmt.Errorf("recording %s.%s: %w", resource, action, err)
+	}
+	return insertEvent(ctx, tx, resource, action, id, row)
+}
+
+func insertEvent(ctx context.Context, tx rowQuerier, resource, action string, id int64, row any) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO events (id, type, resource, resource_id, data, created_at) VALUES (?,?,?,?,?,?)",
+		newEventID(), resource+"."+action, resource, id, string(data), time.Now().UTC())
+	return err
+}
+
+func readRow(ctx context.Context, tx rowQuerier, resource string, id int64) (any, error) {
+	switch resource {
+	case resourceStudent:
+		var student types.Student
+		err := tx.QueryRowContext(ctx, "select id, name, email, age, COALESCE(class_id, 0), COALESCE(roll_no, ''), COALESCE(photo_url, '') from students where id = ?", id).
+			Scan(&student.Id, &student.Name, &student.Email, &student.Age, &student.ClassID, &student.RollNo, &student.PhotoURL)
+		return student, err
+	case resourceClass:
+		return scanClass(tx.QueryRowContext(ctx, "select "+classColumns+" where c.id = ?", id))
+	case resourceAttendance:
+		return scanAttendance(tx.QueryRowContext(ctx, "select "+attendanceColumns+" from attendance_records where id = ?", id))
+	}
+	return nil, fmt.Errorf("unknown resource %q", resource)
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
+
+// Outbox methods
+
+// GetUnpublishedEvents returns up to limit events the relay has not
+// published yet, in order
+func (s *Sqlite) GetUnpublishedEvents(ctx context.Context, limit int) (events []types.Event, err error) {
+	const query = "SELECT seq, id, type, resource, resource_id, data, created_at FROM events WHERE published_at IS NULL ORDER BY seq LIMIT ?"
+	ctx, span := startSpan(ctx, "GetUnpublishedEvents", query)
+	defer endSpan(span, &err)
+
+	return s.queryEvents(ctx, query, limit)
+}
+
+// MarkEventsPublished marks every event up to and including throughSeq as
+// published
+func (s *Sqlite) MarkEventsPublished(ctx context.Context, throughSeq int64) (err error) {
+	const query = "UPDATE events SET published_at = ? WHERE seq <= ? AND published_at IS NULL"
+	ctx, span := startSpan(ctx, "MarkEventsPublished", query)
+	defer endSpan(span, &err)
+
+	_, err = s.Db.ExecContext(ctx, query, time.Now().UTC(), throughSeq)
+	return err
+}
+
+// GetEvents returns up to limit events after seq since, in order, whether
+// published or not
+func (s *Sqlite) GetEvents(ctx context.Context, since int64, limit int) (events []types.Event, err error) {
+	const query = "SELECT seq, id, type, resource, resource_id, data, created_at FROM events WHERE seq > ? ORDER BY seq LIMIT ?"
+	ctx, span := startSpan(ctx, "GetEvents", query)
+	defer endSpan(span, &err)
+
+	return s.queryEvents(ctx, query, since, limit)
+}
+
+// GetEventSeqRange returns the first and last seq still in the outbox, or
+// zeros when it is empty
+func (s *Sqlite) GetEventSeqRange(ctx context.Context) (first, last int64, err error) {
+	const query = "SELECT COALESCE(MIN(seq), 0), COALESCE(MAX(seq), 0) FROM events"
+	ctx, span := startSpan(ctx, "GetEventSeqRange", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query).Scan(&first, &last)
+	return first, last, err
+}
+
+// PruneEvents deletes published events created before the cutoff and
+// returns how many went. The newest event is always kept so the change feed
+// can still tell where it stands.
+func (s *Sqlite) PruneEvents(ctx context.Context, before time.Time) (pruned int64, err error) {
+	const query = `DELETE FROM events WHERE published_at IS NOT NULL AND created_at < ?
+    AND seq < (SELECT MAX(seq) FROM events)`
+	ctx, span := startSpan(ctx, "PruneEvents", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *Sqlite) queryEvents(ctx context.Context, query string, args ...any) ([]types.Event, error) {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []types.Event{}
+	for rows.Next() {
+		var e types.Event
+		var data string
+		if err := rows.Scan(&e.Seq, &e.ID, &e.Type, &e.Resource, &e.ResourceID, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Data = json.RawMessage(data)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
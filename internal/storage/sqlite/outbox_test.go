@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestFailedWriteRecordsNoEvent(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// the first class is written and recorded before the second fails, and
+	// both go with the rollback
+	_, err := s.ImportClasses(ctx, []types.Class{
+		{Name: "5A", Grade: "5", Section: "A"},
+		{Name: "5B", Grade: "5", Section: "B", TeacherName: "Sam Lee"},
+	})
+	if !errors.Is(err, storage.ErrUnknownTeacher) {
+		t.Fatalf("Expected ErrUnknownTeacher, got %v", err)
+	}
+	if first, last, err := s.GetEventSeqRange(ctx); err != nil || first != 0 || last != 0 {
+		t.Errorf("expected no events, got %d to %d (%v)", first, last, err)
+	}
+	if classes, err := s.GetClasses(ctx, types.ClassFilter{}); err != nil || len(classes) != 0 {
+		t.Errorf("expected no classes, got %+v (%v)", classes, err)
+	}
+}
+
+func TestPruneEventsKeepsNewest(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Asha Rao", "Ben Okafor", "Chen Li"} {
+		if _, err := s.CreateStudent(ctx, name, "", 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, last, err := s.GetEventSeqRange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkEventsPublished(ctx, last); err != nil {
+		t.Fatal(err)
+	}
+	if pruned, err := s.PruneEvents(ctx, time.Now().Add(time.Hour)); err != nil || pruned != 2 {
+		t.Fatalf("expected 2 events pruned, got %d (%v)", pruned, err)
+	}
+	if first, newest, err := s.GetEventSeqRange(ctx); err != nil || first != last || newest != last {
+		t.Errorf("expected only event %d to be kept, got %d to %d (%v)", last, first, newest, err)
+	}
+}
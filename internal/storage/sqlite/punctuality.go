@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/timetable"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// schedule returns what a record of the class on date is due for: the
+// hours of its period, or for a daily record the class's first to last
+// period of the weekday, falling back to the school day. The class's grace
+// is used where it has one, the school's otherwise.
+func (s *Sqlite) schedule(ctx context.Context, tx *sql.Tx, classID, periodID int64, date time.Time) (timetable.Schedule, error) {
+	var start, end sql.NullString
+	var err error
+	if periodID != 0 {
+		err = tx.QueryRowContext(ctx, "SELECT start_time, end_time FROM periods WHERE id = ?", periodID).Scan(&start, &end)
+	} else {
+		err = tx.QueryRowContext(ctx, `SELECT MIN(p.start_time), MAX(p.end_time) FROM timetable_entries te
+JOIN periods p ON p.id = te.period_id
+WHERE te.class_id = ? AND te.weekday = ?`, classID, int(date.Weekday())).Scan(&start, &end)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return timetable.Schedule{}, err
+	}
+	if !start.Valid {
+		start.String, end.String = s.punctuality.DayStart, s.punctuality.DayEnd
+	}
+
+	var lateGrace, earlyGrace sql.NullInt64
+	err = tx.QueryRowContext(ctx, "SELECT late_grace_minutes, early_leave_grace_minutes FROM classes WHERE id = ?", classID).Scan(&lateGrace, &earlyGrace)
+	if err != nil && err != sql.ErrNoRows {
+		return timetable.Schedule{}, err
+	}
+
+	schedule := timetable.Schedule{
+		Start:           atClock(date, start.String),
+		End:             atClock(date, end.String),
+		LateGrace:       s.punctuality.LateGrace,
+		EarlyLeaveGrace: s.punctuality.EarlyLeaveGrace,
+	}
+	if lateGrace.Valid {
+		schedule.LateGrace = time.Duration(lateGrace.Int64) * time.Minute
+	}
+	if earlyGrace.Valid {
+		schedule.EarlyLeaveGrace = time.Duration(earlyGrace.Int64) * time.Minute
+	}
+	return schedule, nil
+}
+
+// punctual classifies a record's times against its schedule; without
+// times there is nothing to classify. A status given with the times always
+// wins over the derived one.
+func (s *Sqlite) punctual(ctx context.Context, tx *sql.Tx, classID, periodID int64, date time.Time, times types.AttendanceTimes) (timetable.Punctuality, error) {
+	if times.CheckInAt == nil && times.CheckOutAt == nil {
+		return timetable.Punctuality{}, nil
+	}
+	schedule, err := s.schedule(ctx, tx, classID, periodID, date)
+	if err != nil {
+		return timetable.Punctuality{}, fmt.Errorf("schedule of class %d: %w", classID, err)
+	}
+	return schedule.Classify(times.CheckInAt, times.CheckOutAt), nil
+}
+
+// atClock returns the time of day clock, HH:MM in the server's time zone,
+// on the calendar day of date
+func atClock(date time.Time, clock string) time.Time {
+	hm, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hm.Hour(), hm.Minute(), 0, 0, time.Local)
+}
+
+// nullableTime stores a missing time as NULL
+func nullableTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
@@ -1,8 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/tukesh1/student-api/internal/config"
@@ -10,67 +14,54 @@ import (
 )
 
 type Sqlite struct {
-	Db *sql.DB
+	Db      *sql.DB
+	weekend string // strftime('%w') numbers of the weekend days, e.g. "0,6"
+	rollUp  config.Timetable
+
+	punctuality config.Punctuality
 }
 
 func New(cfg *config.Config) (*Sqlite, error) {
-	db, err := sql.Open("sqlite3", cfg.StoragePath)
+	weekend, err := weekendDays(cfg.Calendar.Weekend)
 	if err != nil {
 		return nil, err
 	}
-
-	// Create students table
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS students(
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT,
-    email TEXT,
-    age INTEGER,
-    class_id INTEGER,
-    roll_no TEXT
-)`)
-	if err != nil {
-		return nil, err
+	for _, clock := range []string{cfg.Punctuality.DayStart, cfg.Punctuality.DayEnd} {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return nil, fmt.Errorf("invalid school day time %q, expected HH:MM", clock)
+		}
 	}
 
-	// Create classes table
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS classes(
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT,
-    grade TEXT,
-    section TEXT,
-    teacher_name TEXT
-)`)
+	db, err := sql.Open("sqlite3", cfg.StoragePath)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create attendance_records table
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS attendance_records(
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    student_id INTEGER,
-    class_id INTEGER,
-    date DATE,
-    status TEXT,
-    remarks TEXT,
-    FOREIGN KEY(student_id) REFERENCES students(id),
-    FOREIGN KEY(class_id) REFERENCES classes(id)
-)`)
-	if err != nil {
+	if err := migrate(db); err != nil {
 		return nil, err
 	}
 
 	return &Sqlite{
-		Db: db,
+		Db:      db,
+		weekend: weekend,
+		rollUp:  cfg.Timetable,
+
+		punctuality: cfg.Punctuality,
 	}, nil
 }
 
-func (s *Sqlite) CreateStudent(name string, email string, age int) (int64, error) {
-	stmt, err := s.Db.Prepare("INSERT INTO students (name, email, age) VALUES (?,?,?)")
+func (s *Sqlite) CreateStudent(ctx context.Context, name string, email string, age int) (id int64, err error) {
+	const query = "INSERT INTO students (name, email, age) VALUES (?,?,?)"
+	ctx, span := startSpan(ctx, "CreateStudent", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
-	result, err := stmt.Exec(name, email, age)
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, name, email, age)
 	if err != nil {
 		return 0, err
 	}
@@ -78,17 +69,23 @@ func (s *Sqlite) CreateStudent(name string, email string, age int) (int64, error
 	if err != nil {
 		return 0, err
 	}
-	return lastId, nil
+	if err := recordEvent(ctx, tx, resourceStudent, actionCreated, lastId); err != nil {
+		return 0, err
+	}
+	return lastId, tx.Commit()
 }
 
-func (s *Sqlite) GetStudentById(id int64) (types.Student, error) {
-	stmt, err := s.Db.Prepare("select id, name, email, age from students where id =? LIMIT 1")
+func (s *Sqlite) GetStudentById(ctx context.Context, id int64) (student types.Student, err error) {
+	const query = "select id, name, email, age, COALESCE(class_id, 0), COALESCE(roll_no, ''), COALESCE(photo_url, '') from students where id =? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetStudentById", query)
+	defer endSpan(span, &err)
+
+	stmt, err := s.Db.PrepareContext(ctx, query)
 	if err != nil {
 		return types.Student{}, err
 	}
 	defer stmt.Close()
-	var student types.Student
-	err = stmt.QueryRow(id).Scan(&student.Id, &student.Name, &student.Email, &student.Age)
+	err = stmt.QueryRowContext(ctx, id).Scan(&student.Id, &student.Name, &student.Email, &student.Age, &student.ClassID, &student.RollNo, &student.PhotoURL)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return types.Student{}, fmt.Errorf("qNo student found with id  %s", fmt.Sprint(id))
@@ -99,38 +96,77 @@ func (s *Sqlite) GetStudentById(id int64) (types.Student, error) {
 	return student, nil
 }
 
-func (s *Sqlite) GetStudents() ([]types.Student, error) {
-	stmt, err := s.Db.Prepare("select id, name, email, age from students")
+func (s *Sqlite) GetStudents(ctx context.Context, filter types.StudentFilter) (students []types.Student, err error) {
+	query, args := studentsQuery(filter)
+	ctx, span := startSpan(ctx, "GetStudents", query)
+	defer endSpan(span, &err)
+
+	err = s.eachStudent(ctx, query, args, func(student types.Student) error {
+		students = append(students, student)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-	rows, err := stmt.Query()
+	return students, nil
+}
+
+// StreamStudents calls fn for each matching student without loading the
+// whole list into memory; an error from fn stops the iteration
+func (s *Sqlite) StreamStudents(ctx context.Context, filter types.StudentFilter, fn func(types.Student) error) (err error) {
+	query, args := studentsQuery(filter)
+	ctx, span := startSpan(ctx, "StreamStudents", query)
+	defer endSpan(span, &err)
+
+	return s.eachStudent(ctx, query, args, fn)
+}
+
+func studentsQuery(filter types.StudentFilter) (string, []any) {
+	var where []string
+	var args []any
+	if filter.ClassID != 0 {
+		where = append(where, "class_id = ?")
+		args = append(args, filter.ClassID)
+	}
+	query := "select id, name, email, age, COALESCE(class_id, 0), COALESCE(roll_no, ''), COALESCE(photo_url, '') from students"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	return query + " ORDER BY id", args
+}
+
+func (s *Sqlite) eachStudent(ctx context.Context, query string, args []any, fn func(types.Student) error) error {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var students []types.Student
 
 	for rows.Next() {
 		var student types.Student
-		err := rows.Scan(&student.Id, &student.Name, &student.Email, &student.Age)
+		err := rows.Scan(&student.Id, &student.Name, &student.Email, &student.Age, &student.ClassID, &student.RollNo, &student.PhotoURL)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(student); err != nil {
+			return err
 		}
-		students = append(students, student)
 	}
-	return students, nil
+	return rows.Err()
 }
 
-func (s *Sqlite) UpdateStudent(id int64, name string, email string, age int) error {
-	stmt, err := s.Db.Prepare("UPDATE students SET name = ?, email = ?, age = ? WHERE id = ?")
+func (s *Sqlite) UpdateStudent(ctx context.Context, id int64, name string, email string, age int) (err error) {
+	const query = "UPDATE students SET name = ?, email = ?, age = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateStudent", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	result, err := stmt.Exec(name, email, age, id)
+	result, err := tx.ExecContext(ctx, query, name, email, age, id)
 	if err != nil {
 		return err
 	}
@@ -144,29 +180,59 @@ func (s *Sqlite) UpdateStudent(id int64, name string, email string, age int) err
 		return fmt.Errorf("no student found with id %d", id)
 	}
 
-	return nil
+	if err := recordEvent(ctx, tx, resourceStudent, actionUpdated, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *Sqlite) DeleteStudent(id int64) error {
-	stmt, err := s.Db.Prepare("DELETE FROM students WHERE id = ?")
+// SetStudentPhoto stores the URL of a student's photo; an empty URL
+// removes it
+func (s *Sqlite) SetStudentPhoto(ctx context.Context, id int64, photoURL string) (err error) {
+	const query = "UPDATE students SET photo_url = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "SetStudentPhoto", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	result, err := stmt.Exec(id)
+	result, err := tx.ExecContext(ctx, query, nullableString(photoURL), id)
 	if err != nil {
 		return err
 	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("no student found with id %d", id)
+	}
+	if err := recordEvent(ctx, tx, resourceStudent, actionUpdated, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	rowsAffected, err := result.RowsAffected()
+func (s *Sqlite) DeleteStudent(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM students WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteStudent", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("no student found with id %d", id)
+	if err := recordEvent(ctx, tx, resourceStudent, actionDeleted, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no student found with id %d", id)
+		}
+		return err
 	}
-
-	return nil
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
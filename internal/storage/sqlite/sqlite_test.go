@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+// newTestStorage opens a migrated database in a temporary directory with
+// the default calendar and punctuality settings
+func newTestStorage(t *testing.T) *Sqlite {
+	t.Helper()
+	cfg := &config.Config{StoragePath: filepath.Join(t.TempDir(), "test.db")}
+	cfg.Calendar.Weekend = []string{"Saturday", "Sunday"}
+	cfg.Timetable = config.Timetable{AbsentRatio: 0.5, LateIfFirstMissed: true}
+	cfg.Punctuality = config.Punctuality{LateGrace: 5 * time.Minute, EarlyLeaveGrace: 5 * time.Minute, DayStart: "08:00", DayEnd: "15:00"}
+
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Db.Close() })
+	return s
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
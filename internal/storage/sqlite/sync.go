@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/timetable"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// syncTimeFormat is how updated_at is stored, matching the triggers that
+// set it on other writes
+const syncTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// SyncAttendance applies one attendance change uploaded by a client that
+// may have made it offline. The change is matched to the server copy by
+// student, date and period, and the last writer wins: it is applied when
+// its ModifiedAt is after the copy's updated_at, and is otherwise a
+// conflict that leaves the server copy alone, ties included. A ModifiedAt
+// in the future is taken as now so a fast device clock cannot pin a
+// record. An applied change stores its ModifiedAt as the new updated_at.
+// An upsert replaces the record's times, and the minutes late and left
+// early are worked out from them again as when marking.
+//
+// Deleted records leave a tombstone with the time of the delete, so a
+// change made before a record was deleted is a conflict rather than
+// bringing it back. Deleting a record the server never had is rejected.
+//
+// Outcomes are kept by ClientID, so uploading a mutation again returns the
+// first outcome instead of applying it twice. Mutations rejected by the
+// calendar or the timetable are not kept and can be sent again.
+func (s *Sqlite) SyncAttendance(ctx context.Context, m types.SyncMutation) (result types.SyncResult, err error) {
+	const query = "SELECT outcome, COALESCE(record_id, 0) FROM sync_mutations WHERE client_id = ?"
+	ctx, span := startSpan(ctx, "SyncAttendance", query)
+	defer endSpan(span, &err)
+
+	result = types.SyncResult{ClientID: m.ClientID}
+	err = s.Db.QueryRowContext(ctx, query, m.ClientID).Scan(&result.Outcome, &result.RecordID)
+	if err == nil {
+		result.Replayed = true
+		return s.withServerCopy(ctx, result)
+	}
+	if err != sql.ErrNoRows {
+		return result, err
+	}
+
+	if m.Op == types.SyncUpsert {
+		if err := s.checkInstructional(ctx, m.Date); err != nil {
+			return reject(result, err)
+		}
+	}
+	if now := time.Now().UTC(); m.ModifiedAt.After(now) {
+		m.ModifiedAt = now
+	}
+	modifiedAt := m.ModifiedAt.UTC().Format(syncTimeFormat)
+	day := m.Date.Format("2006-01-02")
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	if m.Op == types.SyncUpsert && m.PeriodID != 0 {
+		if err := checkPeriod(ctx, tx, m.ClassID, m.PeriodID, m.Date); err != nil {
+			return reject(result, err)
+		}
+	}
+
+	var classID int64
+	var updatedAt time.Time
+	err = tx.QueryRowContext(ctx, "SELECT id, class_id, updated_at FROM attendance_records WHERE student_id = ? AND date = ? AND period_id IS ?",
+		m.StudentID, day, nullableID(m.PeriodID)).Scan(&result.RecordID, &classID, &updatedAt)
+	found := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return result, err
+	}
+	var deleted bool
+	var deletedAt time.Time
+	if !found {
+		err = tx.QueryRowContext(ctx, "SELECT record_id, deleted_at FROM attendance_tombstones WHERE student_id = ? AND date = ? AND period_id = ?",
+			m.StudentID, day, m.PeriodID).Scan(&result.RecordID, &deletedAt)
+		deleted = err == nil
+		if err != nil && err != sql.ErrNoRows {
+			return result, err
+		}
+	}
+
+	var p timetable.Punctuality
+	if m.Op == types.SyncUpsert {
+		p, err = s.punctual(ctx, tx, m.ClassID, m.PeriodID, m.Date, types.AttendanceTimes{CheckInAt: m.CheckInAt, CheckOutAt: m.CheckOutAt})
+		if err != nil {
+			return result, err
+		}
+		if m.Status, err = onLeave(ctx, tx, m.StudentID, m.Date, m.Status); err != nil {
+			return result, err
+		}
+	}
+	in, out := nullableTime(m.CheckInAt), nullableTime(m.CheckOutAt)
+
+	result.Outcome = types.SyncApplied
+	switch {
+	case found && !m.ModifiedAt.After(updatedAt), deleted && !m.ModifiedAt.After(deletedAt):
+		result.Outcome = types.SyncConflict
+	case m.Op == types.SyncDelete && deleted:
+		// deleted after the change was made; nothing to do
+	case m.Op == types.SyncDelete && !found:
+		result.Outcome, result.Error = types.SyncRejected, "no attendance record to delete"
+		return result, nil
+	case m.Op == types.SyncDelete:
+		if err := recordEvent(ctx, tx, resourceAttendance, actionDeleted, result.RecordID); err != nil {
+			return result, err
+		}
+		if err := tombstone(ctx, tx, result.RecordID, modifiedAt); err != nil {
+			return result, err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM attendance_records WHERE id = ?", result.RecordID); err != nil {
+			return result, err
+		}
+	case found:
+		classID = m.ClassID
+		_, err := tx.ExecContext(ctx, `UPDATE attendance_records SET class_id = ?, status = ?, remarks = ?, check_in_at = ?, check_out_at = ?,
+minutes_late = ?, minutes_early = ?, updated_at = ? WHERE id = ?`,
+			m.ClassID, m.Status, m.Remarks, in, out, p.MinutesLate, p.MinutesEarly, modifiedAt, result.RecordID)
+		if err != nil {
+			return result, err
+		}
+		if err := recordEvent(ctx, tx, resourceAttendance, actionUpdated, result.RecordID); err != nil {
+			return result, err
+		}
+	default:
+		classID = m.ClassID
+		res, err := tx.ExecContext(ctx, `INSERT INTO attendance_records (student_id, class_id, period_id, date, status, remarks, check_in_at, check_out_at,
+minutes_late, minutes_early, updated_at) VALUES (?,?,?,?,?,?,?,?,?,?,?)`,
+			m.StudentID, m.ClassID, nullableID(m.PeriodID), day, m.Status, m.Remarks, in, out, p.MinutesLate, p.MinutesEarly, modifiedAt)
+		if err != nil {
+			return result, err
+		}
+		if result.RecordID, err = res.LastInsertId(); err != nil {
+			return result, err
+		}
+		if err := recordEvent(ctx, tx, resourceAttendance, actionCreated, result.RecordID); err != nil {
+			return result, err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM attendance_tombstones WHERE student_id = ? AND date = ? AND period_id = ?",
+			m.StudentID, day, m.PeriodID); err != nil {
+			return result, err
+		}
+	}
+	if result.Outcome == types.SyncApplied && m.PeriodID != 0 && classID != 0 {
+		if err := s.rollUpDay(ctx, tx, m.StudentID, classID, m.Date); err != nil {
+			return result, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO sync_mutations (client_id, outcome, record_id, created_at) VALUES (?,?,?,?)",
+		m.ClientID, result.Outcome, nullableID(result.RecordID), time.Now().UTC())
+	if err != nil {
+		return result, err
+	}
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+	return s.withServerCopy(ctx, result)
+}
+
+// withServerCopy adds the record that was kept to a conflict, or marks it
+// deleted
+func (s *Sqlite) withServerCopy(ctx context.Context, result types.SyncResult) (types.SyncResult, error) {
+	if result.Outcome != types.SyncConflict {
+		return result, nil
+	}
+	record, err := scanAttendance(s.Db.QueryRowContext(ctx, "select "+attendanceColumns+" from attendance_records where id = ?", result.RecordID))
+	switch {
+	case err == sql.ErrNoRows:
+		result.Deleted = true
+	case err != nil:
+		return result, err
+	default:
+		result.Server = &record
+	}
+	return result, nil
+}
+
+// tombstone remembers that a record is being deleted, and when, so sync
+// can tell a change made before the delete from one made after it
+func tombstone(ctx context.Context, tx *sql.Tx, id int64, deletedAt string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO attendance_tombstones (student_id, date, period_id, record_id, deleted_at)
+SELECT student_id, date(date), COALESCE(period_id, 0), id, ? FROM attendance_records WHERE id = ?
+ON CONFLICT (student_id, date, period_id) DO UPDATE SET record_id = excluded.record_id, deleted_at = excluded.deleted_at`, deletedAt, id)
+	return err
+}
+
+// reject turns a refusal by the calendar or the timetable into a rejected
+// outcome; other errors are returned as they are
+func reject(result types.SyncResult, err error) (types.SyncResult, error) {
+	if errors.Is(err, storage.ErrNonInstructionalDay) || errors.Is(err, storage.ErrPeriodNotScheduled) {
+		result.Outcome, result.Error = types.SyncRejected, err.Error()
+		return result, nil
+	}
+	return result, err
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestSyncAttendanceReplay(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	m := types.SyncMutation{ClientID: "7d3c4a1e-0b5f-4c1a-9f2e-1a2b3c4d5e6f", Op: types.SyncUpsert, StudentID: 1, ClassID: 1,
+		Date: date("2025-03-10"), Status: "Present", ModifiedAt: date("2025-03-10").Add(9 * time.Hour)}
+	first, err := s.SyncAttendance(ctx, m)
+	if err != nil || first.Outcome != types.SyncApplied || first.Replayed {
+		t.Fatalf("expected the first upload to apply, got %+v (%v)", first, err)
+	}
+
+	m.Status = "Absent"
+	again, err := s.SyncAttendance(ctx, m)
+	if err != nil || again.Outcome != types.SyncApplied || !again.Replayed || again.RecordID != first.RecordID {
+		t.Errorf("expected the stored outcome for record %d, got %+v (%v)", first.RecordID, again, err)
+	}
+	if record, err := s.GetAttendanceById(ctx, first.RecordID); err != nil || record.Status != "Present" {
+		t.Errorf("a replay should not write again, got %+v (%v)", record, err)
+	}
+}
+
+func TestSyncAttendanceTombstones(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	day := date("2025-03-10")
+	at := func(hours int) time.Time { return day.Add(time.Duration(hours) * time.Hour) }
+	n := 0
+	sync := func(op string, hours int) types.SyncResult {
+		t.Helper()
+		n++
+		result, err := s.SyncAttendance(ctx, types.SyncMutation{ClientID: string(rune('a' + n)), Op: op, StudentID: 1, ClassID: 1,
+			Date: day, Status: "Present", ModifiedAt: at(hours)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	created := sync(types.SyncUpsert, 8)
+	if created.Outcome != types.SyncApplied {
+		t.Fatalf("expected the upsert to apply, got %+v", created)
+	}
+	if result := sync(types.SyncDelete, 10); result.Outcome != types.SyncApplied {
+		t.Fatalf("expected the delete to apply, got %+v", result)
+	}
+
+	// changes made before the delete do not bring the record back
+	for _, op := range []string{types.SyncUpsert, types.SyncDelete} {
+		result := sync(op, 9)
+		if result.Outcome != types.SyncConflict || !result.Deleted || result.Server != nil || result.RecordID != created.RecordID {
+			t.Errorf("%s: expected a conflict with the deleted record %d, got %+v", op, created.RecordID, result)
+		}
+	}
+	if records, _ := s.GetAttendanceByDate(ctx, 1, day); len(records) != 0 {
+		t.Errorf("expected the record to stay deleted, got %+v", records)
+	}
+
+	if result := sync(types.SyncDelete, 11); result.Outcome != types.SyncApplied {
+		t.Errorf("deleting again after the delete should apply, got %+v", result)
+	}
+	recreated := sync(types.SyncUpsert, 12)
+	if recreated.Outcome != types.SyncApplied || recreated.RecordID == created.RecordID {
+		t.Fatalf("expected a change after the delete to write a new record, got %+v", recreated)
+	}
+	if result := sync(types.SyncUpsert, 11); result.Outcome != types.SyncConflict || result.Deleted || result.Server == nil {
+		t.Errorf("expected a conflict with the new record, got %+v", result)
+	}
+
+	// a delete outside sync leaves a tombstone too
+	if err := s.DeleteAttendanceRecord(ctx, recreated.RecordID); err != nil {
+		t.Fatal(err)
+	}
+	if result := sync(types.SyncUpsert, 13); result.Outcome != types.SyncConflict || !result.Deleted {
+		t.Errorf("expected a conflict with the deleted record, got %+v", result)
+	}
+}
+
+func TestSyncAttendanceDeleteUnknown(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	m := types.SyncMutation{ClientID: "0f8e2d4c-6b1a-4e3f-8c7d-9a0b1c2d3e4f", Op: types.SyncDelete, StudentID: 1,
+		Date: date("2025-03-10"), ModifiedAt: date("2025-03-10").Add(9 * time.Hour)}
+	for i := 0; i < 2; i++ {
+		result, err := s.SyncAttendance(ctx, m)
+		if err != nil || result.Outcome != types.SyncRejected || result.Replayed {
+			t.Errorf("upload %d: expected a delete of no record to be rejected, got %+v (%v)", i+1, result, err)
+		}
+	}
+}
+
+func TestSyncAttendanceTimes(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	in := time.Date(2025, 3, 10, 8, 20, 0, 0, time.Local)
+	m := types.SyncMutation{ClientID: "0f1e2d3c-4b5a-4987-8765-43210fedcba9", Op: types.SyncUpsert, StudentID: 1, ClassID: 1,
+		Date: date("2025-03-10"), Status: "Late", CheckInAt: &in, ModifiedAt: in}
+	result, err := s.SyncAttendance(ctx, m)
+	if err != nil || result.Outcome != types.SyncApplied {
+		t.Fatalf("expected the upload to apply, got %+v (%v)", result, err)
+	}
+	record, err := s.GetAttendanceById(ctx, result.RecordID)
+	if err != nil || record.CheckInAt == nil || !record.CheckInAt.Equal(in) || record.MinutesLate == 0 {
+		t.Errorf("expected the check-in time and minutes late to be stored, got %+v (%v)", record, err)
+	}
+
+	// a later upsert without times clears them
+	m.ClientID, m.Status, m.CheckInAt, m.ModifiedAt = "1a2b3c4d-5e6f-4a1b-8c2d-3e4f5a6b7c8d", "Present", nil, in.Add(time.Hour)
+	if result, err := s.SyncAttendance(ctx, m); err != nil || result.Outcome != types.SyncApplied {
+		t.Fatalf("expected the second upload to apply, got %+v (%v)", result, err)
+	}
+	record, err = s.GetAttendanceById(ctx, result.RecordID)
+	if err != nil || record.CheckInAt != nil || record.MinutesLate != 0 || record.Status != "Present" {
+		t.Errorf("expected the times and minutes to be cleared, got %+v (%v)", record, err)
+	}
+}
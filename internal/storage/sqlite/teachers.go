@@ -0,0 +1,307 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// titles are ignored when matching teacher names
+var titles = map[string]bool{"dr": true, "prof": true, "mr": true, "mrs": true, "ms": true, "miss": true, "sir": true}
+
+// teacherKey normalises a teacher name so that "Dr. Sarah  Johnson" and
+// "sarah johnson" match
+func teacherKey(name string) string {
+	fields := strings.Fields(strings.ToLower(strings.ReplaceAll(name, ".", " ")))
+	for len(fields) > 1 && titles[fields[0]] {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+type querier interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+// resolveTeacher returns the id of the teacher whose name matches, or
+// storage.ErrUnknownTeacher when there is none. A blank name resolves to no
+// teacher.
+func resolveTeacher(ctx context.Context, db querier, name string) (int64, error) {
+	key := teacherKey(name)
+	if key == "" {
+		return 0, nil
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id, name FROM teachers ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var existing string
+		if err := rows.Scan(&id, &existing); err != nil {
+			return 0, err
+		}
+		if teacherKey(existing) == key {
+			return id, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("teacher %q: %w", strings.TrimSpace(name), storage.ErrUnknownTeacher)
+}
+
+// checkTeacher fails with storage.ErrUnknownTeacher unless the teacher exists.
+// Foreign keys are not enforced, so writes that take a teacher id check it.
+func checkTeacher(ctx context.Context, db querier, id int64) error {
+	rows, err := db.QueryContext(ctx, "SELECT 1 FROM teachers WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("teacher %d: %w", id, storage.ErrUnknownTeacher)
+	}
+	return nil
+}
+
+// migrateTeacherNames creates one teacher per distinct classes.teacher_name
+// and points the classes at them. Names are taken in sorted order so that
+// teacher ids do not depend on map iteration. The old column is kept for
+// rollbacks but no longer written.
+func migrateTeacherNames(tx *sql.Tx) error {
+	ctx := context.Background()
+	rows, err := tx.QueryContext(ctx, "SELECT id, COALESCE(teacher_name, '') FROM classes ORDER BY id")
+	if err != nil {
+		return err
+	}
+	classes := map[string][]int64{}
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		classes[name] = append(classes[name], id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		teacherID, err := resolveTeacher(ctx, tx, name)
+		if errors.Is(err, storage.ErrUnknownTeacher) {
+			var result sql.Result
+			if result, err = tx.ExecContext(ctx, "INSERT INTO teachers (name) VALUES (?)", strings.TrimSpace(name)); err == nil {
+				teacherID, err = result.LastInsertId()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		for _, classID := range classes[name] {
+			if _, err := tx.ExecContext(ctx, "UPDATE classes SET teacher_id = ? WHERE id = ?", nullableID(teacherID), classID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// conflict turns unique constraint violations into storage.ErrConflict
+func conflict(err error, what string) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("%s: %w", what, storage.ErrConflict)
+	}
+	return err
+}
+
+// nullableString stores an empty string as NULL
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Teacher methods
+func (s *Sqlite) CreateTeacher(ctx context.Context, name, email, phone, employeeID string) (id int64, err error) {
+	const query = "INSERT INTO teachers (name, email, phone, employee_id) VALUES (?,?,?,?)"
+	ctx, span := startSpan(ctx, "CreateTeacher", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, name, email, phone, nullableString(employeeID))
+	if err != nil {
+		return 0, conflict(err, "employee id "+employeeID)
+	}
+	return result.LastInsertId()
+}
+
+const teacherColumns = "id, name, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(employee_id, '')"
+
+func (s *Sqlite) GetTeacherById(ctx context.Context, id int64) (teacher types.Teacher, err error) {
+	const query = "select " + teacherColumns + " from teachers where id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetTeacherById", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, id).Scan(&teacher.Id, &teacher.Name, &teacher.Email, &teacher.Phone, &teacher.EmployeeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Teacher{}, fmt.Errorf("no teacher found with id %d", id)
+		}
+		return types.Teacher{}, fmt.Errorf("query error %w", err)
+	}
+	return teacher, nil
+}
+
+func (s *Sqlite) GetTeachers(ctx context.Context) (teachers []types.Teacher, err error) {
+	const query = "select " + teacherColumns + " from teachers ORDER BY name"
+	ctx, span := startSpan(ctx, "GetTeachers", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teachers = []types.Teacher{}
+	for rows.Next() {
+		var t types.Teacher
+		if err := rows.Scan(&t.Id, &t.Name, &t.Email, &t.Phone, &t.EmployeeID); err != nil {
+			return nil, err
+		}
+		teachers = append(teachers, t)
+	}
+	return teachers, rows.Err()
+}
+
+// UpdateTeacher updates a teacher. Classes read their teacher_name from
+// the teacher, so a rename records an update event for each of them.
+func (s *Sqlite) UpdateTeacher(ctx context.Context, id int64, name, email, phone, employeeID string) (err error) {
+	const query = "UPDATE teachers SET name = ?, email = ?, phone = ?, employee_id = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateTeacher", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRowContext(ctx, "SELECT name FROM teachers WHERE id = ?", id).Scan(&oldName)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no teacher found with id %d", id)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, name, email, phone, nullableString(employeeID), id); err != nil {
+		return conflict(err, "employee id "+employeeID)
+	}
+	if name != oldName {
+		classes, err := teacherClasses(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		for _, classID := range classes {
+			if err := recordEvent(ctx, tx, resourceClass, actionUpdated, classID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteTeacher deletes a teacher and leaves their classes without one,
+// recording an update event for each of those classes
+func (s *Sqlite) DeleteTeacher(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM teachers WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteTeacher", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	classes, err := teacherClasses(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE classes SET teacher_id = NULL WHERE teacher_id = ?", id); err != nil {
+		return err
+	}
+	if err := execOne(ctx, tx, query, id, "teacher"); err != nil {
+		return err
+	}
+	for _, classID := range classes {
+		if err := recordEvent(ctx, tx, resourceClass, actionUpdated, classID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TeachesStudent reports whether the student is in one of the teacher's
+// classes, as their homeroom or through an active enrollment
+func (s *Sqlite) TeachesStudent(ctx context.Context, teacherID, studentID int64) (teaches bool, err error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM students s JOIN classes c ON c.id = s.class_id WHERE s.id = ? AND c.teacher_id = ?)
+    OR EXISTS (SELECT 1 FROM enrollments e JOIN classes c ON c.id = e.class_id WHERE e.student_id = ? AND e.status = 'active' AND c.teacher_id = ?)`
+	ctx, span := startSpan(ctx, "TeachesStudent", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, studentID, teacherID, studentID, teacherID).Scan(&teaches)
+	return teaches, err
+}
+
+// teacherClasses returns the ids of the classes a teacher teaches
+func teacherClasses(ctx context.Context, tx *sql.Tx, teacherID int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM classes WHERE teacher_id = ? ORDER BY id", teacherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ResolveTeacher finds a teacher by name, ignoring case, spacing and titles
+// such as "Dr.". Teachers are never created from free text.
+func (s *Sqlite) ResolveTeacher(ctx context.Context, name string) (id int64, err error) {
+	const query = "SELECT id, name FROM teachers ORDER BY id"
+	ctx, span := startSpan(ctx, "ResolveTeacher", query)
+	defer endSpan(span, &err)
+
+	return resolveTeacher(ctx, s.Db, name)
+}
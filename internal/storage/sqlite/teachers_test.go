@@ -0,0 +1,206 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+func TestResolveTeacher(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	id, err := s.CreateTeacher(ctx, "Dr. Sarah Johnson", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sarah johnson", "  Sarah   JOHNSON ", "Ms. Sarah Johnson"} {
+		if got, err := s.ResolveTeacher(ctx, name); err != nil || got != id {
+			t.Errorf("%q: expected teacher %d, got %d (%v)", name, id, got, err)
+		}
+	}
+	if got, err := s.ResolveTeacher(ctx, " "); err != nil || got != 0 {
+		t.Errorf("a blank name should resolve to no teacher, got %d (%v)", got, err)
+	}
+
+	if _, err := s.ResolveTeacher(ctx, "Sam Lee"); !errors.Is(err, storage.ErrUnknownTeacher) {
+		t.Errorf("Expected ErrUnknownTeacher, got %v", err)
+	}
+	if teachers, _ := s.GetTeachers(ctx); len(teachers) != 1 {
+		t.Errorf("resolving should never create teachers, got %+v", teachers)
+	}
+}
+
+func TestImportClassesChecksTeachers(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	id, err := s.CreateTeacher(ctx, "Sarah Johnson", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, classes := range [][]types.Class{
+		{{Name: "5A", TeacherID: id}, {Name: "5B", TeacherID: id + 1}},
+		{{Name: "5A", TeacherID: id}, {Name: "5B", TeacherName: "Sam Lee"}},
+	} {
+		if _, err := s.ImportClasses(ctx, classes); !errors.Is(err, storage.ErrUnknownTeacher) {
+			t.Errorf("Expected ErrUnknownTeacher, got %v", err)
+		}
+	}
+	if classes, _ := s.GetClasses(ctx, types.ClassFilter{}); len(classes) != 0 {
+		t.Errorf("a failed import should write nothing, got %+v", classes)
+	}
+
+	ids, err := s.ImportClasses(ctx, []types.Class{{Name: "5A", TeacherID: id}, {Name: "5B", TeacherName: "sarah johnson"}, {Name: "5C"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int64{id, id, 0} {
+		class, err := s.GetClassById(ctx, ids[i])
+		if err != nil || class.TeacherID != want {
+			t.Errorf("class %d: expected teacher %d, got %+v (%v)", i, want, class, err)
+		}
+	}
+}
+
+func TestMigrateTeacherNames(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Zoe Park", "Dr. Amy Chen", "zoe park", "", "Amy Chen"} {
+		if _, err := s.Db.ExecContext(ctx, "INSERT INTO classes (name, grade, section, teacher_name) VALUES ('c', '', '', ?)", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx, err := s.Db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateTeacherNames(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// sorted names: "Amy Chen" first, so it gets id 1 and "Dr. Amy Chen" matches it
+	teachers, err := s.GetTeachers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teachers) != 2 || teachers[0].Id != 1 || teachers[0].Name != "Amy Chen" || teachers[1].Name != "Zoe Park" {
+		t.Fatalf("unexpected teachers %+v", teachers)
+	}
+	classes, err := s.GetClasses(ctx, types.ClassFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int64]int64{1: 2, 2: 1, 3: 2, 4: 0, 5: 1}
+	for _, c := range classes {
+		if c.TeacherID != want[c.Id] {
+			t.Errorf("class %d: expected teacher %d, got %d", c.Id, want[c.Id], c.TeacherID)
+		}
+	}
+}
+
+func TestTeacherChangesRecordClassEvents(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	id, err := s.CreateTeacher(ctx, "Sarah Johnson", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := s.ImportClasses(ctx, []types.Class{{Name: "5A", TeacherID: id}, {Name: "5B"}, {Name: "5C", TeacherID: id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	classEvents := func() []int64 {
+		t.Helper()
+		events, err := s.GetEvents(ctx, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int64
+		for _, e := range events {
+			if e.Type == "class.updated" {
+				got = append(got, e.ResourceID)
+			}
+		}
+		return got
+	}
+
+	if err := s.UpdateTeacher(ctx, id, "Sarah Johnson", "sarah@example.com", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := classEvents(); len(got) != 0 {
+		t.Errorf("an unchanged name should not touch classes, got events for %v", got)
+	}
+
+	if err := s.UpdateTeacher(ctx, id, "Sarah Lee", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := classEvents(); len(got) != 2 || got[0] != ids[0] || got[1] != ids[2] {
+		t.Errorf("expected class events for %d and %d, got %v", ids[0], ids[2], got)
+	}
+
+	if err := s.DeleteTeacher(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if got := classEvents(); len(got) != 4 || got[2] != ids[0] || got[3] != ids[2] {
+		t.Errorf("expected two more class events after the delete, got %v", got)
+	}
+	if class, err := s.GetClassById(ctx, ids[0]); err != nil || class.TeacherID != 0 {
+		t.Errorf("expected class without a teacher, got %+v (%v)", class, err)
+	}
+	if err := s.UpdateTeacher(ctx, id, "Sarah Lee", "", "", ""); err == nil {
+		t.Error("expected an error updating a deleted teacher")
+	}
+}
+
+func TestTeachesStudent(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	lee, err := s.CreateTeacher(ctx, "Sam Lee", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := s.ImportClasses(ctx, []types.Class{{Name: "5A", TeacherID: lee}, {Name: "Art", TeacherID: lee}, {Name: "5B"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var students []int64
+	for _, name := range []string{"homeroom", "enrolled", "dropped", "other"} {
+		id, err := s.CreateStudent(ctx, name, name+"@example.com", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		students = append(students, id)
+	}
+	for _, q := range []struct {
+		query string
+		args  []any
+	}{
+		{"UPDATE students SET class_id = ? WHERE id = ?", []any{ids[0], students[0]}},
+		{"UPDATE students SET class_id = ? WHERE id IN (?, ?, ?)", []any{ids[2], students[1], students[2], students[3]}},
+		{"INSERT INTO enrollments (student_id, class_id, subject_id, start_date, status) VALUES (?, ?, 1, '2025-01-06', 'active')", []any{students[1], ids[1]}},
+		{"INSERT INTO enrollments (student_id, class_id, subject_id, start_date, status) VALUES (?, ?, 1, '2025-01-06', 'dropped')", []any{students[2], ids[1]}},
+	} {
+		if _, err := s.Db.ExecContext(ctx, q.query, q.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, want := range []bool{true, true, false, false} {
+		if got, err := s.TeachesStudent(ctx, lee, students[i]); err != nil || got != want {
+			t.Errorf("student %d: expected %v, got %v (%v)", students[i], want, got, err)
+		}
+	}
+	if got, err := s.TeachesStudent(ctx, lee+1, students[0]); err != nil || got {
+		t.Errorf("another teacher should not teach student %d, got %v (%v)", students[0], got, err)
+	}
+}
@@ -0,0 +1,413 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/timetable"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// rolledUpRemarks marks daily records written by the period roll-up
+const rolledUpRemarks = "rolled up from periods"
+
+// checkPeriod fails with storage.ErrPeriodNotScheduled unless the period
+// exists and, when the class has a timetable for the weekday of date, is
+// on it
+func checkPeriod(ctx context.Context, tx *sql.Tx, classID, periodID int64, date time.Time) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM periods WHERE id = ?)", periodID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no period found with id %d: %w", periodID, storage.ErrPeriodNotScheduled)
+	}
+	var scheduled, matching int
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(period_id = ?), 0) FROM timetable_entries WHERE class_id = ? AND weekday = ?",
+		periodID, classID, int(date.Weekday())).Scan(&scheduled, &matching)
+	if err != nil {
+		return err
+	}
+	if scheduled > 0 && matching == 0 {
+		return fmt.Errorf("period %d on %s: %w", periodID, date.Weekday(), storage.ErrPeriodNotScheduled)
+	}
+	return nil
+}
+
+// CreatePeriodAttendance marks a student for one period, replacing an
+// earlier mark of the same period, and rolls their daily record up. When the
+// class has a timetable for that weekday the period must be on it. Times
+// are classified against the period's hours as in CreateAttendanceRecord;
+// times left nil keep those of the earlier mark.
+func (s *Sqlite) CreatePeriodAttendance(ctx context.Context, studentID, classID, periodID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (id int64, err error) {
+	const query = `INSERT INTO attendance_records (student_id, class_id, period_id, date, status, remarks, check_in_at, check_out_at, minutes_late, minutes_early)
+VALUES (?,?,?,?,?,?,?,?,?,?)`
+	ctx, span := startSpan(ctx, "CreatePeriodAttendance", query)
+	defer endSpan(span, &err)
+
+	if err := s.checkInstructional(ctx, date); err != nil {
+		return 0, err
+	}
+	day := date.Format("2006-01-02")
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkPeriod(ctx, tx, classID, periodID, date); err != nil {
+		return 0, err
+	}
+
+	var checkInAt, checkOutAt *time.Time
+	err = tx.QueryRowContext(ctx, "SELECT id, check_in_at, check_out_at FROM attendance_records WHERE student_id = ? AND date = ? AND period_id = ?", studentID, day, periodID).
+		Scan(&id, &checkInAt, &checkOutAt)
+	found := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	times.CheckInAt = cmp.Or(times.CheckInAt, checkInAt)
+	times.CheckOutAt = cmp.Or(times.CheckOutAt, checkOutAt)
+	p, err := s.punctual(ctx, tx, classID, periodID, date, times)
+	if err != nil {
+		return 0, err
+	}
+	if status, err = onLeave(ctx, tx, studentID, date, cmp.Or(status, p.Status)); err != nil {
+		return 0, err
+	}
+	in, out := nullableTime(times.CheckInAt), nullableTime(times.CheckOutAt)
+
+	if !found {
+		result, err := tx.ExecContext(ctx, query, studentID, classID, periodID, day, status, remarks, in, out, p.MinutesLate, p.MinutesEarly)
+		if err != nil {
+			return 0, err
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return 0, err
+		}
+		if err := recordEvent(ctx, tx, resourceAttendance, actionCreated, id); err != nil {
+			return 0, err
+		}
+	} else {
+		_, err := tx.ExecContext(ctx, "UPDATE attendance_records SET status = ?, remarks = ?, check_in_at = ?, check_out_at = ?, minutes_late = ?, minutes_early = ? WHERE id = ?",
+			status, remarks, in, out, p.MinutesLate, p.MinutesEarly, id)
+		if err != nil {
+			return 0, err
+		}
+		if err := recordEvent(ctx, tx, resourceAttendance, actionUpdated, id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.rollUpDay(ctx, tx, studentID, classID, date); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// rollUpDay rewrites the student's daily record for the date from their
+// period records. A daily record marked by hand is left as it is; only one
+// the roll-up wrote is updated, and removed once no period records remain.
+// The day is as late as the first period marked and left as early as the
+// last one. An Absent day on approved leave is Excused. Changes to the
+// daily record are recorded in the outbox like any other.
+func (s *Sqlite) rollUpDay(ctx context.Context, tx *sql.Tx, studentID, classID int64, date time.Time) error {
+	day := date.Format("2006-01-02")
+	rows, err := tx.QueryContext(ctx, `SELECT a.status, a.minutes_late, a.minutes_early FROM attendance_records a
+LEFT JOIN periods p ON p.id = a.period_id
+WHERE a.student_id = ? AND date(a.date) = ? AND a.period_id IS NOT NULL
+ORDER BY p.start_time`, studentID, day)
+	if err != nil {
+		return err
+	}
+	var statuses []string
+	var minutesLate, minutesEarly int
+	for rows.Next() {
+		var status string
+		var late int
+		if err := rows.Scan(&status, &late, &minutesEarly); err != nil {
+			rows.Close()
+			return err
+		}
+		if len(statuses) == 0 {
+			minutesLate = late
+		}
+		statuses = append(statuses, status)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var scheduled int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM timetable_entries WHERE class_id = ? AND weekday = ?", classID, int(date.Weekday())).Scan(&scheduled)
+	if err != nil {
+		return err
+	}
+
+	status, err := onLeave(ctx, tx, studentID, date, timetable.RollUp(s.rollUp, statuses, scheduled))
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		var id int64
+		err := tx.QueryRowContext(ctx, "SELECT id FROM attendance_records WHERE student_id = ? AND date(date) = ? AND period_id IS NULL AND remarks = ?",
+			studentID, day, rolledUpRemarks).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, resourceAttendance, actionDeleted, id); err != nil {
+			return err
+		}
+		if err := tombstone(ctx, tx, id, time.Now().UTC().Format(syncTimeFormat)); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM attendance_records WHERE id = ?", id)
+		return err
+	}
+
+	var id int64
+	var current types.AttendanceRecord
+	err = tx.QueryRowContext(ctx, "SELECT id, status, COALESCE(remarks, ''), minutes_late, minutes_early FROM attendance_records WHERE student_id = ? AND date(date) = ? AND period_id IS NULL", studentID, day).
+		Scan(&id, &current.Status, &current.Remarks, &current.MinutesLate, &current.MinutesEarly)
+	if err == sql.ErrNoRows {
+		result, err := tx.ExecContext(ctx, "INSERT INTO attendance_records (student_id, class_id, date, status, remarks, minutes_late, minutes_early) VALUES (?,?,?,?,?,?,?)",
+			studentID, classID, day, status, rolledUpRemarks, minutesLate, minutesEarly)
+		if err != nil {
+			return err
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, resourceAttendance, actionCreated, id)
+	}
+	if err != nil {
+		return err
+	}
+	if current.Remarks != rolledUpRemarks || current.Status == status && current.MinutesLate == minutesLate && current.MinutesEarly == minutesEarly {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE attendance_records SET status = ?, minutes_late = ?, minutes_early = ? WHERE id = ?", status, minutesLate, minutesEarly, id)
+	if err != nil {
+		return err
+	}
+	return recordEvent(ctx, tx, resourceAttendance, actionUpdated, id)
+}
+
+// Timetable methods
+func (s *Sqlite) CreatePeriod(ctx context.Context, name, startTime, endTime string) (id int64, err error) {
+	const query = "INSERT INTO periods (name, start_time, end_time) VALUES (?,?,?)"
+	ctx, span := startSpan(ctx, "CreatePeriod", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, name, startTime, endTime)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetPeriodById(ctx context.Context, id int64) (period types.Period, err error) {
+	const query = "select id, name, start_time, end_time from periods where id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetPeriodById", query)
+	defer endSpan(span, &err)
+
+	err = s.Db.QueryRowContext(ctx, query, id).Scan(&period.Id, &period.Name, &period.StartTime, &period.EndTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Period{}, fmt.Errorf("no period found with id %d", id)
+		}
+		return types.Period{}, fmt.Errorf("query error %w", err)
+	}
+	return period, nil
+}
+
+func (s *Sqlite) GetPeriods(ctx context.Context) (periods []types.Period, err error) {
+	const query = "select id, name, start_time, end_time from periods ORDER BY start_time"
+	ctx, span := startSpan(ctx, "GetPeriods", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods = []types.Period{}
+	for rows.Next() {
+		var p types.Period
+		if err := rows.Scan(&p.Id, &p.Name, &p.StartTime, &p.EndTime); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+func (s *Sqlite) UpdatePeriod(ctx context.Context, id int64, name, startTime, endTime string) (err error) {
+	const query = "UPDATE periods SET name = ?, start_time = ?, end_time = ? WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdatePeriod", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, name, startTime, endTime, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no period found with id %d", id)
+	}
+	return nil
+}
+
+// DeletePeriod deletes a period and its timetable slots; attendance already
+// marked for it is kept
+func (s *Sqlite) DeletePeriod(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM periods WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeletePeriod", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM timetable_entries WHERE period_id = ?", id); err != nil {
+		return err
+	}
+	if err := execOne(ctx, tx, query, id, "period"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Sqlite) CreateSubject(ctx context.Context, name, code string) (id int64, err error) {
+	const query = "INSERT INTO subjects (name, code) VALUES (?,?)"
+	ctx, span := startSpan(ctx, "CreateSubject", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, name, code)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *Sqlite) GetSubjects(ctx context.Context) (subjects []types.Subject, err error) {
+	const query = "select id, name, COALESCE(code, '') from subjects ORDER BY name"
+	ctx, span := startSpan(ctx, "GetSubjects", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects = []types.Subject{}
+	for rows.Next() {
+		var subject types.Subject
+		if err := rows.Scan(&subject.Id, &subject.Name, &subject.Code); err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, subject)
+	}
+	return subjects, rows.Err()
+}
+
+// DeleteSubject deletes a subject, removing it from every timetable along
+// with its enrollments
+func (s *Sqlite) DeleteSubject(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM subjects WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteSubject", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"timetable_entries", "enrollments"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE subject_id = ?", id); err != nil {
+			return err
+		}
+	}
+	if err := execOne(ctx, tx, query, id, "subject"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTimetable lists a class's weekly schedule by weekday and period start
+func (s *Sqlite) GetTimetable(ctx context.Context, classID int64) (entries []types.TimetableEntry, err error) {
+	const query = `SELECT t.id, t.class_id, t.weekday, t.period_id, t.subject_id,
+    COALESCE(p.name, ''), COALESCE(sj.name, ''), COALESCE(p.start_time, ''), COALESCE(p.end_time, '')
+FROM timetable_entries t
+LEFT JOIN periods p ON p.id = t.period_id
+LEFT JOIN subjects sj ON sj.id = t.subject_id
+WHERE t.class_id = ?
+ORDER BY t.weekday, p.start_time`
+	ctx, span := startSpan(ctx, "GetTimetable", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries = []types.TimetableEntry{}
+	for rows.Next() {
+		var e types.TimetableEntry
+		err := rows.Scan(&e.Id, &e.ClassID, &e.Weekday, &e.PeriodID, &e.SubjectID, &e.PeriodName, &e.SubjectName, &e.StartTime, &e.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SetTimetable replaces a class's weekly schedule in one transaction
+func (s *Sqlite) SetTimetable(ctx context.Context, classID int64, entries []types.TimetableEntry) (err error) {
+	const query = "INSERT INTO timetable_entries (class_id, weekday, period_id, subject_id) VALUES (?,?,?,?)"
+	ctx, span := startSpan(ctx, "SetTimetable", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM timetable_entries WHERE class_id = ?", classID); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var known bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM periods WHERE id = ?) AND EXISTS (SELECT 1 FROM subjects WHERE id = ?)", e.PeriodID, e.SubjectID).Scan(&known)
+		if err != nil {
+			return err
+		}
+		if !known {
+			return fmt.Errorf("weekday %d: unknown period %d or subject %d", e.Weekday, e.PeriodID, e.SubjectID)
+		}
+		if _, err := tx.ExecContext(ctx, query, classID, e.Weekday, e.PeriodID, e.SubjectID); err != nil {
+			return fmt.Errorf("weekday %d period %d: %w", e.Weekday, e.PeriodID, err)
+		}
+	}
+	return tx.Commit()
+}
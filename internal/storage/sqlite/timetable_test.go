@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// timetableFixture creates a class with two periods on Mondays, a third
+// period it does not have, and a student
+func timetableFixture(t *testing.T, s *Sqlite) (classID, studentID int64, periods [3]int64) {
+	t.Helper()
+	ctx := context.Background()
+	var err error
+	if classID, err = s.CreateClass(ctx, "5A", "5", "A", 0, 30); err != nil {
+		t.Fatal(err)
+	}
+	if studentID, err = s.CreateStudent(ctx, "Asha Rao", "asha@example.com", 10); err != nil {
+		t.Fatal(err)
+	}
+	subjectID, err := s.CreateSubject(ctx, "Maths", "MATH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, hours := range [][2]string{{"08:00", "09:00"}, {"09:00", "10:00"}, {"10:00", "11:00"}} {
+		if periods[i], err = s.CreatePeriod(ctx, "P"+hours[0], hours[0], hours[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = s.SetTimetable(ctx, classID, []types.TimetableEntry{
+		{Weekday: int(time.Monday), PeriodID: periods[0], SubjectID: subjectID},
+		{Weekday: int(time.Monday), PeriodID: periods[1], SubjectID: subjectID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return classID, studentID, periods
+}
+
+// daily returns the student's daily records on day
+func daily(t *testing.T, s *Sqlite, studentID int64, day string) []types.AttendanceRecord {
+	t.Helper()
+	records, err := s.GetAttendance(context.Background(), types.AttendanceFilter{StudentID: studentID, From: date(day), To: date(day)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestRollUpDay(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	classID, studentID, periods := timetableFixture(t, s)
+
+	mark := func(period int64, status string) int64 {
+		t.Helper()
+		id, err := s.CreatePeriodAttendance(ctx, studentID, classID, period, date("2025-03-10"), status, "", types.AttendanceTimes{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// missing the first of two periods is late; missing both is absent
+	first := mark(periods[0], "Absent")
+	if records := daily(t, s, studentID, "2025-03-10"); len(records) != 1 || records[0].Status != "Late" || records[0].Remarks != rolledUpRemarks {
+		t.Fatalf("expected a rolled-up Late day, got %+v", records)
+	}
+	second := mark(periods[1], "Absent")
+	if records := daily(t, s, studentID, "2025-03-10"); len(records) != 1 || records[0].Status != "Absent" {
+		t.Errorf("expected the day to be rolled up to Absent, got %+v", records)
+	}
+
+	// the rolled-up record goes with the last period record
+	if err := s.DeleteAttendanceRecord(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if records := daily(t, s, studentID, "2025-03-10"); len(records) != 1 {
+		t.Errorf("expected the day to stay while a period is marked, got %+v", records)
+	}
+	if err := s.DeleteAttendanceRecord(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	if records := daily(t, s, studentID, "2025-03-10"); len(records) != 0 {
+		t.Errorf("expected the rolled-up day to be removed, got %+v", records)
+	}
+}
+
+func TestRollUpDayKeepsManualRecord(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	classID, studentID, periods := timetableFixture(t, s)
+
+	id, err := s.CreateAttendanceRecord(ctx, studentID, classID, date("2025-03-10"), "Present", "doctor's note", types.AttendanceTimes{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, period := range periods[:2] {
+		if _, err := s.CreatePeriodAttendance(ctx, studentID, classID, period, date("2025-03-10"), "Absent", "", types.AttendanceTimes{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if record, err := s.GetAttendanceById(ctx, id); err != nil || record.Status != "Present" || record.Remarks != "doctor's note" {
+		t.Errorf("expected the manual daily record to stay, got %+v (%v)", record, err)
+	}
+}
+
+func TestCreatePeriodAttendanceUnscheduled(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	classID, studentID, periods := timetableFixture(t, s)
+
+	for _, period := range []int64{periods[2], periods[2] + 100} {
+		_, err := s.CreatePeriodAttendance(ctx, studentID, classID, period, date("2025-03-10"), "Present", "", types.AttendanceTimes{})
+		if !errors.Is(err, storage.ErrPeriodNotScheduled) {
+			t.Errorf("period %d: expected ErrPeriodNotScheduled, got %v", period, err)
+		}
+	}
+	if records := daily(t, s, studentID, "2025-03-10"); len(records) != 0 {
+		t.Errorf("expected nothing rolled up, got %+v", records)
+	}
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/tukesh1/student-api/internal/storage/sqlite")
+
+// startSpan starts a child span for one storage method, tagged with the SQL it runs
+func startSpan(ctx context.Context, method, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "sqlite."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation.name", method),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+// endSpan records the method's error, if any, and ends the span.
+// Use it as `defer endSpan(span, &err)` with a named error result.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
@@ -0,0 +1,248 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+    COALESCE(d.last_error, ''), COALESCE(d.response_status, 0), d.created_at, d.delivered_at, w.url, w.secret`
+
+// Webhook methods
+
+// CreateWebhook stores a subscription. Event types are kept as a comma
+// separated list so deliveries can be matched in SQL.
+func (s *Sqlite) CreateWebhook(ctx context.Context, hook types.Webhook) (id int64, err error) {
+	const query = "INSERT INTO webhooks (url, secret, events, active) VALUES (?,?,?,?)"
+	ctx, span := startSpan(ctx, "CreateWebhook", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, hook.URL, hook.Secret, strings.Join(hook.Events, ","), hook.Active)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetWebhookById returns a webhook without its secret
+func (s *Sqlite) GetWebhookById(ctx context.Context, id int64) (hook types.Webhook, err error) {
+	const query = "SELECT id, url, events, active, created_at FROM webhooks WHERE id = ? LIMIT 1"
+	ctx, span := startSpan(ctx, "GetWebhookById", query)
+	defer endSpan(span, &err)
+
+	hook, err = scanWebhook(s.Db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Webhook{}, fmt.Errorf("no webhook found with id %d", id)
+		}
+		return types.Webhook{}, fmt.Errorf("query error %w", err)
+	}
+	return hook, nil
+}
+
+// GetWebhooks returns every webhook without its secret
+func (s *Sqlite) GetWebhooks(ctx context.Context) (hooks []types.Webhook, err error) {
+	const query = "SELECT id, url, events, active, created_at FROM webhooks ORDER BY id"
+	ctx, span := startSpan(ctx, "GetWebhooks", query)
+	defer endSpan(span, &err)
+
+	rows, err := s.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks = []types.Webhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+func scanWebhook(row interface{ Scan(...any) error }) (types.Webhook, error) {
+	var hook types.Webhook
+	var events string
+	if err := row.Scan(&hook.Id, &hook.URL, &events, &hook.Active, &hook.CreatedAt); err != nil {
+		return types.Webhook{}, err
+	}
+	hook.Events = strings.Split(events, ",")
+	return hook, nil
+}
+
+// UpdateWebhook replaces a webhook's URL, events and active flag. The secret
+// is only replaced when hook.Secret is set.
+func (s *Sqlite) UpdateWebhook(ctx context.Context, id int64, hook types.Webhook) (err error) {
+	const query = "UPDATE webhooks SET url = ?, events = ?, active = ?, secret = COALESCE(?, secret) WHERE id = ?"
+	ctx, span := startSpan(ctx, "UpdateWebhook", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, hook.URL, strings.Join(hook.Events, ","), hook.Active, nullableString(hook.Secret), id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no webhook found with id %d", id)
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook and its deliveries
+func (s *Sqlite) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	const query = "DELETE FROM webhooks WHERE id = ?"
+	ctx, span := startSpan(ctx, "DeleteWebhook", query)
+	defer endSpan(span, &err)
+
+	tx, err := s.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return err
+	}
+	if err := execOne(ctx, tx, query, id, "webhook"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EnqueueWebhookEvent queues one delivery of the event for every active
+// webhook subscribed to its type, and returns how many were queued. An event
+// already queued for a webhook is not queued twice.
+func (s *Sqlite) EnqueueWebhookEvent(ctx context.Context, eventID, eventType, payload string) (queued int, err error) {
+	const query = `INSERT OR IGNORE INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at)
+SELECT id, ?, ?, ?, ? FROM webhooks
+WHERE active = 1 AND (events = '*' OR ',' || events || ',' LIKE '%,' || ? || ',%')`
+	ctx, span := startSpan(ctx, "EnqueueWebhookEvent", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, eventID, eventType, payload, time.Now().UTC(), eventType)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
+
+// GetDueWebhookDeliveries returns up to limit pending deliveries whose next
+// attempt is due, oldest first, with the URL and secret to send them with
+func (s *Sqlite) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) (deliveries []types.WebhookDelivery, err error) {
+	const query = "SELECT " + deliveryColumns + ` FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.status = 'pending' AND d.next_attempt_at <= ? ORDER BY d.next_attempt_at, d.id LIMIT ?`
+	ctx, span := startSpan(ctx, "GetDueWebhookDeliveries", query)
+	defer endSpan(span, &err)
+
+	return s.queryDeliveries(ctx, query, now.UTC(), limit)
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt
+func (s *Sqlite) UpdateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) (err error) {
+	const query = `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?,
+    response_status = ?, delivered_at = ? WHERE id = ?`
+	ctx, span := startSpan(ctx, "UpdateWebhookDelivery", query)
+	defer endSpan(span, &err)
+
+	var deliveredAt any
+	if d.DeliveredAt != nil {
+		deliveredAt = d.DeliveredAt.UTC()
+	}
+	var responseStatus any
+	if d.ResponseStatus != 0 {
+		responseStatus = d.ResponseStatus
+	}
+	result, err := s.Db.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt.UTC(), nullableString(d.LastError),
+		responseStatus, deliveredAt, d.Id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no webhook delivery found with id %d", d.Id)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetWebhookDeliveries(ctx context.Context, filter types.WebhookDeliveryFilter) (deliveries []types.WebhookDelivery, err error) {
+	var where []string
+	var args []any
+	if filter.WebhookID != 0 {
+		where = append(where, "d.webhook_id = ?")
+		args = append(args, filter.WebhookID)
+	}
+	if filter.Status != "" {
+		where = append(where, "d.status = ?")
+		args = append(args, filter.Status)
+	}
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY d.id DESC"
+	ctx, span := startSpan(ctx, "GetWebhookDeliveries", query)
+	defer endSpan(span, &err)
+
+	return s.queryDeliveries(ctx, query, args...)
+}
+
+// RedeliverWebhook puts a delivery back in the queue with a fresh set of
+// attempts, due now. Delivered and dead deliveries alike can be redelivered.
+func (s *Sqlite) RedeliverWebhook(ctx context.Context, deliveryID int64) (err error) {
+	const query = `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = ?,
+    last_error = NULL, response_status = NULL, delivered_at = NULL WHERE id = ?`
+	ctx, span := startSpan(ctx, "RedeliverWebhook", query)
+	defer endSpan(span, &err)
+
+	result, err := s.Db.ExecContext(ctx, query, time.Now().UTC(), deliveryID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no webhook delivery found with id %d", deliveryID)
+	}
+	return nil
+}
+
+func (s *Sqlite) queryDeliveries(ctx context.Context, query string, args ...any) ([]types.WebhookDelivery, error) {
+	rows, err := s.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		var d types.WebhookDelivery
+		var deliveredAt sql.NullTime
+		err := rows.Scan(&d.Id, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastError, &d.ResponseStatus, &d.CreatedAt, &deliveredAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
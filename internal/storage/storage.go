@@ -1,32 +1,206 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"io"
 	"time"
 
 	"github.com/tukesh1/student-api/internal/types"
 )
 
-// make interface
+// ErrNonInstructionalDay is returned when attendance is written for a
+// weekend, holiday, closure or a day outside the terms
+var ErrNonInstructionalDay = errors.New("not an instructional day")
+
+// ErrConflict is returned when a write would duplicate a unique value
+var ErrConflict = errors.New("already exists")
+
+// ErrPeriodNotScheduled is returned when period attendance is written for a
+// period the class's timetable does not have on that weekday
+var ErrPeriodNotScheduled = errors.New("period not scheduled for the class")
+
+// ErrClassFull is returned when an enrollment would take a class over its
+// capacity
+var ErrClassFull = errors.New("class is full")
+
+// ErrAlreadyReviewed is returned when a leave request that was already
+// approved or rejected is reviewed again
+var ErrAlreadyReviewed = errors.New("leave request already reviewed")
+
+// ErrUnknownTeacher is returned when a class names a teacher, by id or by
+// name, that does not exist
+var ErrUnknownTeacher = errors.New("unknown teacher")
+
+// ErrInvalidGroupBy is returned when analytics are asked for a grouping
+// the storage does not know
+var ErrInvalidGroupBy = errors.New("invalid group_by")
+
+// ErrBlobNotFound is returned when a blob store has nothing under a key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps file contents, such as attachments, outside the
+// database. Keys are slash-separated relative paths chosen by the caller;
+// putting a key again replaces its content.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// make interface; every method takes the request context so tracing spans
+// and cancellation propagate down to the database
 type Storage interface {
 	// Student methods
-	CreateStudent(name string, email string, age int) (int64, error)
-	GetStudentById(id int64) (types.Student, error)
-	GetStudents() ([]types.Student, error)
-	UpdateStudent(id int64, name string, email string, age int) error
-	DeleteStudent(id int64) error
+	CreateStudent(ctx context.Context, name string, email string, age int) (int64, error)
+	GetStudentById(ctx context.Context, id int64) (types.Student, error)
+	GetStudents(ctx context.Context, filter types.StudentFilter) ([]types.Student, error)
+	StreamStudents(ctx context.Context, filter types.StudentFilter, fn func(types.Student) error) error
+	UpdateStudent(ctx context.Context, id int64, name string, email string, age int) error
+	DeleteStudent(ctx context.Context, id int64) error
+	SetStudentPhoto(ctx context.Context, id int64, photoURL string) error
+	ImportStudents(ctx context.Context, students []types.Student) ([]int64, error)
 
 	// Class methods
-	CreateClass(name, grade, section, teacherName string) (int64, error)
-	GetClassById(id int64) (types.Class, error)
-	GetClasses() ([]types.Class, error)
-	UpdateClass(id int64, name, grade, section, teacherName string) error
-	DeleteClass(id int64) error
+	CreateClass(ctx context.Context, name, grade, section string, teacherID int64, capacity int) (int64, error)
+	GetClassById(ctx context.Context, id int64) (types.Class, error)
+	GetClasses(ctx context.Context, filter types.ClassFilter) ([]types.Class, error)
+	StreamClasses(ctx context.Context, filter types.ClassFilter, fn func(types.Class) error) error
+	UpdateClass(ctx context.Context, id int64, name, grade, section string, teacherID int64, capacity int) error
+	DeleteClass(ctx context.Context, id int64) error
+	SetClassGrace(ctx context.Context, id int64, lateMinutes, earlyLeaveMinutes *int) error
+	ImportClasses(ctx context.Context, classes []types.Class) ([]int64, error)
+
+	// Teacher methods
+	CreateTeacher(ctx context.Context, name, email, phone, employeeID string) (int64, error)
+	GetTeacherById(ctx context.Context, id int64) (types.Teacher, error)
+	GetTeachers(ctx context.Context) ([]types.Teacher, error)
+	UpdateTeacher(ctx context.Context, id int64, name, email, phone, employeeID string) error
+	DeleteTeacher(ctx context.Context, id int64) error
+	ResolveTeacher(ctx context.Context, name string) (int64, error) // storage.ErrUnknownTeacher when no teacher matches
+	TeachesStudent(ctx context.Context, teacherID, studentID int64) (bool, error)
 
 	// Attendance methods
-	CreateAttendanceRecord(studentID, classID int64, date time.Time, status, remarks string) (int64, error)
-	GetAttendanceByDate(classID int64, date time.Time) ([]types.AttendanceRecord, error)
-	GetAttendanceByStudent(studentID int64, startDate, endDate time.Time) ([]types.AttendanceRecord, error)
-	UpdateAttendanceRecord(id int64, status, remarks string) error
-	DeleteAttendanceRecord(id int64) error
-	GetAttendanceReport(studentID int64, startDate, endDate time.Time) (types.AttendanceReport, error)
+	CreateAttendanceRecord(ctx context.Context, studentID, classID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (int64, error)
+	GetAttendanceByDate(ctx context.Context, classID int64, date time.Time) ([]types.AttendanceRecord, error)
+	GetAttendanceByStudent(ctx context.Context, studentID int64, startDate, endDate time.Time) ([]types.AttendanceRecord, error)
+	GetAttendance(ctx context.Context, filter types.AttendanceFilter) ([]types.AttendanceRecord, error)
+	StreamAttendance(ctx context.Context, filter types.AttendanceFilter, fn func(types.AttendanceRecord) error) error
+	CreatePeriodAttendance(ctx context.Context, studentID, classID, periodID int64, date time.Time, status, remarks string, times types.AttendanceTimes) (int64, error)
+	GetAttendanceById(ctx context.Context, id int64) (types.AttendanceRecord, error)
+	UpdateAttendanceRecord(ctx context.Context, id int64, status, remarks string, times types.AttendanceTimes) error
+	DeleteAttendanceRecord(ctx context.Context, id int64) error
+	GetAttendanceReport(ctx context.Context, studentID int64, startDate, endDate time.Time) (types.AttendanceReport, error)
+
+	// Analytics methods
+	GetAttendanceAnalytics(ctx context.Context, query types.AnalyticsQuery) ([]types.AttendanceStats, error)
+
+	// Timetable methods
+	CreatePeriod(ctx context.Context, name, startTime, endTime string) (int64, error)
+	GetPeriodById(ctx context.Context, id int64) (types.Period, error)
+	GetPeriods(ctx context.Context) ([]types.Period, error)
+	UpdatePeriod(ctx context.Context, id int64, name, startTime, endTime string) error
+	DeletePeriod(ctx context.Context, id int64) error
+	CreateSubject(ctx context.Context, name, code string) (int64, error)
+	GetSubjects(ctx context.Context) ([]types.Subject, error)
+	DeleteSubject(ctx context.Context, id int64) error
+	GetTimetable(ctx context.Context, classID int64) ([]types.TimetableEntry, error)
+	SetTimetable(ctx context.Context, classID int64, entries []types.TimetableEntry) error
+
+	// Calendar methods
+	CreateAcademicYear(ctx context.Context, name string, startDate, endDate time.Time) (int64, error)
+	GetAcademicYearById(ctx context.Context, id int64) (types.AcademicYear, error)
+	GetAcademicYears(ctx context.Context) ([]types.AcademicYear, error)
+	DeleteAcademicYear(ctx context.Context, id int64) error
+	CreateTerm(ctx context.Context, academicYearID int64, name string, startDate, endDate time.Time) (int64, error)
+	GetTerms(ctx context.Context, academicYearID int64) ([]types.Term, error)
+	DeleteTerm(ctx context.Context, id int64) error
+	CreateClosure(ctx context.Context, name, kind string, startDate, endDate time.Time) (int64, error)
+	GetClosures(ctx context.Context, from, to time.Time) ([]types.Closure, error)
+	DeleteClosure(ctx context.Context, id int64) error
+	ImportClosures(ctx context.Context, closures []types.Closure) ([]int64, error)
+	GetCalendarDays(ctx context.Context, from, to time.Time) ([]types.CalendarDay, error)
+
+	// Guardian methods
+	CreateGuardian(ctx context.Context, guardian types.Guardian) (int64, error)
+	GetGuardianById(ctx context.Context, id int64) (types.Guardian, error)
+	GetGuardians(ctx context.Context) ([]types.Guardian, error)
+	UpdateGuardian(ctx context.Context, id int64, guardian types.Guardian) error
+	DeleteGuardian(ctx context.Context, id int64) error
+	GetStudentGuardians(ctx context.Context, studentID int64) ([]types.StudentGuardian, error)
+	LinkGuardian(ctx context.Context, studentID, guardianID int64, relationship string, primary bool) error
+	UnlinkGuardian(ctx context.Context, studentID, guardianID int64) error
+	GetGuardianStudents(ctx context.Context, guardianID int64) ([]types.Student, error)
+	SetGuardianPassword(ctx context.Context, id int64, passwordHash string) error
+	GetGuardianLogin(ctx context.Context, email string) (int64, string, error)
+	CreateGuardianSession(ctx context.Context, guardianID int64, tokenHash string, expiresAt time.Time) error
+	GetGuardianSession(ctx context.Context, tokenHash string) (int64, error)
+	DeleteGuardianSession(ctx context.Context, tokenHash string) error
+
+	// Notification methods
+	QueueNotification(ctx context.Context, studentID int64, date time.Time) (bool, error)
+	CancelNotification(ctx context.Context, studentID int64, date time.Time) error
+	GetPendingNotifications(ctx context.Context, limit int) ([]types.Notification, error)
+	UpdateNotification(ctx context.Context, notification types.Notification) error
+	GetNotifications(ctx context.Context, filter types.NotificationFilter) ([]types.Notification, error)
+	GetNotificationTemplates(ctx context.Context) ([]types.NotificationTemplate, error)
+	SaveNotificationTemplate(ctx context.Context, template types.NotificationTemplate) error
+
+	// Outbox methods
+	GetUnpublishedEvents(ctx context.Context, limit int) ([]types.Event, error)
+	MarkEventsPublished(ctx context.Context, throughSeq int64) error
+	GetEvents(ctx context.Context, since int64, limit int) ([]types.Event, error)
+	GetEventSeqRange(ctx context.Context) (int64, int64, error)
+	PruneEvents(ctx context.Context, before time.Time) (int64, error)
+
+	// Sync methods
+	SyncAttendance(ctx context.Context, mutation types.SyncMutation) (types.SyncResult, error)
+
+	// Check-in methods
+	CheckIn(ctx context.Context, checkIn types.CheckIn) (int64, error)
+	GetCheckInById(ctx context.Context, id int64) (types.CheckIn, error)
+	GetCheckIns(ctx context.Context, filter types.CheckInFilter) ([]types.CheckIn, error)
+	OverrideCheckIn(ctx context.Context, id int64, status, remarks string) error
+	GetBadgeVersion(ctx context.Context, studentID int64) (int64, error)
+	RevokeBadge(ctx context.Context, studentID int64) (int64, error)
+
+	// Leave methods
+	CreateLeaveRequest(ctx context.Context, leave types.LeaveRequest) (int64, error)
+	GetLeaveRequestById(ctx context.Context, id int64) (types.LeaveRequest, error)
+	GetLeaveRequests(ctx context.Context, filter types.LeaveFilter) ([]types.LeaveRequest, error)
+	ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (int, error)
+
+	// Attachment methods
+	CreateAttachment(ctx context.Context, attachment types.Attachment) (int64, error)
+	GetAttachmentById(ctx context.Context, id int64) (types.Attachment, error)
+	GetAttachments(ctx context.Context, ownerType string, ownerID int64) ([]types.Attachment, error)
+	DeleteAttachment(ctx context.Context, id int64) error
+
+	// Webhook methods
+	CreateWebhook(ctx context.Context, hook types.Webhook) (int64, error)
+	GetWebhookById(ctx context.Context, id int64) (types.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]types.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int64, hook types.Webhook) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	EnqueueWebhookEvent(ctx context.Context, eventID, eventType, payload string) (int, error)
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]types.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery types.WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, filter types.WebhookDeliveryFilter) ([]types.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, deliveryID int64) error
+
+	// Enrollment methods
+	CreateEnrollment(ctx context.Context, studentID, classID, subjectID int64, startDate, endDate time.Time) (int64, error)
+	GetEnrollmentById(ctx context.Context, id int64) (types.Enrollment, error)
+	GetEnrollments(ctx context.Context, filter types.EnrollmentFilter) ([]types.Enrollment, error)
+	UpdateEnrollment(ctx context.Context, id int64, status string, endDate time.Time) error
+
+	// Alert methods
+	GetAlerts(ctx context.Context, filter types.AlertFilter) ([]types.Alert, error)
+	GetAlertById(ctx context.Context, id int64) (types.Alert, error)
+	RaiseAlert(ctx context.Context, alert types.Alert) (int64, error)
+	ResolveAlerts(ctx context.Context, studentID int64, rule, note string) error
+	UpdateAlertStatus(ctx context.Context, id int64, status, note string) error
+
+	// Stats methods
+	GetStats(ctx context.Context, date time.Time) (types.Stats, error)
 }
@@ -0,0 +1,44 @@
+package timetable
+
+import "time"
+
+// Schedule is the span a record is due for, a period or a school day, and
+// the grace allowed at either end
+type Schedule struct {
+	Start, End      time.Time
+	LateGrace       time.Duration
+	EarlyLeaveGrace time.Duration
+}
+
+// Punctuality is how a check-in and check-out compare with a Schedule
+type Punctuality struct {
+	Status       string // Present or Late; "" without a check-in
+	MinutesLate  int    // from the start, once past the grace
+	MinutesEarly int    // before the end, once past the grace
+}
+
+// Classify derives the status of a student from when they checked in and
+// out. Arriving more than LateGrace after the start is Late, and the
+// minutes late count from the start itself; leaving more than
+// EarlyLeaveGrace before the end counts the minutes left early. Partial
+// minutes round up. Either time may be nil.
+func (s Schedule) Classify(checkIn, checkOut *time.Time) Punctuality {
+	var p Punctuality
+	if checkIn != nil {
+		p.Status = "Present"
+		if late := checkIn.Sub(s.Start); late > s.LateGrace {
+			p.Status = "Late"
+			p.MinutesLate = minutes(late)
+		}
+	}
+	if checkOut != nil && !s.End.IsZero() {
+		if early := s.End.Sub(*checkOut); early > s.EarlyLeaveGrace {
+			p.MinutesEarly = minutes(early)
+		}
+	}
+	return p
+}
+
+func minutes(d time.Duration) int {
+	return int((d + time.Minute - 1) / time.Minute)
+}
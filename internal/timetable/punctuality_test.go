@@ -0,0 +1,34 @@
+package timetable
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	s := Schedule{Start: start, End: start.Add(45 * time.Minute), LateGrace: 5 * time.Minute, EarlyLeaveGrace: 5 * time.Minute}
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name    string
+		in, out *time.Time
+		want    Punctuality
+	}{
+		{"no times", nil, nil, Punctuality{}},
+		{"early", at(-10 * time.Minute), nil, Punctuality{Status: "Present"}},
+		{"within grace", at(5 * time.Minute), nil, Punctuality{Status: "Present"}},
+		{"past grace", at(5*time.Minute + 30*time.Second), nil, Punctuality{Status: "Late", MinutesLate: 6}},
+		{"left within grace", at(0), at(40 * time.Minute), Punctuality{Status: "Present"}},
+		{"left early", at(0), at(30 * time.Minute), Punctuality{Status: "Present", MinutesEarly: 15}},
+		{"only left", nil, at(20 * time.Minute), Punctuality{MinutesEarly: 25}},
+		{"late and left early", at(12 * time.Minute), at(39 * time.Minute), Punctuality{Status: "Late", MinutesLate: 12, MinutesEarly: 6}},
+	}
+	for _, tt := range tests {
+		if got := s.Classify(tt.in, tt.out); got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package timetable holds the rules that turn period attendance into a
+// daily status and check-in times into punctuality
+package timetable
+
+import "github.com/tukesh1/student-api/internal/config"
+
+// RollUp derives the daily status from a student's period statuses, ordered
+// by period start time. scheduled is the number of periods the class has
+// that day; periods not yet marked do not count as missed, so the status
+// firms up as the day goes on. Excused periods are left out, and a day of
+// only excused periods is Excused. It returns "" when nothing was marked.
+func RollUp(rule config.Timetable, statuses []string, scheduled int) string {
+	if len(statuses) == 0 {
+		return ""
+	}
+	var counted []string
+	absent := 0
+	for _, s := range statuses {
+		switch s {
+		case "Excused":
+			continue
+		case "Absent":
+			absent++
+		}
+		counted = append(counted, s)
+	}
+	if len(counted) == 0 {
+		return "Excused"
+	}
+
+	total := max(len(counted), scheduled-(len(statuses)-len(counted)))
+	switch {
+	case float64(absent)/float64(total) > rule.AbsentRatio:
+		return "Absent"
+	case counted[0] == "Late":
+		return "Late"
+	case counted[0] == "Absent" && rule.LateIfFirstMissed:
+		return "Late"
+	}
+	return "Present"
+}
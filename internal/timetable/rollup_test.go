@@ -0,0 +1,37 @@
+package timetable
+
+import (
+	"testing"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+func TestRollUp(t *testing.T) {
+	rule := config.Timetable{AbsentRatio: 0.5, LateIfFirstMissed: true}
+	tests := []struct {
+		name      string
+		statuses  []string
+		scheduled int
+		want      string
+	}{
+		{"nothing marked", nil, 6, ""},
+		{"all present", []string{"Present", "Present"}, 2, "Present"},
+		{"half missed", []string{"Absent", "Present", "Absent", "Present"}, 4, "Late"},
+		{"most missed", []string{"Absent", "Absent", "Absent", "Present"}, 4, "Absent"},
+		{"unmarked periods are not missed", []string{"Absent", "Absent"}, 6, "Late"},
+		{"late to first period", []string{"Late", "Present"}, 2, "Late"},
+		{"all excused", []string{"Excused", "Excused"}, 4, "Excused"},
+		{"excused periods are not missed", []string{"Excused", "Excused", "Present", "Absent"}, 4, "Present"},
+		{"first counted period decides lateness", []string{"Excused", "Late", "Present"}, 3, "Late"},
+	}
+	for _, tt := range tests {
+		if got := RollUp(rule, tt.statuses, tt.scheduled); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	rule.LateIfFirstMissed = false
+	if got := RollUp(rule, []string{"Absent", "Present", "Present"}, 3); got != "Present" {
+		t.Errorf("expected Present when a missed first period is not late, got %q", got)
+	}
+}
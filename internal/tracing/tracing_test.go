@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/tukesh1/student-api/internal/config"
+)
+
+func TestSetupStdoutFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.json")
+	cfg := &config.Config{
+		Env: "test",
+		Tracing: config.Tracing{
+			Exporter:    ExporterStdout,
+			File:        file,
+			ServiceName: "student-api",
+			SampleRatio: 1,
+		},
+	}
+
+	shutdown, err := Setup(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Name":"test-span"`) {
+		t.Errorf("Expected exported span in %s, got %s", file, data)
+	}
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	cfg := &config.Config{Tracing: config.Tracing{Exporter: "zipkin"}}
+	if _, err := Setup(context.Background(), cfg); err == nil {
+		t.Error("Expected error for unknown exporter")
+	}
+}
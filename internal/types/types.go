@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 //struct of student making
 type Student struct {
@@ -8,27 +11,535 @@ type Student struct {
 	Name    string `json:"name" validate:"required"`
 	Email   string `json:"email" validate:"required"`
 	Age     int    `json:"age" validate:"required"`
-	ClassID int64  `json:"class_id"`
+	ClassID int64  `json:"class_id"` // homeroom; subject sections are enrollments
 	RollNo  string `json:"roll_no"`
+
+	// set with the photo; it changes with every new photo
+	PhotoURL string `json:"photo_url,omitempty"`
 }
 
+// Class is taught by the teacher TeacherID. TeacherName is read from the
+// teacher; on writes it is still accepted in place of TeacherID and resolved
+// to a teacher by name. Capacity caps the active enrollments; 0 is unlimited.
 type Class struct {
 	Id          int64  `json:"id"`
 	Name        string `json:"name" validate:"required"`
 	Grade       string `json:"grade" validate:"required"`
 	Section     string `json:"section" validate:"required"`
-	TeacherName string `json:"teacher_name" validate:"required"`
+	TeacherID   int64  `json:"teacher_id" validate:"required_without=TeacherName"`
+	TeacherName string `json:"teacher_name" validate:"required_without=TeacherID"`
+	Capacity    int    `json:"capacity" validate:"gte=0"`
+
+	// grace in minutes in place of the school's, set with SetClassGrace
+	LateGraceMinutes       *int `json:"late_grace_minutes,omitempty"`
+	EarlyLeaveGraceMinutes *int `json:"early_leave_grace_minutes,omitempty"`
+}
+
+type Teacher struct {
+	Id         int64  `json:"id"`
+	Name       string `json:"name" validate:"required"`
+	Email      string `json:"email" validate:"omitempty,email"`
+	Phone      string `json:"phone"`
+	EmployeeID string `json:"employee_id"`
 }
 
+// AttendanceRecord is a daily record, or a period record when PeriodID is
+// set. Period records roll up into the student's daily record for the date.
 type AttendanceRecord struct {
 	Id        int64     `json:"id"`
 	StudentID int64     `json:"student_id" validate:"required"`
 	ClassID   int64     `json:"class_id" validate:"required"`
+	PeriodID  int64     `json:"period_id,omitempty"`
 	Date      time.Time `json:"date" validate:"required"`
-	Status    string    `json:"status" validate:"required"` // Present, Absent, Late
+	Status    string    `json:"status" validate:"required"` // Present, Absent, Late, Excused
 	Remarks   string    `json:"remarks"`
+
+	CheckInAt    *time.Time `json:"check_in_at,omitempty"`
+	CheckOutAt   *time.Time `json:"check_out_at,omitempty"`
+	MinutesLate  int        `json:"minutes_late,omitempty"`
+	MinutesEarly int        `json:"minutes_early,omitempty"` // left this long before the end
+}
+
+// AttendanceTimes are when a student checked in and out. A record given
+// a check-in without a status is Present or Late by the schedule and the
+// grace of its class; see timetable.Schedule.
+type AttendanceTimes struct {
+	CheckInAt  *time.Time
+	CheckOutAt *time.Time
+}
+
+// StudentFilter narrows student lists and exports; zero values match all
+type StudentFilter struct {
+	ClassID int64
+}
+
+// ClassFilter narrows class lists and exports; zero values match all
+type ClassFilter struct {
+	Grade     string
+	Section   string
+	TeacherID int64
+}
+
+// Attendance record scopes
+const (
+	ScopeDaily  = "daily" // the default
+	ScopePeriod = "period"
+	ScopeAll    = "all"
+)
+
+// AttendanceFilter narrows attendance lists and exports; zero values match
+// all. From and To are inclusive dates. Scope selects daily records (the
+// default), period records or both; a PeriodID implies the period scope.
+type AttendanceFilter struct {
+	StudentID int64
+	ClassID   int64
+	PeriodID  int64
+	From      time.Time
+	To        time.Time
+	Status    string
+	Scope     string
+}
+
+// ImportRowError lists the validation errors of one spreadsheet row.
+// Row is the 1-based row number in the file, the header being row 1.
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ImportReport is the result of a bulk import; on dry runs nothing is written
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	Imported  int              `json:"imported"`
+	Ids       []int64          `json:"ids,omitempty"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// AnalyticsQuery selects the attendance aggregates to compute. GroupBy is
+// one of class, grade, section, day, week, month or weekday.
+type AnalyticsQuery struct {
+	GroupBy string
+	From    time.Time
+	To      time.Time
+	ClassID int64
+	Grade   string
+}
+
+// AttendanceStats aggregates the attendance records of one group
+type AttendanceStats struct {
+	Key            string  `json:"key"`
+	Label          string  `json:"label"`
+	Records        int     `json:"records"`
+	Present        int     `json:"present"`
+	Absent         int     `json:"absent"`
+	Excused        int     `json:"excused"` // of the absences
+	Late           int     `json:"late"`
+	Students       int     `json:"students"`
+	Days           int     `json:"days"`            // instructional days in the group's period
+	AttendanceRate float64 `json:"attendance_rate"` // present or late over students x days less excused absences, in percent
+
+	MinutesLate      int `json:"minutes_late"`
+	EarlyDepartures  int `json:"early_departures"`
+	MinutesLeftEarly int `json:"minutes_left_early"`
+}
+
+// AcademicYear spans the terms of one school year; dates are inclusive
+type AcademicYear struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+type Term struct {
+	Id             int64     `json:"id"`
+	AcademicYearID int64     `json:"academic_year_id"`
+	Name           string    `json:"name"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+// Closure kinds
+const (
+	ClosureHoliday = "holiday"
+	ClosureBreak   = "break"   // e.g. exam or mid-term breaks
+	ClosureAdHoc   = "closure" // unplanned, e.g. weather
+)
+
+// Closure is a school-wide range of days without instruction
+type Closure struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+// Period is a slot of the school day; times are HH:MM
+type Period struct {
+	Id        int64  `json:"id"`
+	Name      string `json:"name" validate:"required"`
+	StartTime string `json:"start_time" validate:"required,datetime=15:04"`
+	EndTime   string `json:"end_time" validate:"required,datetime=15:04"`
+}
+
+type Subject struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name" validate:"required"`
+	Code string `json:"code"`
+}
+
+// TimetableEntry schedules a subject in one period of a class's week.
+// Weekday counts from Sunday (0) like time.Weekday; the names and times are
+// filled in when reading.
+type TimetableEntry struct {
+	Id          int64  `json:"id"`
+	ClassID     int64  `json:"class_id"`
+	Weekday     int    `json:"weekday" validate:"min=0,max=6"`
+	PeriodID    int64  `json:"period_id" validate:"required"`
+	SubjectID   int64  `json:"subject_id" validate:"required"`
+	PeriodName  string `json:"period_name,omitempty"`
+	SubjectName string `json:"subject_name,omitempty"`
+	StartTime   string `json:"start_time,omitempty"`
+	EndTime     string `json:"end_time,omitempty"`
+}
+
+// CalendarDay tells whether a date is an instructional day. A day is
+// instructional unless it falls on a weekend, inside a closure or, once any
+// terms are defined, outside every term.
+type CalendarDay struct {
+	Date          time.Time `json:"date"`
+	Instructional bool      `json:"instructional"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// Alert statuses; an alert is active until it is resolved
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// Alert flags a student who breaks one of the absenteeism rules. A student
+// has at most one active alert per rule; re-evaluation refreshes it.
+type Alert struct {
+	Id        int64     `json:"id"`
+	StudentID int64     `json:"student_id"`
+	Rule      string    `json:"rule"` // low_attendance, consecutive_absences or repeated_lateness
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AlertFilter narrows alert lists; zero values match all
+type AlertFilter struct {
+	StudentID int64
+	Status    string
+	Rule      string
 }
 
+// Guardian is a parent or emergency contact. A guardian with a password can
+// log in to see the students they are linked to.
+type Guardian struct {
+	Id               int64  `json:"id"`
+	Name             string `json:"name" validate:"required"`
+	Email            string `json:"email" validate:"omitempty,email"`
+	Phone            string `json:"phone" validate:"required_without=Email"`
+	AltPhone         string `json:"alt_phone"`
+	PreferredChannel string `json:"preferred_channel" validate:"omitempty,oneof=phone sms email"` // defaults to phone
+}
+
+// StudentGuardian is a guardian as linked to one student. A student has at
+// most one primary contact.
+type StudentGuardian struct {
+	Guardian
+	StudentID    int64  `json:"student_id"`
+	Relationship string `json:"relationship"` // mother, father, parent, guardian, grandparent, sibling or other
+	IsPrimary    bool   `json:"is_primary"`
+}
+
+// Notification statuses. A queued notification is sent at the next
+// dispatch inside the send window; cancelled ones were withdrawn before
+// sending because the absence was corrected.
+const (
+	NotificationQueued    = "queued"
+	NotificationSent      = "sent"
+	NotificationFailed    = "failed"
+	NotificationSkipped   = "skipped"
+	NotificationCancelled = "cancelled"
+)
+
+// Notification tells a student's primary guardian about an absence. There
+// is at most one per student per day.
+type Notification struct {
+	Id         int64      `json:"id"`
+	StudentID  int64      `json:"student_id"`
+	Date       time.Time  `json:"date"`
+	Status     string     `json:"status"`
+	GuardianID int64      `json:"guardian_id,omitempty"`
+	Channel    string     `json:"channel,omitempty"` // email or sms
+	Recipient  string     `json:"recipient,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	Body       string     `json:"body,omitempty"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
+
+// NotificationFilter narrows notification lists; zero values match all
+type NotificationFilter struct {
+	StudentID int64
+	Status    string
+	From      time.Time
+	To        time.Time
+}
+
+// NotificationTemplate is the text/template source of the message sent on
+// one channel. SMS messages have no subject.
+type NotificationTemplate struct {
+	Channel string `json:"channel"`
+	Subject string `json:"subject"`
+	Body    string `json:"body" validate:"required"`
+}
+
+// Event is a change to a student, class or attendance record, written to
+// the outbox in the same transaction as the change. Seq orders events and is
+// the cursor of the change feed. Data is the row after the change, or as it
+// was before a delete.
+type Event struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Resource   string          `json:"resource"`
+	ResourceID int64           `json:"resource_id"`
+	Data       json.RawMessage `json:"data"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Operations of a sync mutation
+const (
+	SyncUpsert = "upsert"
+	SyncDelete = "delete"
+)
+
+// Outcomes of a sync mutation
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+// SyncMutation is one attendance change a client made, possibly while
+// offline. ClientID is a UUID the client generates so uploads can be
+// retried; ModifiedAt is when the change was made on the device. The
+// record is the student's daily record, or their period record when
+// PeriodID is set. An upsert replaces the record's check-in and check-out
+// times with CheckInAt and CheckOutAt.
+type SyncMutation struct {
+	ClientID   string
+	Op         string
+	StudentID  int64
+	ClassID    int64
+	PeriodID   int64
+	Date       time.Time
+	Status     string
+	Remarks    string
+	CheckInAt  *time.Time
+	CheckOutAt *time.Time
+	ModifiedAt time.Time
+}
+
+// SyncResult is what became of a sync mutation. On a conflict Server is
+// the record that was kept, or Deleted says the server deleted the record
+// after the change was made. Replayed marks the stored result of a
+// mutation that was uploaded before.
+type SyncResult struct {
+	ClientID string            `json:"client_id"`
+	Outcome  string            `json:"outcome"`
+	RecordID int64             `json:"record_id,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Server   *AttendanceRecord `json:"server,omitempty"`
+	Deleted  bool              `json:"deleted,omitempty"`
+	Replayed bool              `json:"replayed,omitempty"`
+}
+
+// CheckIn is a student checking in at a kiosk for a class session, the
+// period's when PeriodID is set. Status is what the check-in recorded;
+// OverrideStatus is set when a teacher changed it.
+type CheckIn struct {
+	Id             int64      `json:"id"`
+	StudentID      int64      `json:"student_id"`
+	ClassID        int64      `json:"class_id"`
+	PeriodID       int64      `json:"period_id,omitempty"`
+	Date           time.Time  `json:"date"`
+	SessionID      string     `json:"session_id"`
+	CheckedInAt    time.Time  `json:"checked_in_at"`
+	Status         string     `json:"status"`
+	AttendanceID   int64      `json:"attendance_id"`
+	OverrideStatus string     `json:"override_status,omitempty"`
+	OverriddenAt   *time.Time `json:"overridden_at,omitempty"`
+}
+
+// CheckInFilter narrows check-in lists; zero values match all
+type CheckInFilter struct {
+	StudentID int64
+	ClassID   int64
+	SessionID string
+	Date      time.Time
+}
+
+// Statuses of a leave request
+const (
+	LeavePending  = "pending"
+	LeaveApproved = "approved"
+	LeaveRejected = "rejected"
+)
+
+// LeaveRequest asks for a student to be excused from StartDate to EndDate,
+// inclusive. SubmittedBy is guardian, student or staff; GuardianID is set
+// for requests made through the guardian portal.
+type LeaveRequest struct {
+	Id            int64      `json:"id"`
+	StudentID     int64      `json:"student_id"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       time.Time  `json:"end_date"`
+	Reason        string     `json:"reason"`
+	AttachmentURL string     `json:"attachment_url,omitempty"`
+	SubmittedBy   string     `json:"submitted_by"`
+	GuardianID    int64      `json:"guardian_id,omitempty"`
+	Status        string     `json:"status"`
+	ReviewedBy    string     `json:"reviewed_by,omitempty"`
+	ReviewNote    string     `json:"review_note,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// LeaveFilter narrows leave request lists; zero values match all. From and
+// To select requests overlapping the inclusive range.
+type LeaveFilter struct {
+	StudentID  int64
+	GuardianID int64
+	Status     string
+	From       time.Time
+	To         time.Time
+}
+
+// Owners an attachment can belong to
+const (
+	OwnerStudent      = "student"
+	OwnerLeaveRequest = "leave_request"
+	OwnerAttendance   = "attendance"
+)
+
+// Attachment is a file kept for a student, a leave request or an
+// attendance record, such as a medical certificate or a signed register.
+// StudentID is the student the owner belongs to. The content lives in the
+// blob store under BlobKey; SHA256 is the hex digest of it.
+type Attachment struct {
+	Id          int64     `json:"id"`
+	OwnerType   string    `json:"owner_type"`
+	OwnerID     int64     `json:"owner_id"`
+	StudentID   int64     `json:"student_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	BlobKey     string    `json:"-"`
+	UploadedBy  string    `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Webhook is a subscription to API events. Events lists event types such as
+// student.created, or "*" for all. The secret signs every payload and is
+// only shown when the webhook is created.
+type Webhook struct {
+	Id        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Webhook delivery statuses; dead deliveries ran out of attempts and wait
+// in the dead-letter list for a manual redelivery
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is one event on its way to one webhook
+type WebhookDelivery struct {
+	Id             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	URL            string     `json:"-"`
+	Secret         string     `json:"-"`
+}
+
+// WebhookDeliveryFilter narrows delivery lists; zero values match all
+type WebhookDeliveryFilter struct {
+	WebhookID int64
+	Status    string
+}
+
+// Enrollment statuses; only active enrollments take a place in the class
+const (
+	EnrollmentActive    = "active"
+	EnrollmentDropped   = "dropped"
+	EnrollmentCompleted = "completed"
+)
+
+// Enrollment puts a student in a subject section, a class other than (or as
+// well as) their homeroom. EndDate is nil while the enrollment is open-ended.
+type Enrollment struct {
+	Id          int64      `json:"id"`
+	StudentID   int64      `json:"student_id"`
+	ClassID     int64      `json:"class_id"`
+	SubjectID   int64      `json:"subject_id"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Status      string     `json:"status"`
+	ClassName   string     `json:"class_name,omitempty"`
+	SubjectName string     `json:"subject_name,omitempty"`
+}
+
+// EnrollmentFilter narrows enrollment lists; zero values match all
+type EnrollmentFilter struct {
+	StudentID int64
+	ClassID   int64
+	SubjectID int64
+	Status    string
+}
+
+// Stats holds the headline counters exported as metrics
+type Stats struct {
+	TotalStudents int64 `json:"total_students"`
+	TotalClasses  int64 `json:"total_classes"`
+	MarkedToday   int64 `json:"marked_today"` // students with an attendance record for the day
+}
+
+// AttendanceReport summarises one student's attendance. TotalDays counts
+// the instructional days in the range, so unmarked days lower the rate.
+// AbsentDays counts every absence, split into ExcusedDays and
+// UnexcusedDays; excused days do not count against the rate.
 type AttendanceReport struct {
 	StudentID      int64   `json:"student_id"`
 	StudentName    string  `json:"student_name"`
@@ -36,6 +547,12 @@ type AttendanceReport struct {
 	TotalDays      int     `json:"total_days"`
 	PresentDays    int     `json:"present_days"`
 	AbsentDays     int     `json:"absent_days"`
+	ExcusedDays    int     `json:"excused_days"`
+	UnexcusedDays  int     `json:"unexcused_days"`
 	LateDays       int     `json:"late_days"`
 	AttendanceRate float64 `json:"attendance_rate"`
+
+	MinutesLate      int `json:"minutes_late"`       // total over the late days
+	EarlyDepartures  int `json:"early_departures"`   // days the student left early
+	MinutesLeftEarly int `json:"minutes_left_early"` // total over those days
 }
@@ -0,0 +1,46 @@
+package dates
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Layout is the calendar date format used in the API and the database
+const Layout = "2006-01-02"
+
+// Parse accepts a YYYY-MM-DD date or a full RFC 3339 timestamp
+func Parse(value string) (time.Time, error) {
+	if t, err := time.Parse(Layout, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// QueryRange reads the optional from and to query parameters. A date
+// parameter sets both ends to the same day.
+func QueryRange(r *http.Request) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	if v := q.Get("date"); v != "" {
+		day, err := Parse(v)
+		return day, day, err
+	}
+	if v := q.Get("from"); v != "" {
+		if from, err = Parse(v); err != nil {
+			return from, to, err
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = Parse(v); err != nil {
+			return from, to, err
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, fmt.Errorf("to must not be before from")
+	}
+	return from, to, nil
+}
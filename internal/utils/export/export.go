@@ -0,0 +1,232 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/utils/response"
+	"github.com/tukesh1/student-api/internal/utils/spreadsheet"
+)
+
+type Format string
+
+const (
+	JSON   Format = "json"
+	CSV    Format = "csv"
+	XLSX   Format = "xlsx"
+	NDJSON Format = "ndjson"
+)
+
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// flushEvery controls how often streamed csv and ndjson rows are pushed to the client
+const flushEvery = 100
+
+// Negotiate picks the response format from the ?format= override or the
+// Accept header, defaulting to plain JSON
+func Negotiate(r *http.Request) Format {
+	switch Format(strings.ToLower(r.URL.Query().Get("format"))) {
+	case CSV:
+		return CSV
+	case XLSX:
+		return XLSX
+	case NDJSON:
+		return NDJSON
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		switch mediaType {
+		case spreadsheet.ContentTypeCSV:
+			return CSV
+		case spreadsheet.ContentTypeXLSX:
+			return XLSX
+		case ContentTypeNDJSON:
+			return NDJSON
+		case "application/json":
+			return JSON
+		}
+	}
+	return JSON
+}
+
+// Table describes how items of T are flattened into spreadsheet columns.
+// NDJSON exports encode the items themselves.
+type Table[T any] struct {
+	Columns []string
+	Row     func(T) []any
+}
+
+// Stream writes every item produced by source to w in the given format.
+// Rows are written as they arrive (xlsx is assembled in a temporary file
+// and sent once complete), so callers should stream straight from storage.
+func Stream[T any](w http.ResponseWriter, r *http.Request, format Format, name string, table Table[T], source func(fn func(T) error) error) {
+	ew := &writer[T]{w: w, format: format, name: name, table: table}
+	err := source(ew.write)
+	if err == nil {
+		err = ew.close()
+	}
+	if err == nil {
+		return
+	}
+
+	logger.FromRequest(r).Error("export failed", slog.String("format", string(format)), slog.String("error", err.Error()))
+	if !ew.started {
+		response.WriteJson(w, http.StatusInternalServerError, response.GeneralError(err))
+	}
+	// once rows have been sent the status line is gone; the client sees a truncated file
+}
+
+type writer[T any] struct {
+	w      http.ResponseWriter
+	format Format
+	name   string
+	table  Table[T]
+
+	started bool
+	rows    int
+	csv     *csv.Writer
+	json    *json.Encoder
+	xlsx    *excelize.File
+	stream  *excelize.StreamWriter
+}
+
+func (ew *writer[T]) start() error {
+	if ew.started {
+		return nil
+	}
+	ew.started = true
+
+	filename := fmt.Sprintf("%s-%s.%s", ew.name, time.Now().Format("20060102"), ew.format)
+	h := ew.w.Header()
+	switch ew.format {
+	case CSV:
+		h.Set("Content-Type", spreadsheet.ContentTypeCSV+"; charset=utf-8")
+		h.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		ew.csv = csv.NewWriter(ew.w)
+		return ew.csv.Write(ew.table.Columns)
+	case XLSX:
+		h.Set("Content-Type", spreadsheet.ContentTypeXLSX)
+		h.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		ew.xlsx = excelize.NewFile()
+		stream, err := ew.xlsx.NewStreamWriter("Sheet1")
+		if err != nil {
+			return err
+		}
+		ew.stream = stream
+		return ew.stream.SetRow("A1", toAny(ew.table.Columns))
+	case NDJSON:
+		h.Set("Content-Type", ContentTypeNDJSON)
+		ew.json = json.NewEncoder(ew.w)
+		return nil
+	}
+	return fmt.Errorf("unsupported export format %s", ew.format)
+}
+
+func (ew *writer[T]) write(item T) error {
+	if err := ew.start(); err != nil {
+		return err
+	}
+	ew.rows++
+
+	var err error
+	switch ew.format {
+	case CSV:
+		err = ew.csv.Write(toStrings(ew.table.Row(item)))
+	case XLSX:
+		cell, cellErr := excelize.CoordinatesToCellName(1, ew.rows+1)
+		if cellErr != nil {
+			return cellErr
+		}
+		err = ew.stream.SetRow(cell, toCells(ew.table.Row(item)))
+	case NDJSON:
+		err = ew.json.Encode(item)
+	}
+	if err != nil {
+		return err
+	}
+	if ew.rows%flushEvery == 0 {
+		ew.flush()
+	}
+	return nil
+}
+
+func (ew *writer[T]) close() error {
+	if err := ew.start(); err != nil {
+		return err
+	}
+	switch ew.format {
+	case CSV:
+		ew.csv.Flush()
+		return ew.csv.Error()
+	case XLSX:
+		defer ew.xlsx.Close()
+		if err := ew.stream.Flush(); err != nil {
+			return err
+		}
+		_, err := ew.xlsx.WriteTo(ew.w)
+		return err
+	}
+	return nil
+}
+
+func (ew *writer[T]) flush() {
+	if ew.csv != nil {
+		ew.csv.Flush()
+	}
+	if f, ok := ew.w.(http.Flusher); ok && ew.format != XLSX {
+		f.Flush()
+	}
+}
+
+func toStrings(values []any) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case time.Time:
+			out[i] = v.Format("2006-01-02")
+		case string:
+			out[i] = escapeFormula(v)
+		case nil:
+		default:
+			out[i] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
+
+// toCells writes dates as plain text so they read the same as in csv.
+// Strings go in as text cells, which are never run as formulas, so they
+// are written as they are.
+func toCells(values []any) []any {
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok {
+			values[i] = t.Format("2006-01-02")
+		}
+	}
+	return values
+}
+
+// escapeFormula prefixes text a spreadsheet would run as a formula with a
+// quote, so a student named "=HYPERLINK(...)" opens from csv as plain text
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
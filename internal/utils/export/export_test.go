@@ -0,0 +1,104 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		url, accept string
+		want        Format
+	}{
+		{"/api/students", "", JSON},
+		{"/api/students", "text/csv", CSV},
+		{"/api/students", "application/x-ndjson;q=0.9, application/json", NDJSON},
+		{"/api/students", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", XLSX},
+		{"/api/students?format=csv", "application/json", CSV},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.url, nil)
+		req.Header.Set("Accept", c.accept)
+		if got := Negotiate(req); got != c.want {
+			t.Errorf("Negotiate(%s, %q) = %s, want %s", c.url, c.accept, got, c.want)
+		}
+	}
+}
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+var table = Table[item]{
+	Columns: []string{"id", "name"},
+	Row:     func(i item) []any { return []any{i.ID, i.Name} },
+}
+
+func TestStreamCSVAndNDJSON(t *testing.T) {
+	source := func(fn func(item) error) error {
+		fn(item{1, "Ada"})
+		return fn(item{2, "Lovelace, A"})
+	}
+
+	rr := httptest.NewRecorder()
+	Stream(rr, httptest.NewRequest("GET", "/", nil), CSV, "items", table, source)
+	if want := "id,name\n1,Ada\n2,\"Lovelace, A\"\n"; rr.Body.String() != want {
+		t.Errorf("csv = %q, want %q", rr.Body.String(), want)
+	}
+
+	rr = httptest.NewRecorder()
+	Stream(rr, httptest.NewRequest("GET", "/", nil), NDJSON, "items", table, source)
+	if want := "{\"id\":1,\"name\":\"Ada\"}\n{\"id\":2,\"name\":\"Lovelace, A\"}\n"; rr.Body.String() != want {
+		t.Errorf("ndjson = %q, want %q", rr.Body.String(), want)
+	}
+	if rr.Header().Get("Content-Type") != ContentTypeNDJSON {
+		t.Errorf("unexpected content type %q", rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestStreamErrorBeforeFirstRow(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Stream(rr, httptest.NewRequest("GET", "/", nil), CSV, "items", table, func(fn func(item) error) error {
+		return errors.New("db down")
+	})
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestStreamEscapesFormulas(t *testing.T) {
+	source := func(fn func(item) error) error {
+		fn(item{-1, "=HYPERLINK(\"http://evil\")"})
+		return fn(item{2, "@SUM(A1)"})
+	}
+
+	rr := httptest.NewRecorder()
+	Stream(rr, httptest.NewRequest("GET", "/", nil), CSV, "items", table, source)
+	if want := "id,name\n-1,\"'=HYPERLINK(\"\"http://evil\"\")\"\n2,'@SUM(A1)\n"; rr.Body.String() != want {
+		t.Errorf("csv = %q, want %q", rr.Body.String(), want)
+	}
+
+	rr = httptest.NewRecorder()
+	Stream(rr, httptest.NewRequest("GET", "/", nil), XLSX, "items", table, source)
+	f, err := excelize.OpenReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// xlsx keeps the text as it is in a text cell rather than a formula
+	if len(rows) != 3 || rows[1][0] != "-1" || rows[1][1] != "=HYPERLINK(\"http://evil\")" || rows[2][1] != "@SUM(A1)" {
+		t.Errorf("unexpected xlsx rows %q", rows)
+	}
+	if formula, err := f.GetCellFormula(f.GetSheetName(0), "B2"); err != nil || formula != "" {
+		t.Errorf("expected no formula in B2, got %q (%v)", formula, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Package ical reads the events of an iCalendar (RFC 5545) file, enough to
+// import school holidays from the calendars published by education boards.
+//
+// Only the dates of an event are kept. Times with a TZID, or with no zone,
+// give the date as written; UTC times ending in Z give the UTC date. VTIMEZONE
+// blocks are not read. Recurring events (RRULE or RDATE) are not supported
+// and are rejected rather than imported as their first occurrence.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT reduced to its summary and the inclusive dates it covers
+type Event struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Parse returns the events of the calendar in file order
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	var endSet, endExclusive bool
+	for n, line := range lines {
+		name, params, value, ok := property(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current, endSet, endExclusive = &Event{}, false, false
+		case name == "END" && value == "VEVENT" && current != nil:
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, current.Summary)
+			}
+			switch {
+			case !endSet:
+				current.End = current.Start
+			case endExclusive && current.End.After(current.Start):
+				current.End = current.End.AddDate(0, 0, -1)
+			}
+			if current.End.Before(current.Start) {
+				current.End = current.Start
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "RRULE" || name == "RDATE":
+			return nil, fmt.Errorf("line %d: recurring event %q is not supported, list each occurrence as its own event", n+1, current.Summary)
+		case name == "DTSTART" || name == "DTEND":
+			date, allDay, err := parseDate(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if name == "DTSTART" {
+				current.Start = date
+			} else {
+				// an all-day end, or a timed end at midnight, is exclusive
+				current.End, endSet, endExclusive = date, true, allDay || isMidnight(value)
+			}
+		}
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines, which start with a space or a tab
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// property splits NAME;PARAM=X:VALUE
+func property(line string) (name, params, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", "", false
+	}
+	name, params, _ = strings.Cut(head, ";")
+	return strings.ToUpper(name), strings.ToUpper(params), value, true
+}
+
+func parseDate(params, value string) (date time.Time, allDay bool, err error) {
+	value = strings.TrimSuffix(value, "Z")
+	if len(value) == 8 || (strings.Contains(params, "VALUE=DATE") && !strings.Contains(params, "VALUE=DATE-TIME")) {
+		date, err = time.Parse("20060102", value)
+		return date, true, err
+	}
+	t, err := time.Parse("20060102T150405", value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), false, nil
+}
+
+func isMidnight(value string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(value, "Z"), "T000000")
+}
+
+var unescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescape(s string) string {
+	return strings.TrimSpace(unescaper.Replace(s))
+}
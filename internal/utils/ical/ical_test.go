@@ -0,0 +1,75 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Winter\\, break\r\n" +
+	"DTSTART;VALUE=DATE:20241223\r\n" +
+	"DTEND;VALUE=DATE:20250102\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Sports\r\n" +
+	"  day\r\n" +
+	"DTSTART:20250314T090000Z\r\n" +
+	"DTEND:20250314T150000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Founders day\r\n" +
+	"DTSTART;VALUE=DATE:20250401\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Summary: "Winter, break", Start: day("2024-12-23"), End: day("2025-01-01")},
+		{Summary: "Sports day", Start: day("2025-03-14"), End: day("2025-03-14")},
+		{Summary: "Founders day", Start: day("2025-04-01"), End: day("2025-04-01")},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], events[i])
+		}
+	}
+}
+
+func TestParseMissingStart(t *testing.T) {
+	_, err := Parse(strings.NewReader("BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n"))
+	if err == nil {
+		t.Error("Expected an error for an event without DTSTART")
+	}
+}
+
+func TestParseTZID(t *testing.T) {
+	events, err := Parse(strings.NewReader("BEGIN:VEVENT\nSUMMARY:Late start\nDTSTART;TZID=Asia/Kolkata:20250314T000000\nDTEND;TZID=Asia/Kolkata:20250314T230000\nEND:VEVENT\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Start != day("2025-03-14") || events[0].End != day("2025-03-14") {
+		t.Errorf("expected the date as written in its time zone, got %+v", events)
+	}
+}
+
+func TestParseRecurring(t *testing.T) {
+	_, err := Parse(strings.NewReader("BEGIN:VEVENT\nSUMMARY:Assembly\nDTSTART;VALUE=DATE:20250303\nRRULE:FREQ=WEEKLY\nEND:VEVENT\n"))
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected recurring events to be rejected, got %v", err)
+	}
+}
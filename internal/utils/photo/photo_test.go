@@ -0,0 +1,146 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// exifJPEG encodes img as a JPEG carrying an EXIF orientation
+func exifJPEG(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	payload := append(append([]byte("Exif\x00\x00"), tiff...), append(entry, 0, 0, 0, 0)...)
+
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(payload)+2))
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(app1, payload...)...), data[2:]...)
+}
+
+// portrait is 20x40: red on top, blue below
+func portrait() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 20; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if y >= 20 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	data := exifJPEG(t, portrait(), 6)
+	if o := orientation(data); o != 6 {
+		t.Fatalf("orientation = %d, want 6", o)
+	}
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// turned clockwise: 40x20 with the red half on the right
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Fatalf("bounds = %v, want 40x20", b)
+	}
+	if r, _, bl, _ := img.At(35, 10).RGBA(); r>>8 < 200 || bl>>8 > 60 {
+		t.Errorf("right side is not red: %v", img.At(35, 10))
+	}
+
+	var out bytes.Buffer
+	if err := Encode(&out, img); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out.Bytes(), []byte("Exif")) || orientation(out.Bytes()) != 1 {
+		t.Error("EXIF survived re-encoding")
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i / 4) // pixel n has value n
+	}
+	// pixel values of the result, row by row
+	for o, want := range map[int][]byte{
+		1: {0, 1, 2, 3, 4, 5},
+		2: {2, 1, 0, 5, 4, 3},
+		3: {5, 4, 3, 2, 1, 0},
+		4: {3, 4, 5, 0, 1, 2},
+		5: {0, 3, 1, 4, 2, 5},
+		6: {3, 0, 4, 1, 5, 2},
+		7: {5, 2, 4, 1, 3, 0},
+		8: {2, 5, 1, 4, 0, 3},
+	} {
+		got := orient(src, o)
+		var values []byte
+		for i := 0; i < len(got.Pix); i += 4 {
+			values = append(values, got.Pix[i])
+		}
+		if !bytes.Equal(values, want) {
+			t.Errorf("orientation %d = %v, want %v", o, values, want)
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 300, 100))) // transparent
+	img, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("transparency not flattened onto white: %v", img.At(0, 0))
+	}
+	if b := Thumbnail(img, Small).Bounds(); b.Dx() != Small || b.Dy() != Small {
+		t.Errorf("thumbnail is %v, want %dx%d", b, Small, Small)
+	}
+	if b := Fit(img, 150).Bounds(); b.Dx() != 150 || b.Dy() != 50 {
+		t.Errorf("fit is %v, want 150x50", b)
+	}
+
+	if _, err := Decode([]byte("GIF89a")); err == nil {
+		t.Error("decoding a GIF succeeded")
+	}
+}
+
+func TestDecodeRefusesLargeImages(t *testing.T) {
+	// a PNG header is enough: the size is checked before any pixel is read
+	header := func(width, height uint32) []byte {
+		ihdr := make([]byte, 17)
+		copy(ihdr, "IHDR")
+		binary.BigEndian.PutUint32(ihdr[4:], width)
+		binary.BigEndian.PutUint32(ihdr[8:], height)
+		ihdr[12], ihdr[13] = 8, 6 // 8-bit RGBA
+		data := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0d"), ihdr...)
+		return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+	}
+	for _, size := range [][2]uint32{{4000, 4001}, {20000, 1000}, {65535, 65535}} {
+		if _, err := Decode(header(size[0], size[1])); !errors.Is(err, ErrUnsupported) || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("%dx%d: expected a too large error, got %v", size[0], size[1], err)
+		}
+	}
+	if _, err := Decode(header(4000, 4000)); err == nil || strings.Contains(err.Error(), "too large") {
+		t.Errorf("a 16 megapixel header should pass the size check, got %v", err)
+	}
+}
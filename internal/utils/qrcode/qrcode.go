@@ -0,0 +1,55 @@
+// Package qrcode draws QR codes as PNG or SVG images, with the four-module
+// quiet zone scanners need around them
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+
+	"rsc.io/qr"
+)
+
+// quietZone is the white border, in modules, around the code
+const quietZone = 4
+
+// PNG returns text as a QR code image with scale pixels per module
+func PNG(text string, scale int) ([]byte, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return nil, err
+	}
+	code.Scale = scale
+	return code.PNG(), nil
+}
+
+// SVG returns text as a QR code drawing with scale pixels per module. Each
+// run of dark modules in a row is one rectangle of the path, which keeps
+// the document small.
+func SVG(text string, scale int) ([]byte, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return nil, err
+	}
+	side := code.Size + 2*quietZone
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		side*scale, side*scale, side, side)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, side, side)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < code.Size && code.Black(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x+quietZone, y+quietZone, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"strconv"
+	"testing"
+
+	"rsc.io/qr"
+)
+
+func TestPNG(t *testing.T) {
+	data, err := PNG("S1.42.abc", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("not a PNG: %v", err)
+	}
+	code, _ := qr.Encode("S1.42.abc", qr.M)
+	if want := (code.Size + 2*quietZone) * 4; img.Bounds().Dx() != want || img.Bounds().Dy() != want {
+		t.Errorf("image is %v, want %dx%d", img.Bounds(), want, want)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	data, err := SVG("S1.42.abc", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Width string `xml:"width,attr"`
+		Path  struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("not XML: %v", err)
+	}
+	code, _ := qr.Encode("S1.42.abc", qr.M)
+	if want := (code.Size + 2*quietZone) * 4; doc.Width != strconv.Itoa(want) {
+		t.Errorf("width = %s, want %d", doc.Width, want)
+	}
+
+	// the runs of the path cover exactly the dark modules
+	dark := 0
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				dark++
+			}
+		}
+	}
+	covered := 0
+	for _, run := range bytes.Split([]byte(doc.Path.D), []byte("z")) {
+		var x, y, w, w2 int
+		if len(run) == 0 {
+			continue
+		}
+		if _, err := fmt.Sscanf(string(run), "M%d %dh%dv1h-%d", &x, &y, &w, &w2); err != nil {
+			t.Fatalf("bad run %q: %v", run, err)
+		}
+		if !code.Black(x-quietZone, y-quietZone) || w != w2 {
+			t.Errorf("run %q does not start on a dark module", run)
+		}
+		covered += w
+	}
+	if covered != dark {
+		t.Errorf("path covers %d modules, want %d", covered, dark)
+	}
+}
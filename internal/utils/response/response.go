@@ -2,7 +2,9 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -32,6 +34,14 @@ func GeneralError(err error) Response {
 }
 
 func ValidationError(errs validator.ValidationErrors) Response {
+	return  Response{
+		 Status: StatusError,
+		 Error: strings.Join(ValidationMessages(errs),","),
+	}
+}
+
+// ValidationMessages returns one readable message per failed field
+func ValidationMessages(errs validator.ValidationErrors) []string {
 	var errMsg [] string
 
 	for _,err := range errs{
@@ -42,8 +52,34 @@ func ValidationError(errs validator.ValidationErrors) Response {
 			errMsg = append(errMsg, fmt.Sprintf("field %s is invalid ", err.Field()))
 		}
 	}
-	return  Response{
-		 Status: StatusError,
-		 Error: strings.Join(errMsg,","),
+	return errMsg
+}
+
+// Decode reads a JSON body into v and validates it when it is a struct,
+// writing the error response itself: 400 for an empty, malformed or invalid
+// body and 413 when the body is over the route's size cap
+func Decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		WriteJson(w, http.StatusBadRequest, GeneralError(fmt.Errorf("empty body")))
+		return false
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		WriteJson(w, http.StatusRequestEntityTooLarge, GeneralError(err))
+		return false
+	}
+	if err != nil {
+		WriteJson(w, http.StatusBadRequest, GeneralError(err))
+		return false
 	}
-}
\ No newline at end of file
+
+	// validating request
+	var invalid *validator.InvalidValidationError
+	if err := validator.New().Struct(v); err != nil && !errors.As(err, &invalid) {
+		validateErrs := err.(validator.ValidationErrors)
+		WriteJson(w, http.StatusBadRequest, ValidationError(validateErrs))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+const (
+	ContentTypeCSV  = "text/csv"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// xlsx files are zip archives
+var zipMagic = []byte("PK\x03\x04")
+
+// DetectFormat picks the format from the file name or content type, falling
+// back to sniffing the first bytes
+func DetectFormat(filename, contentType string, head []byte) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		return XLSX
+	case ".csv":
+		return CSV
+	}
+	if strings.HasPrefix(contentType, ContentTypeXLSX) {
+		return XLSX
+	}
+	if bytes.HasPrefix(head, zipMagic) {
+		return XLSX
+	}
+	return CSV
+}
+
+// ReadAll reads every row of a CSV file or of the first sheet of an XLSX
+// workbook, with cells trimmed. maxUnzipped caps how far an XLSX workbook
+// may expand when unzipped, so a small upload cannot inflate into gigabytes.
+func ReadAll(r io.Reader, format Format, maxUnzipped int64) ([][]string, error) {
+	var rows [][]string
+	switch format {
+	case XLSX:
+		f, err := excelize.OpenReader(r, excelize.Options{
+			UnzipSizeLimit:    maxUnzipped,
+			UnzipXMLSizeLimit: maxUnzipped,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx file: %w", err)
+		}
+		defer f.Close()
+		rows, err = f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		reader := csv.NewReader(skipBOM(r))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid csv file: %w", err)
+			}
+			// the csv reader skips empty lines; keep them as empty rows so
+			// row numbers match the file like they do for xlsx
+			line, _ := reader.FieldPos(0)
+			for len(rows) < line-1 {
+				rows = append(rows, nil)
+			}
+			rows = append(rows, record)
+		}
+	}
+
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+	return rows, nil
+}
+
+// skipBOM drops the UTF-8 byte order mark Excel adds to CSV exports
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+	return br
+}
@@ -0,0 +1,72 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadAllCSVKeepsLineNumbers(t *testing.T) {
+	data := "\xEF\xBB\xBFname,age\n Ada ,12\n\nCy,13\n"
+	rows, err := ReadAll(bytes.NewReader([]byte(data)), CSV, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected 4 rows, got %d: %q", len(rows), rows)
+	}
+	if rows[0][0] != "name" || rows[1][0] != "Ada" || rows[2] != nil || rows[3][0] != "Cy" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+}
+
+func TestReadAllXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetSheetRow("Sheet1", "A1", &[]any{"name", "age"})
+	f.SetSheetRow("Sheet1", "A2", &[]any{"Ada", 12})
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if format := DetectFormat("", "application/octet-stream", buf.Bytes()); format != XLSX {
+		t.Fatalf("Expected xlsx to be sniffed, got %s", format)
+	}
+	rows, err := ReadAll(&buf, XLSX, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][0] != "Ada" || rows[1][1] != "12" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+}
+
+func TestReadAllXLSXUnzipLimit(t *testing.T) {
+	f := excelize.NewFile()
+	for i := 1; i <= 1000; i++ {
+		f.SetSheetRow("Sheet1", fmt.Sprintf("A%d", i), &[]any{strings.Repeat("x", 100)})
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadAll(bytes.NewReader(buf.Bytes()), XLSX, int64(buf.Len())); err == nil {
+		t.Error("Expected a workbook larger than the unzip limit to be rejected")
+	}
+	if _, err := ReadAll(bytes.NewReader(buf.Bytes()), XLSX, 1<<20); err != nil {
+		t.Errorf("Expected the workbook to be read under a larger limit, got %v", err)
+	}
+}
+
+func TestReadAllMalformed(t *testing.T) {
+	if _, err := ReadAll(strings.NewReader("name,age\n\"Ada,12\n"), CSV, 1<<20); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+	if _, err := ReadAll(strings.NewReader("PK\x03\x04 not really a zip"), XLSX, 1<<20); err == nil {
+		t.Error("Expected an error for a corrupt workbook")
+	}
+}
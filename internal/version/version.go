@@ -0,0 +1,10 @@
+package version
+
+// Build information, injected at build time with
+//
+//	go build -ldflags "-X github.com/tukesh1/student-api/internal/version.Version=1.2.0 \
+//	  -X github.com/tukesh1/student-api/internal/version.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errForbiddenAddr is returned when a webhook URL resolves to an address
+// inside the network, so webhooks cannot be used to reach internal services
+var errForbiddenAddr = errors.New("webhook address is not public")
+
+// newClient returns the client deliveries are posted with. Redirects are
+// not followed, and unless allowPrivate is set the dialer refuses loopback,
+// private, link-local and other non-public addresses. The check runs on the
+// address actually dialed, after DNS resolution, so a hostname cannot point
+// somewhere else between validation and delivery.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%s: %w", addrPort.Addr(), errForbiddenAddr)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the webhook and bypass the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// a 3xx is reported as the response, a failed delivery
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddr reports whether addr is a globally routable unicast address
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsGlobalUnicast(),
+		addr.IsPrivate(),
+		addr.IsLoopback(),
+		addr.IsLinkLocalUnicast(),
+		sharedAddressSpace.Contains(addr):
+		return false
+	}
+	return true
+}
+
+// sharedAddressSpace is carrier-grade NAT space (RFC 6598), not covered by
+// netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
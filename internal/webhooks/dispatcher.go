@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Dispatcher posts queued deliveries to their webhooks
+type Dispatcher struct {
+	storage storage.Storage
+	cfg     config.Webhooks
+	client  *http.Client
+	now     func() time.Time
+	wake    chan struct{}
+}
+
+func NewDispatcher(storage storage.Storage, cfg config.Webhooks) *Dispatcher {
+	return &Dispatcher{
+		storage: storage,
+		cfg:     cfg,
+		client:  newClient(cfg.Timeout, cfg.AllowPrivate),
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Run sends due deliveries every interval, and as soon as an event is
+// queued or redelivered, until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		if err := d.Flush(ctx); err != nil {
+			slog.Error("error delivering webhooks", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Publish queues an outbox event for the webhooks subscribed to it. It is
+// safe to publish an event twice; it is only queued once per webhook.
+func (d *Dispatcher) Publish(ctx context.Context, event types.Event) error {
+	return d.enqueue(ctx, Event{Seq: event.Seq, ID: event.ID, Type: event.Type, CreatedAt: event.CreatedAt, Data: event.Data})
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	queued, err := d.storage.EnqueueWebhookEvent(ctx, event.ID, event.Type, string(payload))
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		d.Wake()
+	}
+	return nil
+}
+
+// Wake has Run look for due deliveries now instead of at the next tick
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Flush sends one batch of due deliveries. The batch is posted
+// concurrently, so one slow endpoint holds the others up by at most the
+// timeout, and the outcomes are written back one by one.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	due, err := d.storage.GetDueWebhookDeliveries(ctx, d.now(), d.cfg.BatchSize)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := range due {
+		wg.Add(1)
+		go func(delivery *types.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(&due[i])
+	}
+	wg.Wait()
+
+	for _, delivery := range due {
+		if delivery.Status == types.DeliveryDead {
+			slog.Warn("webhook delivery dead-lettered",
+				slog.Int64("deliveryId", delivery.Id),
+				slog.Int64("webhookId", delivery.WebhookID),
+				slog.String("event", delivery.EventType),
+				slog.String("error", delivery.LastError))
+		}
+		if err := d.storage.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("webhook delivery %d: %w", delivery.Id, err)
+		}
+	}
+	return nil
+}
+
+// deliver posts one delivery and records the outcome on it: delivered,
+// scheduled for a retry, or dead once it has used up its attempts
+func (d *Dispatcher) deliver(ctx context.Context, delivery *types.WebhookDelivery) {
+	delivery.Attempts++
+	status, err := d.post(ctx, delivery)
+	delivery.ResponseStatus = status
+	now := d.now()
+	if err == nil {
+		delivery.Status, delivery.LastError, delivery.DeliveredAt = types.DeliveryDelivered, "", &now
+		return
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status = types.DeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(Backoff(d.cfg.BaseBackoff, d.cfg.MaxBackoff, delivery.Attempts))
+}
+
+// post sends the payload and returns the response status; anything
+// outside 2xx is an error
+func (d *Dispatcher) post(ctx context.Context, delivery *types.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "student-api-webhooks")
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(delivery.WebhookID, 10))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Event-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the wait before the retry that follows the given number
+// of failed attempts: base, then doubling each time, capped at max
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+	return min(wait, max)
+}
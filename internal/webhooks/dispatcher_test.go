@@ -0,0 +1,127 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/config"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// mockStorage serves a fixed batch of due deliveries and records what is
+// written back. Other methods fall through to the nil embedded interface.
+type mockStorage struct {
+	storage.Storage
+	due     []types.WebhookDelivery
+	updated map[int64]types.WebhookDelivery
+}
+
+func (m *mockStorage) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]types.WebhookDelivery, error) {
+	return m.due, nil
+}
+
+func (m *mockStorage) UpdateWebhookDelivery(ctx context.Context, d types.WebhookDelivery) error {
+	m.updated[d.Id] = d
+	return nil
+}
+
+func newTestDispatcher(m *mockStorage, now time.Time) *Dispatcher {
+	d := NewDispatcher(m, config.Webhooks{
+		BatchSize:   10,
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  time.Hour,
+		// the test receivers listen on loopback
+		AllowPrivate: true,
+	})
+	d.now = func() time.Time { return now }
+	return d
+}
+
+func TestFlush(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	payload := `{"id":"evt_1","type":"student.created","data":{"id":7}}`
+
+	var signature, timestamp, body string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, signature, timestamp = string(b), r.Header.Get("X-Webhook-Signature"), r.Header.Get("X-Webhook-Timestamp")
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	m := &mockStorage{
+		due: []types.WebhookDelivery{
+			{Id: 1, WebhookID: 1, EventType: "student.created", Payload: payload, Status: types.DeliveryPending, URL: ok.URL, Secret: "s3cret"},
+			{Id: 2, WebhookID: 2, EventType: "student.created", Payload: payload, Status: types.DeliveryPending, URL: failing.URL},
+			{Id: 3, WebhookID: 2, EventType: "student.created", Payload: payload, Status: types.DeliveryPending, Attempts: 2, URL: failing.URL},
+		},
+		updated: map[int64]types.WebhookDelivery{},
+	}
+	if err := newTestDispatcher(m, now).Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	delivered := m.updated[1]
+	if delivered.Status != types.DeliveryDelivered || delivered.ResponseStatus != http.StatusOK || delivered.DeliveredAt == nil {
+		t.Errorf("delivery 1: got %+v", delivered)
+	}
+	if body != payload {
+		t.Errorf("body = %q, want %q", body, payload)
+	}
+	if want := Sign("s3cret", timestamp, []byte(payload)); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+
+	retry := m.updated[2]
+	if retry.Status != types.DeliveryPending || retry.Attempts != 1 || retry.ResponseStatus != http.StatusServiceUnavailable {
+		t.Errorf("delivery 2: got %+v", retry)
+	}
+	if want := now.Add(time.Minute); !retry.NextAttemptAt.Equal(want) {
+		t.Errorf("delivery 2 retries at %v, want %v", retry.NextAttemptAt, want)
+	}
+
+	if dead := m.updated[3]; dead.Status != types.DeliveryDead || dead.Attempts != 3 || dead.LastError == "" {
+		t.Errorf("delivery 3: got %+v, want dead", dead)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{8, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := Backoff(30*time.Second, 10*time.Minute, tt.attempts); got != tt.want {
+			t.Errorf("Backoff after %d attempts = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, event := range []string{"*", "student.created", "attendance.deleted", "leave_request.reviewed", "attachment.created"} {
+		if !Valid(event) {
+			t.Errorf("%q rejected", event)
+		}
+	}
+	for _, event := range []string{"", "student", "student.*"} {
+		if Valid(event) {
+			t.Errorf("%q accepted", event)
+		}
+	}
+}
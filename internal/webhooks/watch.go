@@ -0,0 +1,273 @@
+package webhooks
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tukesh1/student-api/internal/logger"
+	"github.com/tukesh1/student-api/internal/storage"
+	"github.com/tukesh1/student-api/internal/types"
+)
+
+// Watch wraps storage so that every successful mutation queues an event
+// for the webhooks subscribed to it. Queueing is one insert; the HTTP
+// delivery happens later on the Dispatcher, so writes never wait on a
+// subscriber. Errors are logged and never fail the write.
+//
+// Students, classes and attendance are not watched here: the storage
+// records them in its outbox and they arrive through Publish. Derived and
+// internal writes raise no events: alert evaluation, the notification
+// queue, guardian passwords and sessions.
+func (d *Dispatcher) Watch(s storage.Storage) storage.Storage {
+	return &watched{Storage: s, dispatcher: d}
+}
+
+type watched struct {
+	storage.Storage
+	dispatcher *Dispatcher
+}
+
+// emit queues an event. The request may be gone by the time the write
+// returns, so queueing does not inherit its cancellation.
+func (w *watched) emit(ctx context.Context, eventType string, data any) {
+	ctx = context.WithoutCancel(ctx)
+	event := Event{ID: newEventID(), Type: eventType, CreatedAt: w.dispatcher.now().UTC(), Data: data}
+	if err := w.dispatcher.enqueue(ctx, event); err != nil {
+		logger.FromContext(ctx).Error("error queueing webhook event", slog.String("event", eventType), slog.String("error", err.Error()))
+	}
+}
+
+// emitFetched queues an event carrying the resource as it now reads,
+// falling back to its id if it cannot be read
+func emitFetched[T any](ctx context.Context, w *watched, eventType string, id int64, get func(context.Context, int64) (T, error)) {
+	resource, err := get(ctx, id)
+	if err != nil {
+		w.emit(ctx, eventType, deleted{id})
+		return
+	}
+	w.emit(ctx, eventType, resource)
+}
+
+// deleted is the event data for a resource that no longer exists
+type deleted struct {
+	Id int64 `json:"id"`
+}
+
+func (w *watched) CreatePeriod(ctx context.Context, name, startTime, endTime string) (int64, error) {
+	id, err := w.Storage.CreatePeriod(ctx, name, startTime, endTime)
+	if err == nil {
+		emitFetched(ctx, w, "period.created", id, w.Storage.GetPeriodById)
+	}
+	return id, err
+}
+
+func (w *watched) UpdatePeriod(ctx context.Context, id int64, name, startTime, endTime string) error {
+	err := w.Storage.UpdatePeriod(ctx, id, name, startTime, endTime)
+	if err == nil {
+		emitFetched(ctx, w, "period.updated", id, w.Storage.GetPeriodById)
+	}
+	return err
+}
+
+func (w *watched) DeletePeriod(ctx context.Context, id int64) error {
+	err := w.Storage.DeletePeriod(ctx, id)
+	if err == nil {
+		w.emit(ctx, "period.deleted", deleted{id})
+	}
+	return err
+}
+
+func (w *watched) CreateSubject(ctx context.Context, name, code string) (int64, error) {
+	id, err := w.Storage.CreateSubject(ctx, name, code)
+	if err == nil {
+		w.emit(ctx, "subject.created", types.Subject{Id: id, Name: name, Code: code})
+	}
+	return id, err
+}
+
+func (w *watched) DeleteSubject(ctx context.Context, id int64) error {
+	err := w.Storage.DeleteSubject(ctx, id)
+	if err == nil {
+		w.emit(ctx, "subject.deleted", deleted{id})
+	}
+	return err
+}
+
+func (w *watched) SetTimetable(ctx context.Context, classID int64, entries []types.TimetableEntry) error {
+	err := w.Storage.SetTimetable(ctx, classID, entries)
+	if err == nil {
+		if timetable, err := w.Storage.GetTimetable(ctx, classID); err == nil {
+			entries = timetable
+		}
+		w.emit(ctx, "timetable.updated", struct {
+			ClassID int64                  `json:"class_id"`
+			Entries []types.TimetableEntry `json:"entries"`
+		}{classID, entries})
+	}
+	return err
+}
+
+func (w *watched) CreateAcademicYear(ctx context.Context, name string, startDate, endDate time.Time) (int64, error) {
+	id, err := w.Storage.CreateAcademicYear(ctx, name, startDate, endDate)
+	if err == nil {
+		emitFetched(ctx, w, "academic_year.created", id, w.Storage.GetAcademicYearById)
+	}
+	return id, err
+}
+
+func (w *watched) DeleteAcademicYear(ctx context.Context, id int64) error {
+	err := w.Storage.DeleteAcademicYear(ctx, id)
+	if err == nil {
+		w.emit(ctx, "academic_year.deleted", deleted{id})
+	}
+	return err
+}
+
+func (w *watched) CreateTerm(ctx context.Context, academicYearID int64, name string, startDate, endDate time.Time) (int64, error) {
+	id, err := w.Storage.CreateTerm(ctx, academicYearID, name, startDate, endDate)
+	if err == nil {
+		w.emit(ctx, "term.created", types.Term{Id: id, AcademicYearID: academicYearID, Name: name, StartDate: startDate, EndDate: endDate})
+	}
+	return id, err
+}
+
+func (w *watched) DeleteTerm(ctx context.Context, id int64) error {
+	err := w.Storage.DeleteTerm(ctx, id)
+	if err == nil {
+		w.emit(ctx, "term.deleted", deleted{id})
+	}
+	return err
+}
+
+func (w *watched) CreateClosure(ctx context.Context, name, kind string, startDate, endDate time.Time) (int64, error) {
+	id, err := w.Storage.CreateClosure(ctx, name, kind, startDate, endDate)
+	if err == nil {
+		w.emit(ctx, "closure.created", types.Closure{Id: id, Name: name, Kind: kind, StartDate: startDate, EndDate: endDate})
+	}
+	return id, err
+}
+
+func (w *watched) DeleteClosure(ctx context.Context, id int64) error {
+	err := w.Storage.DeleteClosure(ctx, id)
+	if err == nil {
+		w.emit(ctx, "closure.deleted", deleted{id})
+	}
+	return err
+}
+
+func (w *watched) ImportClosures(ctx context.Context, closures []types.Closure) ([]int64, error) {
+	ids, err := w.Storage.ImportClosures(ctx, closures)
+	if err == nil {
+		for i, id := range ids {
+			closure := closures[i]
+			closure.Id = id
+			w.emit(ctx, "closure.created", closure)
+		}
+	}
+	return ids, err
+}
+
+func (w *watched) CreateGuardian(ctx context.Context, guardian types.Guardian) (int64, error) {
+	id, err := w.Storage.CreateGuardian(ctx, guardian)
+	if err == nil {
+		emitFetched(ctx, w, "guardian.created", id, w.Storage.GetGuardianById)
+	}
+	return id, err
+}
+
+func (w *watched) UpdateGuardian(ctx context.Context, id int64, guardian types.Guardian) error {
+	err := w.Storage.UpdateGuardian(ctx, id, guardian)
+	if err == nil {
+		emitFetched(ctx, w, "guardian.updated", id, w.Storage.GetGuardianById)
+	}
+	return err
+}
+
+func (w *watched) DeleteGuardian(ctx context.Context, id int64) error {
+	err := w.Storage.DeleteGuardian(ctx, id)
+	if err == nil {
+		w.emit(ctx, "guardian.deleted", deleted{id})
+	}
+	return err
+}
+
+// guardianLink is the event data for linking and unlinking a guardian
+type guardianLink struct {
+	StudentID    int64  `json:"student_id"`
+	GuardianID   int64  `json:"guardian_id"`
+	Relationship string `json:"relationship,omitempty"`
+	IsPrimary    bool   `json:"is_primary"`
+}
+
+func (w *watched) LinkGuardian(ctx context.Context, studentID, guardianID int64, relationship string, primary bool) error {
+	err := w.Storage.LinkGuardian(ctx, studentID, guardianID, relationship, primary)
+	if err == nil {
+		w.emit(ctx, "guardian.linked", guardianLink{studentID, guardianID, relationship, primary})
+	}
+	return err
+}
+
+func (w *watched) UnlinkGuardian(ctx context.Context, studentID, guardianID int64) error {
+	err := w.Storage.UnlinkGuardian(ctx, studentID, guardianID)
+	if err == nil {
+		w.emit(ctx, "guardian.unlinked", guardianLink{StudentID: studentID, GuardianID: guardianID})
+	}
+	return err
+}
+
+func (w *watched) CreateEnrollment(ctx context.Context, studentID, classID, subjectID int64, startDate, endDate time.Time) (int64, error) {
+	id, err := w.Storage.CreateEnrollment(ctx, studentID, classID, subjectID, startDate, endDate)
+	if err == nil {
+		emitFetched(ctx, w, "enrollment.created", id, w.Storage.GetEnrollmentById)
+	}
+	return id, err
+}
+
+func (w *watched) UpdateEnrollment(ctx context.Context, id int64, status string, endDate time.Time) error {
+	err := w.Storage.UpdateEnrollment(ctx, id, status, endDate)
+	if err == nil {
+		emitFetched(ctx, w, "enrollment.updated", id, w.Storage.GetEnrollmentById)
+	}
+	return err
+}
+
+func (w *watched) CreateLeaveRequest(ctx context.Context, leave types.LeaveRequest) (int64, error) {
+	id, err := w.Storage.CreateLeaveRequest(ctx, leave)
+	if err == nil {
+		emitFetched(ctx, w, "leave_request.created", id, w.Storage.GetLeaveRequestById)
+	}
+	return id, err
+}
+
+func (w *watched) ReviewLeaveRequest(ctx context.Context, id int64, status, reviewedBy, note string) (int, error) {
+	excused, err := w.Storage.ReviewLeaveRequest(ctx, id, status, reviewedBy, note)
+	if err == nil {
+		emitFetched(ctx, w, "leave_request.reviewed", id, w.Storage.GetLeaveRequestById)
+	}
+	return excused, err
+}
+
+func (w *watched) CreateAttachment(ctx context.Context, attachment types.Attachment) (int64, error) {
+	id, err := w.Storage.CreateAttachment(ctx, attachment)
+	if err == nil {
+		emitFetched(ctx, w, "attachment.created", id, w.Storage.GetAttachmentById)
+	}
+	return id, err
+}
+
+func (w *watched) DeleteAttachment(ctx context.Context, id int64) error {
+	err := w.Storage.DeleteAttachment(ctx, id)
+	if err == nil {
+		w.emit(ctx, "attachment.deleted", deleted{id})
+	}
+	return err
+}
+
+func (w *watched) UpdateAlertStatus(ctx context.Context, id int64, status, note string) error {
+	err := w.Storage.UpdateAlertStatus(ctx, id, status, note)
+	if err == nil {
+		emitFetched(ctx, w, "alert.updated", id, w.Storage.GetAlertById)
+	}
+	return err
+}
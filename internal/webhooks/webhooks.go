@@ -0,0 +1,82 @@
+// Package webhooks tells subscribers about changes to the data. Every
+// mutation becomes an Event, either published from the storage outbox or
+// raised by the wrapped storage, and is queued as one delivery per
+// subscribed webhook; a Dispatcher posts the queue in the background,
+// signed with each webhook's secret, retrying with exponential backoff
+// until the delivery succeeds or is dead-lettered.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// Event types a webhook can subscribe to; "*" subscribes to all of them
+var Events = []string{
+	"student.created", "student.updated", "student.deleted",
+	"class.created", "class.updated", "class.deleted",
+	"teacher.created", "teacher.updated", "teacher.deleted",
+	"attendance.created", "attendance.updated", "attendance.deleted",
+	"period.created", "period.updated", "period.deleted",
+	"subject.created", "subject.deleted",
+	"timetable.updated",
+	"academic_year.created", "academic_year.deleted",
+	"term.created", "term.deleted",
+	"closure.created", "closure.deleted",
+	"guardian.created", "guardian.updated", "guardian.deleted", "guardian.linked", "guardian.unlinked",
+	"enrollment.created", "enrollment.updated",
+	"alert.updated",
+	"leave_request.created", "leave_request.reviewed",
+	"attachment.created", "attachment.deleted",
+}
+
+// AllEvents subscribes a webhook to every event type
+const AllEvents = "*"
+
+// Valid reports whether eventType can be subscribed to
+func Valid(eventType string) bool {
+	return eventType == AllEvents || slices.Contains(Events, eventType)
+}
+
+// Event is the JSON body posted to webhooks. Data is the resource as it
+// reads after the change, or just its id when it was deleted. Seq is set on
+// events from the outbox and orders them, as deliveries may arrive out of
+// order.
+type Event struct {
+	Seq       int64     `json:"seq,omitempty"`
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Sign returns the X-Webhook-Signature header for a payload: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed by the webhook secret.
+// Receivers recompute it to check the payload came from us, and reject
+// old timestamps to stop replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random signing secret for a webhook
+func NewSecret() string {
+	return "whsec_" + random(24)
+}
+
+func newEventID() string {
+	return "evt_" + random(16)
+}
+
+func random(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}